@@ -0,0 +1,115 @@
+package chess
+
+import "math/bits"
+
+// Precomputed attack tables, indexed by square (rank*8 + file, where file 0 is the h-file)
+var (
+	knightAttacks    [64]uint64
+	kingAttacks      [64]uint64
+	whitePawnAttacks [64]uint64
+	blackPawnAttacks [64]uint64
+)
+
+func init() {
+	onBoard := func(rank, file int) bool {
+		return rank >= 0 && rank < 8 && file >= 0 && file < 8
+	}
+
+	knightOffsets := [8][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingOffsets := [8][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+
+	for i := range 64 {
+		rank, file := i/8, i%8
+
+		for _, o := range knightOffsets {
+			if onBoard(rank+o[0], file+o[1]) {
+				knightAttacks[i] |= uint64(1) << Index(rank+o[0], file+o[1])
+			}
+		}
+
+		for _, o := range kingOffsets {
+			if onBoard(rank+o[0], file+o[1]) {
+				kingAttacks[i] |= uint64(1) << Index(rank+o[0], file+o[1])
+			}
+		}
+
+		for _, df := range []int{-1, 1} {
+			if onBoard(rank+1, file+df) {
+				whitePawnAttacks[i] |= uint64(1) << Index(rank+1, file+df)
+			}
+			if onBoard(rank-1, file+df) {
+				blackPawnAttacks[i] |= uint64(1) << Index(rank-1, file+df)
+			}
+		}
+	}
+}
+
+// Casts a ray from i in the direction (dRank, dFile), stopping at (and including) the first occupied square
+func slidingRay(i, dRank, dFile int, occupied uint64) uint64 {
+	var ray uint64
+	rank, file := i/8+dRank, i%8+dFile
+	for rank >= 0 && rank < 8 && file >= 0 && file < 8 {
+		m := uint64(1) << Index(rank, file)
+		ray |= m
+		if occupied&m != 0 {
+			break
+		}
+		rank += dRank
+		file += dFile
+	}
+	return ray
+}
+
+func rookAttacks(i int, occupied uint64) uint64 {
+	return slidingRay(i, 1, 0, occupied) |
+		slidingRay(i, -1, 0, occupied) |
+		slidingRay(i, 0, 1, occupied) |
+		slidingRay(i, 0, -1, occupied)
+}
+
+func bishopAttacks(i int, occupied uint64) uint64 {
+	return slidingRay(i, 1, 1, occupied) |
+		slidingRay(i, 1, -1, occupied) |
+		slidingRay(i, -1, 1, occupied) |
+		slidingRay(i, -1, -1, occupied)
+}
+
+func (b *Board) whitePieces() uint64 {
+	return b.whitePawns | b.whiteRooks | b.whiteKnights | b.whiteBishops | b.whiteQueens | b.whiteKings
+}
+
+func (b *Board) blackPieces() uint64 {
+	return b.blackPawns | b.blackRooks | b.blackKnights | b.blackBishops | b.blackQueens | b.blackKings
+}
+
+func (b *Board) occupied() uint64 {
+	return b.whitePieces() | b.blackPieces()
+}
+
+// Returns every piece (of either colour) attacking square i, given the occupancy
+// Sliding attacks are calculated using occupied, so removing pieces from it reveals x-ray attackers
+func (b *Board) attackersTo(i int, occupied uint64) uint64 {
+	var (
+		rooksQueens   = b.whiteRooks | b.blackRooks | b.whiteQueens | b.blackQueens
+		bishopsQueens = b.whiteBishops | b.blackBishops | b.whiteQueens | b.blackQueens
+	)
+
+	return (blackPawnAttacks[i] & b.whitePawns) |
+		(whitePawnAttacks[i] & b.blackPawns) |
+		(knightAttacks[i] & (b.whiteKnights | b.blackKnights)) |
+		(kingAttacks[i] & (b.whiteKings | b.blackKings)) |
+		(rookAttacks(i, occupied) & rooksQueens) |
+		(bishopAttacks(i, occupied) & bishopsQueens)
+}
+
+// Returns whether the king of the side to move is attacked
+func (b *Board) InCheck() bool {
+	kings, enemies := b.whiteKings, b.blackPieces()
+	if b.Turn == BlackTurn {
+		kings, enemies = b.blackKings, b.whitePieces()
+	}
+	if kings == 0 {
+		return false
+	}
+	return b.attackersTo(bits.TrailingZeros64(kings), b.occupied())&enemies != 0
+}
@@ -0,0 +1,30 @@
+package chess_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestBenchPositions(t *testing.T) {
+	for _, fen := range chess.BenchPositions {
+		b, err := chess.BoardFromFEN(fen)
+		require.NoError(t, err, fen)
+		_, status := b.LegalMoves()
+		assert.Equal(t, chess.InProgress, status, fen)
+	}
+}
+
+// Any change to the search or evaluation which alters the node count must update the signature here, so behaviour
+// changes are never made by accident
+func TestBenchSignature(t *testing.T) {
+	if testing.Short() {
+		t.Skip("searches every bench position")
+	}
+
+	e := &chess.Engine{PT: *chess.NewPawnTable(12), EP: chess.DefaultParams}
+	r := e.Bench(chess.BenchDepth)
+	assert.Equal(t, 713759, r.Nodes)
+}
@@ -45,6 +45,25 @@ type Board struct {
 	noisyMoves    []int
 	CanEnPassant  bool
 	EnPassantFile int // if last move was a double push, holds file
+
+	// En passant state of the starting position, restored when unmoving the first move
+	initialCanEnPassant  bool
+	initialEnPassantFile int
+
+	// Told about every move and unmove, so evaluators can update incrementally
+	observer MoveObserver
+}
+
+// Hooks for anything which needs to keep in step with a board, see Board.SetObserver
+type MoveObserver interface {
+	Moved(b *Board, m Move)   // called once m has been applied
+	Unmoved(b *Board, m Move) // called once m has been taken back
+}
+
+// Tells o about every move made and unmade on b from now on, nil stops it
+// Copies made with Copy don't keep the observer
+func (b *Board) SetObserver(o MoveObserver) {
+	b.observer = o
 }
 
 // Returns a board in the proper starting configuration
@@ -72,7 +91,7 @@ func NewBoard() Board {
 }
 
 func BoardFromFEN(fen string) (Board, error) {
-	parts := strings.Split(fen, " ")
+	parts := strings.Fields(fen)
 	if len(parts) != 6 && len(parts) != 4 {
 		return Board{}, ErrInvalidFEN
 	}
@@ -82,58 +101,69 @@ func BoardFromFEN(fen string) (Board, error) {
 		noisyMoves: []int{},
 	}
 
-	pieces := parts[0]
-
-	i := 0
-	for _, symbol := range pieces {
-		posMask := uint64(1) << (63 - i)
-		switch symbol {
-		case '/':
-			continue
-		case 'P':
-			b.whitePawns |= posMask
-		case 'R':
-			b.whiteRooks |= posMask
-		case 'N':
-			b.whiteKnights |= posMask
-		case 'B':
-			b.whiteBishops |= posMask
-		case 'Q':
-			b.whiteQueens |= posMask
-		case 'K':
-			b.whiteKings |= posMask
-		case 'p':
-			b.blackPawns |= posMask
-		case 'r':
-			b.blackRooks |= posMask
-		case 'n':
-			b.blackKnights |= posMask
-		case 'b':
-			b.blackBishops |= posMask
-		case 'q':
-			b.blackQueens |= posMask
-		case 'k':
-			b.blackKings |= posMask
-		case '1':
-
-		case '2':
-			i += 1
-		case '3':
-			i += 2
-		case '4':
-			i += 3
-		case '5':
-			i += 4
-		case '6':
-			i += 5
-		case '7':
-			i += 6
-		case '8':
-			i += 7
-		default:
+	ranks := strings.Split(parts[0], "/")
+	if len(ranks) != 8 {
+		return Board{}, ErrInvalidFEN
+	}
+
+	for r, rank := range ranks {
+		i := r * 8
+		for _, symbol := range rank {
+			if i >= (r+1)*8 {
+				return Board{}, ErrInvalidFEN
+			}
+
+			posMask := uint64(1) << (63 - i)
+			switch symbol {
+			case 'P':
+				b.whitePawns |= posMask
+			case 'R':
+				b.whiteRooks |= posMask
+			case 'N':
+				b.whiteKnights |= posMask
+			case 'B':
+				b.whiteBishops |= posMask
+			case 'Q':
+				b.whiteQueens |= posMask
+			case 'K':
+				b.whiteKings |= posMask
+			case 'p':
+				b.blackPawns |= posMask
+			case 'r':
+				b.blackRooks |= posMask
+			case 'n':
+				b.blackKnights |= posMask
+			case 'b':
+				b.blackBishops |= posMask
+			case 'q':
+				b.blackQueens |= posMask
+			case 'k':
+				b.blackKings |= posMask
+			case '1':
+
+			case '2':
+				i += 1
+			case '3':
+				i += 2
+			case '4':
+				i += 3
+			case '5':
+				i += 4
+			case '6':
+				i += 5
+			case '7':
+				i += 6
+			case '8':
+				i += 7
+			default:
+				return Board{}, ErrInvalidFEN
+			}
+			i++
+		}
+
+		if i != (r+1)*8 {
 			return Board{}, ErrInvalidFEN
 		}
-		i++
 	}
 
 	active := parts[1]
@@ -146,6 +176,9 @@ func BoardFromFEN(fen string) (Board, error) {
 		return Board{}, ErrInvalidFEN
 	}
 
+	if parts[2] == "" || (parts[2] != "-" && strings.Trim(parts[2], "KQkq") != "") {
+		return Board{}, ErrInvalidFEN
+	}
 	b.CastleRights = CastleRightsFromString(parts[2])
 
 	enPassantTarget := parts[3]
@@ -156,13 +189,16 @@ func BoardFromFEN(fen string) (Board, error) {
 		}
 		b.CanEnPassant = true
 		b.EnPassantFile = i % 8
+		b.initialCanEnPassant = true
+		b.initialEnPassantFile = i % 8
 	}
 
 	if len(parts) == 6 {
 		fullMoveClock, err := strconv.Atoi(parts[5])
-		if err != nil {
+		if err != nil || fullMoveClock < 0 {
 			return Board{}, ErrInvalidFEN
 		}
+		fullMoveClock = max(fullMoveClock, 1) // plenty of FENs in the wild use 0
 		if b.Turn == WhiteTurn {
 			b.HalfMoves = (fullMoveClock - 1) * 2
 		} else {
@@ -170,15 +206,81 @@ func BoardFromFEN(fen string) (Board, error) {
 		}
 
 		halfMoveClock, err := strconv.Atoi(parts[4])
-		if err != nil {
+		if err != nil || halfMoveClock < 0 {
 			return Board{}, ErrInvalidFEN
 		}
-		b.noisyMoves = append(b.noisyMoves, b.HalfMoves-halfMoveClock)
+		b.noisyMoves = append(b.noisyMoves, b.HalfMoves-halfMoveClock-1)
+	}
+
+	if !b.isValid() {
+		return Board{}, ErrInvalidFEN
 	}
 
+	b.removeUnusableCastleRights()
+
 	return b, nil
 }
 
+// Checks the position could have been reached in a legal game, as far as move generation is concerned
+// i.e. one king each, no pawns on the back ranks, the side not to move isn't in check, and any en passant target is plausible
+func (b *Board) isValid() bool {
+	if bits.OnesCount64(b.whiteKings) != 1 || bits.OnesCount64(b.blackKings) != 1 {
+		return false
+	}
+
+	backRanks := uint64(0xff000000000000ff)
+	if (b.whitePawns|b.blackPawns)&backRanks != 0 {
+		return false
+	}
+
+	b.Turn = !b.Turn
+	opponentInCheck := b.InCheck()
+	b.Turn = !b.Turn
+	if opponentInCheck {
+		return false
+	}
+
+	if b.CanEnPassant {
+		// The pawn which just double pushed, and the squares it passed through
+		pawn, passed, from := Index(4, b.EnPassantFile), Index(5, b.EnPassantFile), Index(6, b.EnPassantFile)
+		enemyPawns := b.blackPawns
+		if b.Turn == BlackTurn {
+			pawn, passed, from = Index(3, b.EnPassantFile), Index(2, b.EnPassantFile), Index(1, b.EnPassantFile)
+			enemyPawns = b.whitePawns
+		}
+
+		if enemyPawns&(uint64(1)<<pawn) == 0 || b.occupied()&(uint64(1)<<passed|uint64(1)<<from) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Castle rights are kept in FENs even when the king or rook has left its home square, they would otherwise produce bogus castling moves
+func (b *Board) removeUnusableCastleRights() {
+	if b.whiteKings != 1<<3 {
+		b.CastleRights.LoseWhiteKing()
+		b.CastleRights.LoseWhiteQueen()
+	}
+	if b.whiteRooks&(1<<0) == 0 {
+		b.CastleRights.LoseWhiteKing()
+	}
+	if b.whiteRooks&(1<<7) == 0 {
+		b.CastleRights.LoseWhiteQueen()
+	}
+	if b.blackKings != 1<<59 {
+		b.CastleRights.LoseBlackKing()
+		b.CastleRights.LoseBlackQueen()
+	}
+	if b.blackRooks&(1<<56) == 0 {
+		b.CastleRights.LoseBlackKing()
+	}
+	if b.blackRooks&(1<<63) == 0 {
+		b.CastleRights.LoseBlackQueen()
+	}
+}
+
 func BoardFromRanks(rs [8]string, turn Turn, castleRights CastleRights) Board {
 	b := Board{
 		Turn:         turn,
@@ -229,7 +331,7 @@ func (b *Board) Zobrist() uint64 {
 
 	applyBitboard := func(bb uint64, zobristVals [64]uint64) {
 		for bb != 0 {
-			i := bits.LeadingZeros64(bb)
+			i := bits.TrailingZeros64(bb)
 			z ^= zobristVals[i]
 			bb &= bb - 1
 		}
@@ -262,8 +364,23 @@ func (b *Board) Zobrist() uint64 {
 	return z
 }
 
+// Zobrist key of the pawns alone, used to index the PawnTable
+func (b *Board) PawnZobrist() uint64 {
+	var z uint64
+
+	for bb := b.whitePawns; bb != 0; bb &= bb - 1 {
+		z ^= whitePawnZobrist[bits.TrailingZeros64(bb)]
+	}
+	for bb := b.blackPawns; bb != 0; bb &= bb - 1 {
+		z ^= blackPawnZobrist[bits.TrailingZeros64(bb)]
+	}
+
+	return z
+}
+
 func (b *Board) Copy() Board {
 	c := *b
+	c.observer = nil // the observer is tracking b, not the copy
 	c.Moves = slices.Clone(b.Moves)
 	c.noisyMoves = slices.Clone(b.noisyMoves)
 	return c
@@ -352,6 +469,52 @@ func (b *Board) RankStrings() [8]string {
 	return r
 }
 
+// Returns the position in Forsyth-Edwards Notation, which BoardFromFEN reads back
+// The en passant square is given after every double push, whether or not a capture is possible
+func (b *Board) FEN() string {
+	var s strings.Builder
+	for r, rank := range b.RankStrings() {
+		if r > 0 {
+			s.WriteByte('/')
+		}
+		empty := 0
+		for _, c := range []byte(rank) {
+			if c == ' ' {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				s.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			s.WriteByte(c)
+		}
+		if empty > 0 {
+			s.WriteString(strconv.Itoa(empty))
+		}
+	}
+
+	if b.Turn == WhiteTurn {
+		s.WriteString(" w ")
+	} else {
+		s.WriteString(" b ")
+	}
+	s.WriteString(b.CastleRights.String())
+
+	if b.CanEnPassant {
+		rank := 5
+		if b.Turn == BlackTurn {
+			rank = 2
+		}
+		s.WriteString(" " + AlgebraicFromIndex(Index(rank, b.EnPassantFile)))
+	} else {
+		s.WriteString(" -")
+	}
+
+	s.WriteString(" " + strconv.Itoa(b.QuietMoveCounter()) + " " + strconv.Itoa(b.HalfMoves/2+1))
+	return s.String()
+}
+
 // Returns a string representation of the board
 // Assumes the bitboards are in a valid state
 func (b *Board) String() string {
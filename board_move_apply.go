@@ -1,6 +1,7 @@
 package chess
 
 // WARN: must follow format <from square><to square>[<promoted to>]
+// The move is not checked for legality, use TryMove for untrusted input
 func (b *Board) DoAlgebraicMove(s string) error {
 	from, to, promotion, err := ParseAlgebraicMove(s)
 	if err != nil {
@@ -12,7 +13,7 @@ func (b *Board) DoAlgebraicMove(s string) error {
 }
 
 // Applies given move
-// Assumes it is valid and legal
+// Assumes it is valid and legal, use TryCoordinateMove for untrusted input
 func (b *Board) DoCoordinateMove(from, to int, promotion Promotion) {
 	pieceType := b.pieceType(from)
 	capture := b.pieceType(to).ToCapture()
@@ -72,6 +73,7 @@ func (b *Board) Move(m Move) {
 		b.EnPassantFile = int(m.ToFile())
 	} else {
 		b.CanEnPassant = false
+		b.EnPassantFile = 0
 	}
 
 	if b.Turn == WhiteTurn {
@@ -219,6 +221,10 @@ func (b *Board) Move(m Move) {
 	}
 
 	b.Turn = !b.Turn
+
+	if b.observer != nil {
+		b.observer.Moved(b, m)
+	}
 }
 
 func (b *Board) Unmove() {
@@ -242,7 +248,11 @@ func (b *Board) Unmove() {
 		b.CanEnPassant = lastMove.IsDoublePush()
 		b.EnPassantFile = int(lastMove.ToFile())
 	} else {
-		b.CanEnPassant = false
+		b.CanEnPassant = b.initialCanEnPassant
+		b.EnPassantFile = b.initialEnPassantFile
+	}
+	if !b.CanEnPassant {
+		b.EnPassantFile = 0
 	}
 
 	var from uint32 = m.From()
@@ -372,4 +382,8 @@ func (b *Board) Unmove() {
 			b.whitePawns ^= toMask << 8
 		}
 	}
+
+	if b.observer != nil {
+		b.observer.Unmoved(b, m)
+	}
 }
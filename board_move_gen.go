@@ -428,10 +428,7 @@ func (b *Board) LegalMoves() ([]Move, GameStatus) {
 
 		var ray uint64 = 0
 		switch {
-		case fileDiff == 0 && rankDiff > 0 && b.Turn != WhiteTurn: // north, on white turn, an enpassant will block nort ray
-			ray = northRay(kingIndex, occupied&^(kings|capturedPiece), 0) & orthogonalSlidingEnemies
-		case fileDiff == 0 && rankDiff < 0 && b.Turn != BlackTurn: // south, on black turn, an en passant will block a south rau
-			ray = southRay(kingIndex, occupied&^(kings|capturedPiece), 0) & orthogonalSlidingEnemies
+		case fileDiff == 0: // north/south, the capturing pawn always lands between the king and any attacker on this file
 		case rankDiff == 0 && fileDiff < 0: // east
 			ray = eastRay(kingIndex, occupied&^(kings|capturingPawn|capturedPiece), 0) & orthogonalSlidingEnemies
 		case rankDiff == 0 && fileDiff > 0: // west
@@ -623,7 +620,8 @@ func (b *Board) LegalMoves() ([]Move, GameStatus) {
 		}
 	}
 
-	if b.QuietMoveCounter() == 50 {
+	// 50 moves by each side
+	if b.QuietMoveCounter() >= 100 {
 		return ms, Draw
 	}
 
@@ -154,3 +154,73 @@ func TestPerft(t *testing.T) {
 		})
 	}
 }
+
+func TestPerftEdgeCases(t *testing.T) {
+	tests := []struct {
+		Name  string
+		FEN   string
+		Depth int
+		Nodes int
+	}{
+		{"Illegal en passant (discovered check)", "3k4/3p4/8/K1P4r/8/8/8/8 b - - 0 1", 6, 1134888},
+		{"Illegal en passant (pinned)", "8/8/4k3/8/2p5/8/B2P2K1/8 w - - 0 1", 6, 1015133},
+		{"En passant gives check", "8/8/1k6/2b5/2pP4/8/5K2/8 b - d3 0 1", 6, 1440467},
+		{"En passant blocks a check on the same file", "2K5/8/3p4/1Pp5/1R3p1k/8/4P1P1/2r5 w - c6 0 4", 5, 1517989},
+		{"Short castling gives check", "5k2/8/8/8/8/8/8/4K2R w K - 0 1", 6, 661072},
+		{"Long castling gives check", "3k4/8/8/8/8/8/8/R3K3 w Q - 0 1", 6, 803711},
+		{"Castling rights", "r3k2r/1b4bq/8/8/8/8/7B/R3K2R w KQkq - 0 1", 4, 1274206},
+		{"Castling prevented", "r3k2r/8/3Q4/8/8/5q2/8/R3K2R b KQkq - 0 1", 4, 1720476},
+		{"Promote out of check", "2K2r2/4P3/8/8/8/8/8/3k4 w - - 0 1", 6, 3821001},
+		{"Discovered check", "8/8/1P2K3/8/2n5/1q6/8/5k2 b - - 0 1", 5, 1004658},
+		{"Promote to give check", "4k3/1P6/8/8/8/8/K7/8 w - - 0 1", 6, 217342},
+		{"Underpromote to give check", "8/P1k5/K7/8/8/8/8/8 w - - 0 1", 6, 92683},
+		{"Self stalemate", "K1k5/8/P7/8/8/8/8/8 w - - 0 1", 6, 2217},
+		{"Stalemate and checkmate (white)", "8/k1P5/8/1K6/8/8/8/8 w - - 0 1", 7, 567584},
+		{"Stalemate and checkmate (black)", "8/8/2k5/5q2/5n2/8/5K2/8 b - - 0 1", 4, 23527},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			b, err := chess.BoardFromFEN(tt.FEN)
+			require.NoError(t, err, "BoardFromFen should not produce an error")
+
+			assert.Equal(t, tt.Nodes, perft(&b, tt.Depth))
+		})
+	}
+}
+
+// The quiet move counter is in plies, so the draw comes after 50 moves by each side, at 100
+func TestFiftyMoveRule(t *testing.T) {
+	tests := []struct {
+		fen    string
+		status chess.GameStatus
+	}{
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 99 80", chess.InProgress},
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 100 80", chess.Draw},
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 150 80", chess.Draw},
+		{"R3k3/8/4K3/8/8/8/8/8 b - - 100 80", chess.Checkmate}, // mate takes priority
+	}
+
+	for _, tt := range tests {
+		b, err := chess.BoardFromFEN(tt.fen)
+		require.NoError(t, err)
+		_, status := b.LegalMoves()
+		assert.Equal(t, tt.status, status, tt.fen)
+	}
+
+	// Counted from the last capture or pawn move played on the board, as well as the FEN's clock
+	b, err := chess.BoardFromFEN("4k3/8/8/8/8/8/8/R3K3 w - - 0 1")
+	require.NoError(t, err)
+	for i := range 100 {
+		_, status := b.LegalMoves()
+		require.Equal(t, chess.InProgress, status, "after %d plies", i)
+		m := []string{"Ra2", "Kd8", "Ra1", "Ke8"}[i%4]
+		if i%8 >= 4 {
+			m = []string{"Rb1", "Kf8", "Ra1", "Ke8"}[i%4]
+		}
+		_, err := b.TryMove(m)
+		require.NoError(t, err)
+	}
+	_, status := b.LegalMoves()
+	assert.Equal(t, chess.Draw, status)
+}
@@ -0,0 +1,239 @@
+package chess
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrIllegalMove   = errors.New("Illegal move")
+	ErrAmbiguousMove = errors.New("Ambiguous move")
+	ErrGameOver      = errors.New("Game is over")
+)
+
+// Parses, validates and applies a move in coordinate (e2e4, e2-e4, e7e8=Q), UCI (e7e8q) or SAN (Nf3, exd5, O-O) notation
+// The board is left unchanged if an error is returned
+func (b *Board) TryMove(s string) (Move, error) {
+	m, err := b.ParseMove(s)
+	if err != nil {
+		return 0, err
+	}
+	b.Move(m)
+	return m, nil
+}
+
+// Validates and applies a move given as squares
+// The board is left unchanged if an error is returned
+func (b *Board) TryCoordinateMove(from, to int, promotion Promotion) (Move, error) {
+	ms, err := b.legalMovesInProgress()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, m := range ms {
+		if int(m.From()) == from && int(m.To()) == to && m.Promotion() == promotion {
+			b.Move(m)
+			return m, nil
+		}
+	}
+	return 0, ErrIllegalMove
+}
+
+// Parses a move in coordinate, UCI or SAN notation, and checks it is legal
+func (b *Board) ParseMove(s string) (Move, error) {
+	if from, to, promotion, ok := parseCoordinateMove(s); ok {
+		ms, err := b.legalMovesInProgress()
+		if err != nil {
+			return 0, err
+		}
+
+		for _, m := range ms {
+			if int(m.From()) == from && int(m.To()) == to && m.Promotion() == promotion {
+				return m, nil
+			}
+		}
+		return 0, ErrIllegalMove
+	}
+
+	return b.ParseSAN(s)
+}
+
+func (b *Board) legalMovesInProgress() ([]Move, error) {
+	ms, status := b.LegalMoves()
+	if status != InProgress {
+		return nil, ErrGameOver
+	}
+	return ms, nil
+}
+
+// Accepts e2e4, e2-e4, e7e8q, e7e8=Q and e7-e8Q
+func parseCoordinateMove(s string) (from, to int, promotion Promotion, ok bool) {
+	s = strings.ReplaceAll(s, "-", "")
+	s = strings.ReplaceAll(s, "=", "")
+	if len(s) != 4 && len(s) != 5 {
+		return 0, 0, NoPromotion, false
+	}
+
+	from, to, promotion, err := ParseAlgebraicMove(s)
+	return from, to, promotion, err == nil
+}
+
+// Parses a move in Standard Algebraic Notation (e.g. e4, Nbd7, exd6, e8=Q+, O-O-O)
+// Check/mate markers and annotations (+, #, !, ?) are ignored, but the move must be legal
+func (b *Board) ParseSAN(s string) (Move, error) {
+	s = strings.TrimRight(s, "+#!?")
+	s = strings.TrimSuffix(s, " e.p.")
+	s = strings.TrimSuffix(s, "e.p.")
+	if s == "" {
+		return 0, ErrInvalidAlgebraicNotation
+	}
+
+	ms, err := b.legalMovesInProgress()
+	if err != nil {
+		return 0, err
+	}
+
+	switch s {
+	case "O-O", "0-0":
+		return findUniqueMove(ms, func(m Move) bool { return m.Castle() == KingCastle })
+	case "O-O-O", "0-0-0":
+		return findUniqueMove(ms, func(m Move) bool { return m.Castle() == QueenCastle })
+	}
+
+	pieceType := PawnType
+	if strings.ContainsRune("KQRBN", rune(s[0])) {
+		pieceType = PieceTypeFromRune(rune(s[0]))
+		s = s[1:]
+	}
+
+	promotion := NoPromotion
+	if i := strings.IndexByte(s, '='); i != -1 {
+		if i != len(s)-2 {
+			return 0, ErrInvalidAlgebraicNotation
+		}
+		if promotion, err = PromotionFromSymbol(rune(s[i+1])); err != nil || promotion == NoPromotion {
+			return 0, ErrInvalidAlgebraicNotation
+		}
+		s = s[:i]
+	} else if pieceType == PawnType && len(s) >= 3 && strings.ContainsRune("QRBN", rune(s[len(s)-1])) { // e8Q
+		promotion, _ = PromotionFromSymbol(rune(s[len(s)-1]))
+		s = s[:len(s)-1]
+	}
+
+	if len(s) < 2 {
+		return 0, ErrInvalidAlgebraicNotation
+	}
+	to, err := IndexFromAlgebraic(s[len(s)-2:])
+	if err != nil {
+		return 0, err
+	}
+
+	// Whatever is left is disambiguation, optionally followed by a capture marker
+	disambiguation := strings.TrimSuffix(s[:len(s)-2], "x")
+	fromFile, fromRank := -1, -1
+	for _, r := range disambiguation {
+		switch {
+		case r >= 'a' && r <= 'h' && fromFile == -1:
+			fromFile = int('h' - r)
+		case r >= '1' && r <= '8' && fromRank == -1:
+			fromRank = int(r - '1')
+		default:
+			return 0, ErrInvalidAlgebraicNotation
+		}
+	}
+
+	return findUniqueMove(ms, func(m Move) bool {
+		return m.PieceType() == pieceType &&
+			m.Castle() == NoCastle &&
+			int(m.To()) == to &&
+			m.Promotion() == promotion &&
+			(fromFile == -1 || int(m.FromFile()) == fromFile) &&
+			(fromRank == -1 || int(m.FromRank()) == fromRank)
+	})
+}
+
+func findUniqueMove(ms []Move, match func(Move) bool) (Move, error) {
+	found := Move(0)
+	n := 0
+	for _, m := range ms {
+		if match(m) {
+			found = m
+			n++
+		}
+	}
+
+	switch n {
+	case 0:
+		return 0, ErrIllegalMove
+	case 1:
+		return found, nil
+	default:
+		return 0, ErrAmbiguousMove
+	}
+}
+
+// Returns the Standard Algebraic Notation of m, which must be legal in this position
+func (b *Board) SAN(m Move) string {
+	var s strings.Builder
+
+	switch m.Castle() {
+	case KingCastle:
+		s.WriteString("O-O")
+	case QueenCastle:
+		s.WriteString("O-O-O")
+	default:
+		from := AlgebraicFromIndex(int(m.From()))
+		capture := m.Capture() != NoCapture || m.EnPassant()
+
+		if m.PieceType() == PawnType {
+			if capture {
+				s.WriteByte(from[0])
+			}
+		} else {
+			s.WriteRune(m.PieceType().Symbol(WhiteTurn))
+
+			// Disambiguate using the file if possible, then the rank, then both
+			ms, _ := b.LegalMoves()
+			ambiguous, sameFile, sameRank := false, false, false
+			for _, o := range ms {
+				if o == m || o.PieceType() != m.PieceType() || o.To() != m.To() {
+					continue
+				}
+				ambiguous = true
+				sameFile = sameFile || o.FromFile() == m.FromFile()
+				sameRank = sameRank || o.FromRank() == m.FromRank()
+			}
+			switch {
+			case !ambiguous:
+			case !sameFile:
+				s.WriteByte(from[0])
+			case !sameRank:
+				s.WriteByte(from[1])
+			default:
+				s.WriteString(from)
+			}
+		}
+
+		if capture {
+			s.WriteByte('x')
+		}
+		s.WriteString(AlgebraicFromIndex(int(m.To())))
+
+		if m.Promotion() != NoPromotion {
+			s.WriteByte('=')
+			s.WriteRune(m.Promotion().Symbol() - 'a' + 'A')
+		}
+	}
+
+	b.Move(m)
+	if b.InCheck() {
+		if _, status := b.LegalMoves(); status == Checkmate {
+			s.WriteByte('#')
+		} else {
+			s.WriteByte('+')
+		}
+	}
+	b.Unmove()
+
+	return s.String()
+}
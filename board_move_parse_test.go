@@ -0,0 +1,110 @@
+package chess_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestTryMove(t *testing.T) {
+	tests := []struct {
+		Name     string
+		FEN      string
+		Input    string
+		Expected string // coordinate notation
+		Err      error
+	}{
+		{"Coordinate", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4", "e2e4", nil},
+		{"Coordinate with dash", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "g1-f3", "g1f3", nil},
+		{"SAN pawn push", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "d4", "d2d4", nil},
+		{"SAN knight", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "Nc3", "b1c3", nil},
+		{"SAN with check marker", "rnbqkbnr/ppppp1pp/8/5p2/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "Qh5+", "d1h5", nil},
+		{"SAN capture", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "exd5", "e4d5", nil},
+		{"SAN en passant", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "exf6", "e5f6", nil},
+		{"SAN castle king side", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O", "e1g1", nil},
+		{"SAN castle queen side", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "0-0-0", "e8c8", nil},
+		{"UCI castle", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1c1", "e1c1", nil},
+		{"SAN promotion", "8/P6k/8/8/8/8/8/K7 w - - 0 1", "a8=Q", "a7a8q", nil},
+		{"SAN underpromotion", "8/P6k/8/8/8/8/8/K7 w - - 0 1", "a8N", "a7a8n", nil},
+		{"UCI promotion", "8/P6k/8/8/8/8/8/K7 w - - 0 1", "a7a8r", "a7a8r", nil},
+		{"Coordinate promotion", "8/P6k/8/8/8/8/8/K7 w - - 0 1", "a7-a8=B", "a7a8b", nil},
+		{"SAN file disambiguation", "4k3/8/8/8/8/8/K7/R6R w - - 0 1", "Rhd1", "h1d1", nil},
+		{"SAN rank disambiguation", "4k3/R7/8/8/8/8/8/R3K3 w - - 0 1", "R1a4", "a1a4", nil},
+		{"SAN square disambiguation", "7k/8/8/8/2Q1Q3/8/2Q5/4K3 w - - 0 1", "Qc4d3", "c4d3", nil},
+
+		{"Ambiguous", "4k3/8/8/8/8/8/K7/R6R w - - 0 1", "Rd1", "", chess.ErrAmbiguousMove},
+		{"Illegal coordinate", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e5", "", chess.ErrIllegalMove},
+		{"Illegal SAN", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "Nd2", "", chess.ErrIllegalMove},
+		{"Missing promotion", "8/P6k/8/8/8/8/8/K7 w - - 0 1", "a7a8", "", chess.ErrIllegalMove},
+		{"Pinned piece", "4k3/4r3/8/8/8/8/4N3/4K3 w - - 0 1", "Nc3", "", chess.ErrIllegalMove},
+		{"Game over", "7k/6Q1/6K1/8/8/8/8/8 b - - 0 1", "Kxg7", "", chess.ErrGameOver},
+		{"Garbage", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "hello", "", chess.ErrInvalidAlgebraicNotation},
+		{"Empty", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "", "", chess.ErrInvalidAlgebraicNotation},
+		{"Bad promotion", "8/P6k/8/8/8/8/8/K7 w - - 0 1", "a8=K", "", chess.ErrInvalidAlgebraicNotation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			b, err := chess.BoardFromFEN(tt.FEN)
+			require.NoError(t, err)
+			before := b.Zobrist()
+
+			m, err := b.TryMove(tt.Input)
+			if tt.Err != nil {
+				assert.ErrorIs(t, err, tt.Err)
+				assert.Equal(t, before, b.Zobrist(), "board should be unchanged")
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.Expected, m.String())
+			assert.Len(t, b.Moves, 1)
+		})
+	}
+}
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		FEN      string
+		Move     string
+		Expected string
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4", "e4"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "g1f3", "Nf3"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "e4d5", "exd5"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "e5f6", "exf6"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1c1", "O-O-O"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "a7a8q", "a8=Q"},
+		{"4k3/P7/8/8/8/8/8/K7 w - - 0 1", "a7a8q", "a8=Q+"},
+		{"4k3/8/8/8/8/8/K7/R6R w - - 0 1", "h1d1", "Rhd1"},
+		{"4k3/R7/8/8/8/8/8/R3K3 w - - 0 1", "a1a4", "R1a4"},
+		{"7k/8/8/8/2Q1Q3/8/2Q5/4K3 w - - 0 1", "c4d3", "Qc4d3"},
+		{"rnbqkbnr/ppppp2p/8/5pp1/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 3", "d1h5", "Qh5#"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Expected, func(t *testing.T) {
+			b, err := chess.BoardFromFEN(tt.FEN)
+			require.NoError(t, err)
+
+			m := findMove(t, &b, tt.Move)
+			assert.Equal(t, tt.Expected, b.SAN(m))
+
+			parsed, err := b.ParseSAN(tt.Expected)
+			require.NoError(t, err)
+			assert.Equal(t, m, parsed)
+		})
+	}
+}
+
+func TestPromotionFromSymbol(t *testing.T) {
+	p, err := chess.PromotionFromSymbol('Q')
+	assert.NoError(t, err)
+	assert.Equal(t, chess.QueenPromotion, p)
+
+	_, err = chess.PromotionFromSymbol('x')
+	assert.ErrorIs(t, err, chess.ErrInvalidPromotion)
+}
@@ -0,0 +1,79 @@
+package chess
+
+import "math/bits"
+
+// Returns how many times the current position has occurred, counting this time
+// Only positions since the last capture or pawn move can repeat, and each is compared by its Zobrist key
+func (b *Board) RepetitionCount() int {
+	c := b.Copy()
+	key := c.Zobrist()
+	count := 1
+	for ply := 1; ply <= b.QuietMoveCounter() && len(c.Moves) > 0; ply++ {
+		c.Unmove()
+		if ply%2 == 0 && c.Zobrist() == key {
+			count++
+		}
+	}
+	return count
+}
+
+// Reports whether neither side can possibly checkmate, i.e. the kings are alone apart from a single minor piece or
+// bishops which are all on the same colour
+func (b *Board) InsufficientMaterial() bool {
+	if b.whitePawns|b.blackPawns|b.whiteRooks|b.blackRooks|b.whiteQueens|b.blackQueens != 0 {
+		return false
+	}
+
+	knights := bits.OnesCount64(b.whiteKnights | b.blackKnights)
+	bishops := b.whiteBishops | b.blackBishops
+	if knights+bits.OnesCount64(bishops) <= 1 {
+		return true
+	}
+	return knights == 0 && (bishops&darkSquares == 0 || bishops&^darkSquares == 0)
+}
+
+// Reports whether side could never checkmate, however either side plays, which makes running out of time a draw
+// Only the clear cases are recognised: a lone king, a king and knight against a lone king, and bishops which are all
+// on one colour against a king with nothing but bishops on that same colour
+func (b *Board) CannotCheckmate(side Turn) bool {
+	us := [...]uint64{b.whitePawns | b.whiteRooks | b.whiteQueens, b.whiteKnights, b.whiteBishops}
+	them := [...]uint64{b.blackPawns | b.blackRooks | b.blackQueens, b.blackKnights, b.blackBishops}
+	if side == BlackTurn {
+		us, them = them, us
+	}
+
+	switch {
+	case us[0] != 0:
+		return false
+	case us[1] == 0 && us[2] == 0:
+		return true
+	case us[2] == 0:
+		return bits.OnesCount64(us[1]) == 1 && them[0]|them[1]|them[2] == 0
+	case us[1] != 0 || them[0]|them[1] != 0:
+		return false
+	}
+	bishops := us[2] | them[2]
+	return bishops&darkSquares == 0 || bishops&^darkSquares == 0
+}
+
+// Returns the result of the game in PGN form (1-0, 0-1, 1/2-1/2), along with why it ended
+// Draws by repetition and the 50 move rule are counted as soon as they could be claimed
+// Returns * and an empty reason while the game is still in progress
+func (b *Board) Result() (result, reason string) {
+	_, status := b.LegalMoves()
+	switch {
+	case status == Checkmate && b.Turn == WhiteTurn:
+		return "0-1", "checkmate"
+	case status == Checkmate:
+		return "1-0", "checkmate"
+	case status == Stalemate:
+		return "1/2-1/2", "stalemate"
+	case status == Draw:
+		return "1/2-1/2", "50 move rule"
+	case b.InsufficientMaterial():
+		return "1/2-1/2", "insufficient material"
+	case b.RepetitionCount() >= 3:
+		return "1/2-1/2", "threefold repetition"
+	}
+	return "*", ""
+}
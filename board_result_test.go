@@ -0,0 +1,67 @@
+package chess_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func playMoves(t *testing.T, b *chess.Board, moves string) {
+	t.Helper()
+	for _, m := range strings.Fields(moves) {
+		_, err := b.TryMove(m)
+		require.NoError(t, err, m)
+	}
+}
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		moves  string
+		result string
+		reason string
+	}{
+		{"in progress", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e4 e5", "*", ""},
+		{"fool's mate", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "f3 e5 g4 Qh4", "0-1", "checkmate"},
+		{"back rank mate", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "Ra8", "1-0", "checkmate"},
+		{"stalemated", "k7/8/8/8/8/8/8/1Q5K w - - 0 1", "Qb6", "1/2-1/2", "stalemate"},
+		{"repetition", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "Nf3 Nf6 Ng1 Ng8 Nf3 Nf6 Ng1 Ng8", "1/2-1/2", "threefold repetition"},
+		{"only twice", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "Nf3 Nf6 Ng1 Ng8 Nf3 Nf6 Ng1", "*", ""},
+		{"50 moves", "4k3/8/8/8/8/8/8/R3K3 w - - 99 80", "Ra2", "1/2-1/2", "50 move rule"},
+		{"49.5 moves", "4k3/8/8/8/8/8/8/R3K3 w - - 98 80", "Ra2", "*", ""},
+		{"bare kings", "4k3/8/8/8/8/8/3r4/4K3 w - - 0 1", "Kxd2", "1/2-1/2", "insufficient material"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := chess.BoardFromFEN(tt.fen)
+			require.NoError(t, err)
+			playMoves(t, &b, tt.moves)
+
+			result, reason := b.Result()
+			assert.Equal(t, tt.result, result)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestInsufficientMaterial(t *testing.T) {
+	for fen, want := range map[string]bool{
+		"4k3/8/8/8/8/8/8/4K3 w - - 0 1":     true,
+		"4k3/8/8/8/8/8/8/3NK3 w - - 0 1":    true,
+		"4k3/8/8/8/8/8/8/2B1K3 w - - 0 1":   true,
+		"4kb2/8/8/8/8/8/8/2B1K3 w - - 0 1":  true, // both on dark squares
+		"4k1b1/8/8/8/8/8/8/2B1K3 w - - 0 1": false,
+		"4k3/8/8/8/8/8/8/1NN1K3 w - - 0 1":  false,
+		"4kn2/8/8/8/8/8/8/2B1K3 w - - 0 1":  false,
+		"4k3/8/8/8/8/8/7P/4K3 w - - 0 1":    false,
+	} {
+		b, err := chess.BoardFromFEN(fen)
+		require.NoError(t, err)
+		assert.Equal(t, want, b.InsufficientMaterial(), fen)
+	}
+}
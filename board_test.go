@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/zakkbob/chess"
 )
 
@@ -63,3 +64,42 @@ func TestRankString(t *testing.T) {
 
 	assert.Equal(t, expected, got)
 }
+
+func TestZobrist(t *testing.T) {
+	// Same position reached through different move orders
+	a := chess.NewBoard()
+	for _, m := range []string{"g1f3", "g8f6", "b1c3"} {
+		require.NoError(t, a.DoAlgebraicMove(m))
+	}
+	b := chess.NewBoard()
+	for _, m := range []string{"b1c3", "g8f6", "g1f3"} {
+		require.NoError(t, b.DoAlgebraicMove(m))
+	}
+	assert.Equal(t, a.Zobrist(), b.Zobrist())
+
+	// Positions which only differ by a single pawn
+	c := chess.NewBoard()
+	require.NoError(t, c.DoAlgebraicMove("g2g3"))
+	d := chess.NewBoard()
+	require.NoError(t, d.DoAlgebraicMove("h2h3"))
+	assert.NotEqual(t, c.Zobrist(), d.Zobrist())
+}
+
+func TestFEN(t *testing.T) {
+	start := chess.NewBoard()
+	assert.Equal(t, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", start.FEN())
+
+	_, err := start.TryMove("e4")
+	require.NoError(t, err)
+	assert.Equal(t, "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", start.FEN())
+
+	for _, fen := range []string{
+		"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2",
+		"r3k2r/8/8/8/8/8/8/R3K2R b Kq - 12 40",
+		"8/8/8/4k3/8/8/8/K7 w - - 0 1",
+	} {
+		b, err := chess.BoardFromFEN(fen)
+		require.NoError(t, err)
+		assert.Equal(t, fen, b.FEN())
+	}
+}
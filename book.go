@@ -0,0 +1,277 @@
+package chess
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"math/rand"
+	"os"
+	"slices"
+)
+
+var ErrInvalidBook = errors.New("Invalid book")
+
+// Polyglot .bin opening books are a list of 16 byte big endian entries sorted by key
+//
+//	key    uint64  PolyglotKey of the position
+//	move   uint16  to file, to row, from file, from row, promotion piece, 3 bits each from the lowest
+//	weight uint16  how often the move should be played, relative to the position's other moves
+//	learn  uint32  unused
+//
+// Squares are numbered a1 = 0, promotion pieces are none, knight, bishop, rook, queen, and castling is written as the
+// king capturing its own rook
+type BookEntry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+const bookEntrySize = 16
+
+type Book struct {
+	entries []BookEntry // sorted by key
+
+	// Always play the highest weighted move, rather than choosing at random in proportion to the weights
+	BestMove bool
+	Rand     *rand.Rand // uses the global source when nil
+}
+
+// Reads a Polyglot book, sorting the entries if they aren't already
+func LoadBook(r io.Reader) (*Book, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%bookEntrySize != 0 {
+		return nil, fmt.Errorf("%w: size %d isn't a multiple of %d", ErrInvalidBook, len(data), bookEntrySize)
+	}
+
+	entries := make([]BookEntry, len(data)/bookEntrySize)
+	for i := range entries {
+		e := data[i*bookEntrySize:]
+		entries[i] = BookEntry{
+			Key:    binary.BigEndian.Uint64(e),
+			Move:   binary.BigEndian.Uint16(e[8:]),
+			Weight: binary.BigEndian.Uint16(e[10:]),
+			Learn:  binary.BigEndian.Uint32(e[12:]),
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b BookEntry) int {
+		switch {
+		case a.Key < b.Key:
+			return -1
+		case a.Key > b.Key:
+			return 1
+		}
+		return 0
+	})
+
+	return &Book{entries: entries}, nil
+}
+
+func OpenBook(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	book, err := LoadBook(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return book, nil
+}
+
+// Writes entries in the format LoadBook reads, they should already be sorted by key
+func WriteBook(w io.Writer, entries []BookEntry) error {
+	buf := make([]byte, bookEntrySize)
+	for _, e := range entries {
+		binary.BigEndian.PutUint64(buf, e.Key)
+		binary.BigEndian.PutUint16(buf[8:], e.Move)
+		binary.BigEndian.PutUint16(buf[10:], e.Weight)
+		binary.BigEndian.PutUint32(buf[12:], e.Learn)
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bk *Book) Len() int {
+	return len(bk.entries)
+}
+
+// Returns every entry for b's position, in the order they appear in the book
+func (bk *Book) Entries(b *Board) []BookEntry {
+	key := b.PolyglotKey()
+	i, _ := slices.BinarySearchFunc(bk.entries, key, func(e BookEntry, key uint64) int {
+		switch {
+		case e.Key < key:
+			return -1
+		case e.Key > key:
+			return 1
+		}
+		return 0
+	})
+
+	j := i
+	for j < len(bk.entries) && bk.entries[j].Key == key {
+		j++
+	}
+	return bk.entries[i:j]
+}
+
+type BookMove struct {
+	Move   Move
+	Weight int
+}
+
+// Returns the book's legal moves for b, highest weight first
+// Entries which don't match a legal move are skipped, so a book for a different variant or a key collision can't
+// make the engine play nonsense
+func (bk *Book) Moves(b *Board) []BookMove {
+	entries := bk.Entries(b)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ms, _ := b.LegalMoves()
+	var moves []BookMove
+	for _, e := range entries {
+		if m, ok := polyglotMove(e.Move, ms); ok {
+			moves = append(moves, BookMove{Move: m, Weight: int(e.Weight)})
+		}
+	}
+	slices.SortStableFunc(moves, func(a, b BookMove) int {
+		return b.Weight - a.Weight
+	})
+	return moves
+}
+
+// Picks a move to play from the book, or returns false if b isn't in it
+// Moves with zero weight are never played
+func (bk *Book) Move(b *Board) (Move, bool) {
+	moves := bk.Moves(b)
+	total := 0
+	for _, m := range moves {
+		total += m.Weight
+	}
+	if total == 0 {
+		return 0, false
+	}
+	if bk.BestMove {
+		return moves[0].Move, true
+	}
+
+	var n int
+	if bk.Rand != nil {
+		n = bk.Rand.Intn(total)
+	} else {
+		n = rand.Intn(total)
+	}
+	for _, m := range moves {
+		if n < m.Weight {
+			return m.Move, true
+		}
+		n -= m.Weight
+	}
+	panic("unreachable")
+}
+
+// Finds the legal move matching a Polyglot move
+func polyglotMove(pm uint16, ms []Move) (Move, bool) {
+	for _, m := range ms {
+		if PolyglotMove(m) == pm {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// Encodes m the way Polyglot books store it
+func PolyglotMove(m Move) uint16 {
+	from, to := int(m.From())^7, int(m.To())^7
+
+	// Castling is the king taking its own rook
+	switch m.Castle() {
+	case KingCastle:
+		to = from&^7 | 7
+	case QueenCastle:
+		to = from &^ 7
+	}
+
+	var promotion int
+	switch m.Promotion() {
+	case KnightPromotion:
+		promotion = 1
+	case BishopPromotion:
+		promotion = 2
+	case RookPromotion:
+		promotion = 3
+	case QueenPromotion:
+		promotion = 4
+	}
+
+	return uint16(to | from<<6 | promotion<<12)
+}
+
+// Polyglot piece kinds, in the order their keys appear in polyglotRandom
+var polyglotPieceKinds = [12]int{
+	1, 3, 5, 7, 9, 11, // white pawn, knight, bishop, rook, queen, king
+	0, 2, 4, 6, 8, 10, // black
+}
+
+const (
+	polyglotCastleOffset    = 768
+	polyglotEnPassantOffset = 772
+	polyglotTurnOffset      = 780
+)
+
+// Returns the position's key in the standard Polyglot scheme, which is what opening books are indexed by
+// It's separate from Zobrist, whose keys are generated for this engine and can be changed freely
+func (b *Board) PolyglotKey() uint64 {
+	var key uint64
+
+	for p, bb := range b.pieceBoards() {
+		for ; bb != 0; bb &= bb - 1 {
+			key ^= polyglotRandom[64*polyglotPieceKinds[p]+(bits.TrailingZeros64(bb)^7)]
+		}
+	}
+
+	for i, can := range []bool{
+		b.CastleRights.CanWhiteKing(), b.CastleRights.CanWhiteQueen(),
+		b.CastleRights.CanBlackKing(), b.CastleRights.CanBlackQueen(),
+	} {
+		if can {
+			key ^= polyglotRandom[polyglotCastleOffset+i]
+		}
+	}
+
+	// En passant only counts if a pawn is next to the one which double pushed, legal or not
+	if b.CanEnPassant {
+		pawns, rank := b.whitePawns, 4
+		if b.Turn == BlackTurn {
+			pawns, rank = b.blackPawns, 3
+		}
+		var adjacent uint64
+		if b.EnPassantFile > 0 {
+			adjacent |= 1 << Index(rank, b.EnPassantFile-1)
+		}
+		if b.EnPassantFile < 7 {
+			adjacent |= 1 << Index(rank, b.EnPassantFile+1)
+		}
+		if pawns&adjacent != 0 {
+			key ^= polyglotRandom[polyglotEnPassantOffset+7-b.EnPassantFile]
+		}
+	}
+
+	if b.Turn == WhiteTurn {
+		key ^= polyglotRandom[polyglotTurnOffset]
+	}
+
+	return key
+}
@@ -0,0 +1,237 @@
+package chess
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type BookBuildOptions struct {
+	MaxPly    int      // moves after this many plies aren't added, 0 for no limit
+	MinGames  int      // moves played in fewer games than this are left out
+	MinRating int      // games where either player is rated lower, or unrated, are skipped
+	Results   []string // games with any other result are skipped, all decisive results and draws when empty
+	NoLosers  bool     // leave out moves played by the side which went on to lose
+}
+
+// Win/draw/loss statistics for a move, from the point of view of the side playing it
+type BookMoveStats struct {
+	SAN    string
+	Move   uint16 // Polyglot encoded
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+func (s *BookMoveStats) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+type bookPosition struct {
+	fen   string // as first reached, without the move counters
+	moves map[uint16]*BookMoveStats
+}
+
+// Collects the moves played in PGN games, to be written out as a Polyglot book
+type BookBuilder struct {
+	Options BookBuildOptions
+	Games   int // added to the book
+	Skipped int // filtered out by the options
+
+	positions map[uint64]*bookPosition
+}
+
+func NewBookBuilder(opts BookBuildOptions) *BookBuilder {
+	return &BookBuilder{Options: opts, positions: map[uint64]*bookPosition{}}
+}
+
+// Checks g passes the rating and result filters
+func (bb *BookBuilder) accepts(g *PGNGame) bool {
+	results := bb.Options.Results
+	if len(results) == 0 {
+		results = []string{"1-0", "0-1", "1/2-1/2"}
+	}
+	if !slices.Contains(results, g.Result) {
+		return false
+	}
+
+	if bb.Options.MinRating > 0 {
+		for _, tag := range []string{"WhiteElo", "BlackElo"} {
+			rating, err := strconv.Atoi(g.Tags[tag])
+			if err != nil || rating < bb.Options.MinRating {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Adds g's moves to the book, unless it's filtered out
+// Only moves up to MaxPly need to be legal, and nothing is added if one isn't
+func (bb *BookBuilder) AddGame(g *PGNGame) error {
+	if !bb.accepts(g) {
+		bb.Skipped++
+		return nil
+	}
+
+	b, err := g.Board()
+	if err != nil {
+		return err
+	}
+
+	type played struct {
+		key   uint64
+		fen   string
+		move  uint16
+		san   string
+		white bool
+	}
+	var moves []played
+	for ply, san := range g.Moves {
+		if bb.Options.MaxPly > 0 && ply >= bb.Options.MaxPly {
+			break
+		}
+		m, err := b.ParseMove(san)
+		if err != nil {
+			return fmt.Errorf("%w: move %d %q: %w", ErrInvalidPGN, ply/2+1, san, err)
+		}
+		fen := strings.Join(strings.Fields(b.FEN())[:4], " ")
+		moves = append(moves, played{b.PolyglotKey(), fen, PolyglotMove(m), b.SAN(m), b.Turn == WhiteTurn})
+		b.Move(m)
+	}
+
+	for _, p := range moves {
+		won := g.Result == "1-0" && p.white || g.Result == "0-1" && !p.white
+		lost := g.Result == "1-0" && !p.white || g.Result == "0-1" && p.white
+		if lost && bb.Options.NoLosers {
+			continue
+		}
+
+		pos, ok := bb.positions[p.key]
+		if !ok {
+			pos = &bookPosition{fen: p.fen, moves: map[uint16]*BookMoveStats{}}
+			bb.positions[p.key] = pos
+		}
+		stats, ok := pos.moves[p.move]
+		if !ok {
+			stats = &BookMoveStats{SAN: p.san, Move: p.move}
+			pos.moves[p.move] = stats
+		}
+
+		switch {
+		case won:
+			stats.Wins++
+		case lost:
+			stats.Losses++
+		default:
+			stats.Draws++
+		}
+	}
+
+	bb.Games++
+	return nil
+}
+
+// Reads every game from a PGN file into the book
+func (bb *BookBuilder) AddPGN(r io.Reader) error {
+	pr := NewPGNReader(r)
+	for {
+		g, err := pr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := bb.AddGame(g); err != nil {
+			return fmt.Errorf("game %d: %w", bb.Games+bb.Skipped+1, err)
+		}
+	}
+}
+
+// A move which made it into the book, with the weight it's given
+type bookBuiltMove struct {
+	BookMoveStats
+	weight int
+}
+
+// Applies MinGames and works out each move's weight, scoring a win as 2 and a draw as 1
+// Positions are sorted by key, and their moves by weight, highest first
+func (bb *BookBuilder) built() (keys []uint64, moves map[uint64][]bookBuiltMove) {
+	moves = map[uint64][]bookBuiltMove{}
+	for key, pos := range bb.positions {
+		var ms []bookBuiltMove
+		highest := 0
+		for _, s := range pos.moves {
+			if s.Games() < max(bb.Options.MinGames, 1) {
+				continue
+			}
+			weight := 2*s.Wins + s.Draws
+			if weight == 0 {
+				continue
+			}
+			ms = append(ms, bookBuiltMove{*s, weight})
+			highest = max(highest, weight)
+		}
+
+		// Scaled down to fit in 16 bits, keeping their proportions
+		if highest > 0xffff {
+			for i := range ms {
+				ms[i].weight = max(ms[i].weight*0xffff/highest, 1)
+			}
+		}
+		if len(ms) == 0 {
+			continue
+		}
+
+		slices.SortFunc(ms, func(a, b bookBuiltMove) int {
+			if a.weight != b.weight {
+				return b.weight - a.weight
+			}
+			return int(a.Move) - int(b.Move)
+		})
+		keys = append(keys, key)
+		moves[key] = ms
+	}
+	slices.Sort(keys)
+	return keys, moves
+}
+
+// Returns the book's entries, sorted by key as WriteBook expects
+// Moves which never scored, i.e. were only ever played by the loser, are left out as they'd never be played
+func (bb *BookBuilder) Entries() []BookEntry {
+	keys, moves := bb.built()
+	var entries []BookEntry
+	for _, key := range keys {
+		for _, m := range moves[key] {
+			entries = append(entries, BookEntry{Key: key, Move: m.Move, Weight: uint16(m.weight)})
+		}
+	}
+	return entries
+}
+
+// Writes the entries in a human readable form, one block per position
+func (bb *BookBuilder) WriteDump(w io.Writer) error {
+	keys, moves := bb.built()
+	for _, key := range keys {
+		ms := moves[key]
+		total := 0
+		for _, m := range ms {
+			total += m.weight
+		}
+
+		if _, err := fmt.Fprintf(w, "%016x %s\n", key, bb.positions[key].fen); err != nil {
+			return err
+		}
+		for _, m := range ms {
+			_, err := fmt.Fprintf(w, "  %-7s weight %5d %5.1f%%  games %d  +%d =%d -%d\n",
+				m.SAN, m.weight, 100*float64(m.weight)/float64(total), m.Games(), m.Wins, m.Draws, m.Losses)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
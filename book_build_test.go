@@ -0,0 +1,109 @@
+package chess_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+const bookPGN = `[WhiteElo "2400"] [BlackElo "2300"] [Result "1-0"]
+1. e4 e5 2. Nf3 Nc6 1-0
+
+[WhiteElo "2400"] [BlackElo "2300"] [Result "0-1"]
+1. e4 c5 2. Nf3 d6 0-1
+
+[WhiteElo "2400"] [BlackElo "2300"] [Result "1/2-1/2"]
+1. e4 e5 2. Nc3 1/2-1/2
+
+[WhiteElo "1500"] [BlackElo "2300"] [Result "1-0"]
+1. d4 d5 1-0
+
+[WhiteElo "2400"] [BlackElo "2300"] [Result "*"]
+1. c4 *
+`
+
+func buildBook(t *testing.T, opts chess.BookBuildOptions) *chess.BookBuilder {
+	t.Helper()
+	bb := chess.NewBookBuilder(opts)
+	require.NoError(t, bb.AddPGN(strings.NewReader(bookPGN)))
+	return bb
+}
+
+func bookWeights(t *testing.T, bb *chess.BookBuilder, moves ...string) map[string]int {
+	t.Helper()
+	b := chess.NewBoard()
+	for _, m := range moves {
+		_, err := b.TryMove(m)
+		require.NoError(t, err)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, chess.WriteBook(&buf, bb.Entries()))
+	book, err := chess.LoadBook(&buf)
+	require.NoError(t, err)
+
+	weights := map[string]int{}
+	for _, m := range book.Moves(&b) {
+		weights[b.SAN(m.Move)] = m.Weight
+	}
+	return weights
+}
+
+func TestBookBuilder(t *testing.T) {
+	bb := buildBook(t, chess.BookBuildOptions{})
+	assert.Equal(t, 4, bb.Games)
+	assert.Equal(t, 1, bb.Skipped) // the unfinished game
+
+	// e4 won once, lost once and drew once, d4 won once
+	assert.Equal(t, map[string]int{"e4": 3, "d4": 2}, bookWeights(t, bb))
+	// c5 won and e5 lost and drew
+	assert.Equal(t, map[string]int{"c5": 2, "e5": 1}, bookWeights(t, bb, "e4"))
+	assert.Equal(t, map[string]int{"Nf3": 2, "Nc3": 1}, bookWeights(t, bb, "e4", "e5"))
+	// Nf3 only ever lost, so it's never played
+	assert.Empty(t, bookWeights(t, bb, "e4", "c5"))
+
+	bb = buildBook(t, chess.BookBuildOptions{MaxPly: 1, MinRating: 2000})
+	assert.Equal(t, 3, bb.Games)
+	assert.Equal(t, map[string]int{"e4": 3}, bookWeights(t, bb))
+	assert.Empty(t, bookWeights(t, bb, "e4"))
+
+	bb = buildBook(t, chess.BookBuildOptions{MinGames: 2})
+	assert.Equal(t, map[string]int{"e4": 3}, bookWeights(t, bb))
+	assert.Equal(t, map[string]int{"e5": 1}, bookWeights(t, bb, "e4"))
+
+	bb = buildBook(t, chess.BookBuildOptions{Results: []string{"1-0"}})
+	assert.Equal(t, 2, bb.Games)
+	assert.Equal(t, map[string]int{"e4": 2, "d4": 2}, bookWeights(t, bb))
+
+	// Losses don't add to the weight anyway, but no longer count towards MinGames
+	bb = buildBook(t, chess.BookBuildOptions{MinGames: 2, NoLosers: true})
+	assert.Equal(t, map[string]int{"e4": 3}, bookWeights(t, bb))
+	assert.Empty(t, bookWeights(t, bb, "e4"))
+}
+
+func TestBookBuilderDump(t *testing.T) {
+	bb := buildBook(t, chess.BookBuildOptions{MaxPly: 1})
+
+	var buf bytes.Buffer
+	require.NoError(t, bb.WriteDump(&buf))
+	assert.Equal(t,
+		"463b96181691fc9c rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -\n"+
+			"  e4      weight     3  60.0%  games 3  +1 =1 -1\n"+
+			"  d4      weight     2  40.0%  games 1  +1 =0 -0\n",
+		buf.String())
+}
+
+func TestBookBuilderIllegalMove(t *testing.T) {
+	bb := chess.NewBookBuilder(chess.BookBuildOptions{})
+	err := bb.AddPGN(strings.NewReader(`1. e4 e5 2. Ke3 1-0`))
+	assert.ErrorIs(t, err, chess.ErrInvalidPGN)
+	assert.Empty(t, bb.Entries())
+
+	// Moves past MaxPly aren't checked
+	bb = chess.NewBookBuilder(chess.BookBuildOptions{MaxPly: 2})
+	assert.NoError(t, bb.AddPGN(strings.NewReader(`1. e4 e5 2. Ke3 1-0`)))
+}
@@ -0,0 +1,150 @@
+package chess_test
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+// Test positions from the Polyglot book format specification
+func TestPolyglotKey(t *testing.T) {
+	tests := []struct {
+		moves string
+		fen   string
+		key   uint64
+	}{
+		{"", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 0x463b96181691fc9c},
+		{"e2e4", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", 0x823c9b50fd114196},
+		{"e2e4 d7d5", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", 0x0756b94461c50fb0},
+		{"e2e4 d7d5 e4e5", "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2", 0x662fafb965db29d4},
+		{"e2e4 d7d5 e4e5 f7f5", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", 0x22a48b5a8e47ff78},
+		{"e2e4 d7d5 e4e5 f7f5 e1e2", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR b kq - 0 3", 0x652a607ca3f242c1},
+		{"e2e4 d7d5 e4e5 f7f5 e1e2 e8f7", "rnbq1bnr/ppp1pkpp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR w - - 0 4", 0x00fdd303c946bdd9},
+		{"a2a4 b7b5 h2h4 b5b4 c2c4", "rnbqkbnr/p1pppppp/8/8/PpP4P/8/1P1PPPP1/RNBQKBNR b KQkq c3 0 3", 0x3c8123ea7b067637},
+		{"a2a4 b7b5 h2h4 b5b4 c2c4 b4c3 a1a3", "rnbqkbnr/p1pppppp/8/8/P6P/R1p5/1P1PPPP1/1NBQKBNR b Kkq - 0 4", 0x5c3f9b829b279560},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.moves, func(t *testing.T) {
+			b := chess.NewBoard()
+			for _, m := range strings.Fields(tt.moves) {
+				_, err := b.TryMove(m)
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.key, b.PolyglotKey())
+
+			b, err := chess.BoardFromFEN(tt.fen)
+			require.NoError(t, err)
+			assert.Equal(t, tt.key, b.PolyglotKey())
+		})
+	}
+}
+
+func TestPolyglotMove(t *testing.T) {
+	tests := []struct {
+		fen  string
+		move string
+		want uint16
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4", 12<<6 | 28},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O", 4<<6 | 7},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O-O", 4<<6 | 0},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "O-O", 60<<6 | 63},
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8n", 1<<12 | 49<<6 | 57},
+		{"4k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7b8q", 4<<12 | 49<<6 | 57},
+	}
+
+	for _, tt := range tests {
+		b, err := chess.BoardFromFEN(tt.fen)
+		require.NoError(t, err)
+		m, err := b.ParseMove(tt.move)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, chess.PolyglotMove(m), tt.move)
+	}
+}
+
+func writeTestBook(t *testing.T, entries []chess.BookEntry) *chess.Book {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, chess.WriteBook(&buf, entries))
+	book, err := chess.LoadBook(&buf)
+	require.NoError(t, err)
+	return book
+}
+
+func TestBook(t *testing.T) {
+	start := chess.NewBoard()
+	key := start.PolyglotKey()
+
+	afterE4 := chess.NewBoard()
+	_, err := afterE4.TryMove("e4")
+	require.NoError(t, err)
+
+	// Unsorted, with an illegal move and one which should never be played
+	book := writeTestBook(t, []chess.BookEntry{
+		{Key: key, Move: 12<<6 | 28, Weight: 3},                   // e2e4
+		{Key: afterE4.PolyglotKey(), Move: 52<<6 | 36, Weight: 1}, // e7e5
+		{Key: key ^ 1, Move: 6<<6 | 21, Weight: 50},               // g1f3, but for another position
+		{Key: key, Move: 11<<6 | 27, Weight: 1},                   // d2d4
+		{Key: key, Move: 12<<6 | 36, Weight: 100},                 // e2e5, illegal
+		{Key: key, Move: 1<<6 | 18, Weight: 0},                    // b1c3
+	})
+	assert.Equal(t, 6, book.Len())
+	assert.Len(t, book.Entries(&start), 4)
+
+	moves := book.Moves(&start)
+	require.Len(t, moves, 3)
+	assert.Equal(t, "e2e4", moves[0].Move.String())
+	assert.Equal(t, 3, moves[0].Weight)
+	assert.Equal(t, "d2d4", moves[1].Move.String())
+	assert.Equal(t, "b1c3", moves[2].Move.String())
+
+	book.Rand = rand.New(rand.NewSource(1))
+	played := map[string]int{}
+	for range 400 {
+		m, ok := book.Move(&start)
+		require.True(t, ok)
+		played[m.String()]++
+	}
+	assert.Len(t, played, 2)
+	assert.Greater(t, played["e2e4"], played["d2d4"])
+	assert.NotZero(t, played["d2d4"])
+
+	book.BestMove = true
+	m, ok := book.Move(&start)
+	assert.True(t, ok)
+	assert.Equal(t, "e2e4", m.String())
+
+	m, ok = book.Move(&afterE4)
+	assert.True(t, ok)
+	assert.Equal(t, "e7e5", m.String())
+
+	_, err = afterE4.TryMove("e5")
+	require.NoError(t, err)
+	_, ok = book.Move(&afterE4)
+	assert.False(t, ok)
+}
+
+func TestBookSearch(t *testing.T) {
+	b := chess.NewBoard()
+	book := writeTestBook(t, []chess.BookEntry{{Key: b.PolyglotKey(), Move: 6<<6 | 21, Weight: 1}})
+
+	e := chess.Engine{B: b, TT: *chess.NewTranspositionTable(10), EP: chess.DefaultParams, Book: book}
+	assert.Equal(t, "g1f3", e.Search(0).String())
+}
+
+func TestLoadBookErrors(t *testing.T) {
+	_, err := chess.LoadBook(bytes.NewReader(make([]byte, 17)))
+	assert.ErrorIs(t, err, chess.ErrInvalidBook)
+
+	book, err := chess.LoadBook(bytes.NewReader(nil))
+	require.NoError(t, err)
+	b := chess.NewBoard()
+	_, ok := book.Move(&b)
+	assert.False(t, ok)
+}
@@ -0,0 +1,265 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How a time stage's increment is given
+type ClockDelay int
+
+const (
+	FischerIncrement ClockDelay = iota // added after every move
+	BronsteinDelay                     // the time used on a move is given back afterwards, up to the increment
+	SimpleDelay                        // the clock only starts counting down once the increment has passed, also known as US delay
+)
+
+// Part of a time control, e.g. 40 moves in 90 minutes with 30 seconds added per move
+type TimeStage struct {
+	Moves     int           // moves each side plays in this stage, 0 for the rest of the game
+	Base      time.Duration // added to both clocks as the stage starts
+	Increment time.Duration
+	Delay     ClockDelay
+}
+
+// A time control made of stages played one after the other, the last is repeated if it has a move count
+type StagedTimeControl []TimeStage
+
+// Parses a time control in seconds, written like a PGN TimeControl tag with stages separated by colons
+// A d or b after the increment makes it a simple or Bronstein delay
+//
+//	300+2               5 minutes with a 2 second increment
+//	40/5400+30:1800+30  40 moves in 90 minutes, then 30 minutes for the rest, with 30 seconds per move throughout
+//	300+5d              5 minutes with a 5 second simple delay
+func ParseStagedTimeControl(s string) (StagedTimeControl, error) {
+	var tc StagedTimeControl
+	for _, part := range strings.Split(s, ":") {
+		var stage TimeStage
+		if moves, rest, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(moves)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidTimeControl, s)
+			}
+			stage.Moves, part = n, rest
+		}
+
+		base, inc, _ := strings.Cut(part, "+")
+		switch {
+		case strings.HasSuffix(inc, "d"):
+			stage.Delay, inc = SimpleDelay, inc[:len(inc)-1]
+		case strings.HasSuffix(inc, "b"):
+			stage.Delay, inc = BronsteinDelay, inc[:len(inc)-1]
+		}
+		if inc == "" {
+			inc = "0"
+		}
+
+		b, err1 := strconv.ParseFloat(base, 64)
+		i, err2 := strconv.ParseFloat(inc, 64)
+		if err1 != nil || err2 != nil || b < 0 || i < 0 || b == 0 && len(tc) == 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTimeControl, s)
+		}
+		stage.Base = time.Duration(b * float64(time.Second))
+		stage.Increment = time.Duration(i * float64(time.Second))
+		tc = append(tc, stage)
+	}
+
+	for _, stage := range tc[:len(tc)-1] {
+		if stage.Moves == 0 {
+			return nil, fmt.Errorf("%w: %q: only the last stage can be for the rest of the game", ErrInvalidTimeControl, s)
+		}
+	}
+	return tc, nil
+}
+
+func (tc StagedTimeControl) String() string {
+	parts := make([]string, len(tc))
+	for i, stage := range tc {
+		s := strconv.FormatFloat(stage.Base.Seconds(), 'f', -1, 64)
+		if stage.Moves > 0 {
+			s = strconv.Itoa(stage.Moves) + "/" + s
+		}
+		if stage.Increment > 0 {
+			s += "+" + strconv.FormatFloat(stage.Increment.Seconds(), 'f', -1, 64)
+			switch stage.Delay {
+			case SimpleDelay:
+				s += "d"
+			case BronsteinDelay:
+				s += "b"
+			}
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, ":")
+}
+
+// Returns the stage a side is in after playing moves moves, and how many moves are left in it, 0 for the rest of
+// the game
+func (tc StagedTimeControl) stage(moves int) (TimeStage, int) {
+	for i, stage := range tc {
+		if stage.Moves == 0 {
+			return stage, 0
+		}
+		if moves < stage.Moves {
+			return stage, stage.Moves - moves
+		}
+		if i < len(tc)-1 {
+			moves -= stage.Moves
+		}
+	}
+	last := tc[len(tc)-1]
+	return last, last.Moves - moves%last.Moves
+}
+
+// A chess clock for both sides, only the side to move's time runs
+// It starts paused, so the time taken to set up a game isn't counted
+type Clock struct {
+	Control StagedTimeControl
+
+	remaining [2]time.Duration // as of the start of the current move
+	moves     [2]int
+	side      int           // whose turn it is, 0 for white
+	spent     time.Duration // on the current move before the clock was last paused
+	started   time.Time     // when the side to move's time last started running, zero while paused
+	flagged   bool
+
+	now func() time.Time
+}
+
+// Creates a paused clock with the first stage's time on both sides, for a game with turn to move
+func NewClock(tc StagedTimeControl, turn Turn) *Clock {
+	c := &Clock{Control: tc, now: time.Now}
+	c.remaining = [2]time.Duration{tc[0].Base, tc[0].Base}
+	if turn == BlackTurn {
+		c.side = 1
+	}
+	return c
+}
+
+// Returns whose time is running, or would be if the clock weren't paused
+func (c *Clock) Turn() Turn {
+	return c.side == 1
+}
+
+// Starts the side to move's time running, carrying on from where it was if the clock was paused
+func (c *Clock) Start() {
+	if c.started.IsZero() && !c.flagged {
+		c.started = c.now()
+	}
+}
+
+// Stops the clock without ending the move, the time used so far still counts when it's started again
+func (c *Clock) Pause() {
+	if !c.started.IsZero() {
+		c.spent += c.now().Sub(c.started)
+		c.started = time.Time{}
+	}
+}
+
+func (c *Clock) Paused() bool {
+	return c.started.IsZero()
+}
+
+// Returns the time spent on the current move
+func (c *Clock) used() time.Duration {
+	used := c.spent
+	if !c.started.IsZero() {
+		used += c.now().Sub(c.started)
+	}
+	return used
+}
+
+// Returns the time taken off the side to move's clock so far, which doesn't include any simple delay
+func (c *Clock) counted() time.Duration {
+	used := c.used()
+	if stage, _ := c.Control.stage(c.moves[c.side]); stage.Delay == SimpleDelay {
+		used = max(0, used-stage.Increment)
+	}
+	return used
+}
+
+// Returns a side's time left, which is never less than 0
+func (c *Clock) Remaining(side Turn) time.Duration {
+	i := 0
+	if side == BlackTurn {
+		i = 1
+	}
+	if i != c.side {
+		return c.remaining[i]
+	}
+	return max(0, c.remaining[i]-c.counted())
+}
+
+// Reports whether a side has run out of time, which can only be the side to move, stopping the clock if it has
+func (c *Clock) Flagged() (Turn, bool) {
+	if !c.flagged && c.remaining[c.side]-c.counted() <= 0 {
+		c.remaining[c.side] = 0
+		c.spent, c.started = 0, time.Time{}
+		c.flagged = true
+	}
+	return c.Turn(), c.flagged
+}
+
+// Ends the side to move's move, adding its increment and the next stage's time if it has finished a stage, then
+// starts the other side's time unless the clock is paused
+// Returns false, leaving the clock stopped, if the side's flag fell before the move was made
+func (c *Clock) Press() bool {
+	if _, flagged := c.Flagged(); flagged {
+		return false
+	}
+
+	used := c.used()
+	c.remaining[c.side] -= c.counted()
+
+	stage, left := c.Control.stage(c.moves[c.side])
+	switch stage.Delay {
+	case FischerIncrement:
+		c.remaining[c.side] += stage.Increment
+	case BronsteinDelay:
+		c.remaining[c.side] += min(used, stage.Increment)
+	}
+	c.moves[c.side]++
+	if left == 1 {
+		next, _ := c.Control.stage(c.moves[c.side])
+		c.remaining[c.side] += next.Base
+	}
+
+	running := !c.started.IsZero()
+	c.side = 1 - c.side
+	c.spent, c.started = 0, time.Time{}
+	if running {
+		c.started = c.now()
+	}
+	return true
+}
+
+// Returns the clock's times as search limits for the side to move, with any delay treated as an increment
+func (c *Clock) Limits() SearchLimits {
+	white, _ := c.Control.stage(c.moves[0])
+	black, _ := c.Control.stage(c.moves[1])
+	_, left := c.Control.stage(c.moves[c.side])
+	return SearchLimits{
+		WhiteTime: c.Remaining(WhiteTurn),
+		BlackTime: c.Remaining(BlackTurn),
+		WhiteInc:  white.Increment,
+		BlackInc:  black.Increment,
+		MovesToGo: left,
+	}
+}
+
+// Returns the result when a side's flag falls in b, which is a draw if the other side could never checkmate
+func FlagResult(flagged Turn, b *Board) (result, reason string) {
+	name := "white"
+	if flagged == BlackTurn {
+		name = "black"
+	}
+	if b.CannotCheckmate(!flagged) {
+		return "1/2-1/2", name + " ran out of time, but can't be checkmated"
+	}
+	if flagged == WhiteTurn {
+		return "0-1", name + " loses on time"
+	}
+	return "1-0", name + " loses on time"
+}
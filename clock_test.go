@@ -0,0 +1,152 @@
+package chess
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A clock whose time only moves when the test says so
+func newTestClock(t *testing.T, control string) (*Clock, func(time.Duration)) {
+	tc, err := ParseStagedTimeControl(control)
+	require.NoError(t, err)
+	now := time.Unix(0, 0)
+	c := NewClock(tc, WhiteTurn)
+	c.now = func() time.Time { return now }
+	return c, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestParseStagedTimeControl(t *testing.T) {
+	tc, err := ParseStagedTimeControl("40/5400+30:1800+30")
+	require.NoError(t, err)
+	assert.Equal(t, StagedTimeControl{
+		{Moves: 40, Base: 90 * time.Minute, Increment: 30 * time.Second},
+		{Base: 30 * time.Minute, Increment: 30 * time.Second},
+	}, tc)
+	assert.Equal(t, "40/5400+30:1800+30", tc.String())
+
+	tc, err = ParseStagedTimeControl("300+5d")
+	require.NoError(t, err)
+	assert.Equal(t, StagedTimeControl{{Base: 5 * time.Minute, Increment: 5 * time.Second, Delay: SimpleDelay}}, tc)
+	assert.Equal(t, "300+5d", tc.String())
+
+	tc, err = ParseStagedTimeControl("0.5+0.1b")
+	require.NoError(t, err)
+	assert.Equal(t, BronsteinDelay, tc[0].Delay)
+	assert.Equal(t, "0.5+0.1b", tc.String())
+
+	for _, s := range []string{"", "0", "x/60", "60:40/60", "60+-1", "60+1x", "0/60"} {
+		_, err := ParseStagedTimeControl(s)
+		assert.ErrorIs(t, err, ErrInvalidTimeControl, s)
+	}
+}
+
+func TestClockFischer(t *testing.T) {
+	c, advance := newTestClock(t, "60+2")
+	advance(time.Hour) // paused, so nothing is used
+	assert.Equal(t, time.Minute, c.Remaining(WhiteTurn))
+
+	c.Start()
+	advance(10 * time.Second)
+	assert.Equal(t, 50*time.Second, c.Remaining(WhiteTurn))
+	require.True(t, c.Press())
+	assert.Equal(t, 52*time.Second, c.Remaining(WhiteTurn))
+	assert.Equal(t, BlackTurn, c.Turn())
+
+	advance(5 * time.Second)
+	c.Pause()
+	advance(time.Hour)
+	c.Start()
+	advance(5 * time.Second)
+	assert.Equal(t, 50*time.Second, c.Remaining(BlackTurn))
+	assert.Equal(t, SearchLimits{WhiteTime: 52 * time.Second, BlackTime: 50 * time.Second, WhiteInc: 2 * time.Second, BlackInc: 2 * time.Second}, c.Limits())
+
+	advance(time.Minute)
+	side, flagged := c.Flagged()
+	assert.True(t, flagged)
+	assert.Equal(t, BlackTurn, side)
+	assert.False(t, c.Press(), "a move after the flag falls doesn't count")
+	assert.Zero(t, c.Remaining(BlackTurn))
+}
+
+func TestClockSuddenDeathMovesToGo(t *testing.T) {
+	c, _ := newTestClock(t, "300+2")
+	for range 4 {
+		assert.Zero(t, c.Limits().MovesToGo)
+		require.True(t, c.Press())
+	}
+}
+
+func TestClockDelays(t *testing.T) {
+	c, advance := newTestClock(t, "60+5d")
+	c.Start()
+	advance(3 * time.Second)
+	assert.Equal(t, time.Minute, c.Remaining(WhiteTurn), "still within the delay")
+	c.Pause()
+	advance(time.Minute)
+	c.Start()
+	advance(4 * time.Second)
+	assert.Equal(t, 58*time.Second, c.Remaining(WhiteTurn), "the delay isn't given again after a pause")
+	c.Press()
+	assert.Equal(t, 58*time.Second, c.Remaining(WhiteTurn), "nothing is added afterwards")
+
+	c, advance = newTestClock(t, "60+5b")
+	c.Start()
+	advance(3 * time.Second)
+	assert.Equal(t, 57*time.Second, c.Remaining(WhiteTurn))
+	c.Press()
+	assert.Equal(t, time.Minute, c.Remaining(WhiteTurn), "all the time used is given back")
+	advance(8 * time.Second)
+	c.Press()
+	assert.Equal(t, 57*time.Second, c.Remaining(BlackTurn), "up to the delay is given back")
+}
+
+func TestClockStages(t *testing.T) {
+	c, advance := newTestClock(t, "2/60:30+1")
+	c.Start()
+	for range 4 {
+		_, left := c.Control.stage(c.moves[c.side])
+		assert.Equal(t, left, c.Limits().MovesToGo)
+		advance(10 * time.Second)
+		require.True(t, c.Press())
+	}
+	assert.Equal(t, 70*time.Second, c.Remaining(WhiteTurn), "the second stage's time is added after move 2")
+	assert.Zero(t, c.Limits().MovesToGo)
+
+	advance(10 * time.Second)
+	c.Press()
+	assert.Equal(t, 61*time.Second, c.Remaining(WhiteTurn), "with its increment from then on")
+
+	// A repeating last stage
+	c, advance = newTestClock(t, "1/10")
+	c.Start()
+	for range 4 {
+		advance(time.Second)
+		c.Press()
+	}
+	assert.Equal(t, 28*time.Second, c.Remaining(WhiteTurn))
+}
+
+func TestFlagResult(t *testing.T) {
+	tests := []struct {
+		fen     string
+		flagged Turn
+		result  string
+	}{
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", BlackTurn, "1-0"},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", WhiteTurn, "1/2-1/2"},
+		{"4k3/8/8/8/8/8/8/3NK3 w - - 0 1", BlackTurn, "1/2-1/2"},
+		{"4k3/4p3/8/8/8/8/8/3NK3 w - - 0 1", BlackTurn, "1-0"},
+		{"4kb2/8/8/8/8/8/8/2B1K3 w - - 0 1", BlackTurn, "1/2-1/2"},
+		{"4k1b1/8/8/8/8/8/8/2B1K3 w - - 0 1", BlackTurn, "1-0"},
+		{"4k3/8/8/8/8/8/8/3QK3 w - - 0 1", WhiteTurn, "1/2-1/2"},
+	}
+	for _, tt := range tests {
+		b, err := BoardFromFEN(tt.fen)
+		require.NoError(t, err)
+		result, _ := FlagResult(tt.flagged, &b)
+		assert.Equal(t, tt.result, result, tt.fen)
+	}
+}
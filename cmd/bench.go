@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/zakkbob/chess"
+)
+
+func benchCommand(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	depth := fs.Int("depth", chess.BenchDepth, "depth to search each position to, the signature is only comparable at the same depth")
+	params := paramsFlag(fs)
+	nnue := nnueFlag(fs)
+	fs.Parse(args)
+
+	e := &chess.Engine{
+		PT: *chess.NewPawnTable(12),
+		EP: loadParams(*params),
+	}
+	useNNUE(*nnue, e)
+
+	r := e.Bench(*depth)
+	fmt.Printf("%d positions searched to depth %d\n", len(chess.BenchPositions), *depth)
+	fmt.Printf("Time  %v\n", r.Time.Round(time.Millisecond))
+	fmt.Printf("Nodes %d\n", r.Nodes)
+	fmt.Printf("NPS   %.0f\n", r.NPS())
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/zakkbob/chess"
+)
+
+func bookFlag(fs *flag.FlagSet) *string {
+	return fs.String("book", "", "Polyglot opening book to play from")
+}
+
+// Loads the -book file given to a command into e, does nothing if there wasn't one
+func useBook(path string, e *chess.Engine) {
+	if path == "" {
+		return
+	}
+
+	book, err := chess.OpenBook(path)
+	if err != nil {
+		fmt.Println("Cannot load book:", err.Error())
+		os.Exit(1)
+	}
+	e.Book = book
+}
+
+func printBook(book *chess.Book, b *chess.Board) {
+	moves := book.Moves(b)
+	if len(moves) == 0 {
+		fmt.Printf("%-26s %s\n", "Book", "not in book")
+		return
+	}
+
+	total := 0
+	for _, m := range moves {
+		total += m.Weight
+	}
+	for i, m := range moves {
+		label := ""
+		if i == 0 {
+			label = "Book"
+		}
+		share := 0.0
+		if total > 0 {
+			share = 100 * float64(m.Weight) / float64(total)
+		}
+		fmt.Printf("%-26s %-7s %5d %5.1f%%\n", label, b.SAN(m.Move), m.Weight, share)
+	}
+}
+
+func bookCommand(args []string) {
+	if len(args) == 0 || args[0] != "build" {
+		fmt.Println("expected 'build' subcommand")
+		os.Exit(1)
+	}
+	bookBuildCommand(args[1:])
+}
+
+func bookBuildCommand(args []string) {
+	fs := flag.NewFlagSet("book build", flag.ExitOnError)
+	output := fs.String("o", "book.bin", "Polyglot book to write")
+	dump := fs.String("dump", "", "also write the book in a human readable form to this file")
+	maxPly := fs.Int("max-ply", 20, "only add moves up to this many plies into each game (0 for no limit)")
+	minGames := fs.Int("min-games", 1, "leave out moves played in fewer games than this")
+	minRating := fs.Int("min-rating", 0, "skip games where either player is rated lower than this, or unrated")
+	results := fs.String("results", "1-0,0-1,1/2-1/2", "comma separated results of the games to use")
+	noLosers := fs.Bool("no-losers", false, "leave out moves played by the side which lost")
+	params := paramsFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chess book build [flags] <pgn files...>")
+		fmt.Fprintln(fs.Output(), "Moves are weighted by the points they scored, 2 for a win and 1 for a draw")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Books are built from games without evaluating them, but the file is still checked so every command takes the same flags
+	loadParams(*params)
+
+	args = fs.Args()
+
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	bb := chess.NewBookBuilder(chess.BookBuildOptions{
+		MaxPly:    *maxPly,
+		MinGames:  *minGames,
+		MinRating: *minRating,
+		Results:   strings.Split(*results, ","),
+		NoLosers:  *noLosers,
+	})
+	for _, path := range args {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		err = bb.AddPGN(f)
+		f.Close()
+		if err != nil {
+			fmt.Printf("%s: %s\n", path, err.Error())
+			os.Exit(1)
+		}
+	}
+
+	entries := bb.Entries()
+	writeFile(*output, func(w io.Writer) error {
+		return chess.WriteBook(w, entries)
+	})
+	if *dump != "" {
+		writeFile(*dump, bb.WriteDump)
+	}
+
+	fmt.Printf("%d games used, %d skipped, %d entries written to %s\n", bb.Games, bb.Skipped, len(entries), *output)
+}
+
+// Creates path and fills it with write, exiting if anything goes wrong
+func writeFile(path string, write func(io.Writer) error) {
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	w := bufio.NewWriter(f)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		fmt.Printf("Cannot write %s: %s\n", path, err.Error())
+		os.Exit(1)
+	}
+}
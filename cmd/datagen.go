@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/zakkbob/chess"
+)
+
+func datagenCommand(args []string) {
+	fs := flag.NewFlagSet("datagen", flag.ExitOnError)
+	games := fs.Int("games", 100, "number of self-play games")
+	randomPlies := fs.Int("random-plies", 8, "random moves played at the start of each game")
+	depth := fs.Int("depth", 0, "depth limit for every move")
+	nodes := fs.Int("nodes", 5000, "node limit for every move")
+	workers := fs.Int("workers", runtime.NumCPU(), "number of games to play at once")
+	seed := fs.Int64("seed", 1, "seed for the random openings, the same seed and limits always give the same data")
+	format := fs.String("format", "text", "output format, text for 'fen | score | result' lines or binary for 32 byte marlinformat records")
+	params := paramsFlag(fs)
+	nnue := nnueFlag(fs)
+	syzygy := syzygyFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chess datagen [flags] <output file>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || *format != "text" && *format != "binary" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	opts := chess.DatagenOptions{
+		Games:       *games,
+		RandomPlies: *randomPlies,
+		Limits:      chess.SearchLimits{Depth: *depth, Nodes: *nodes},
+		Workers:     *workers,
+		Seed:        *seed,
+		NewEngine: func() *chess.Engine {
+			e := &chess.Engine{
+				TT: *chess.NewTranspositionTable(16),
+				PT: *chess.NewPawnTable(12),
+				EP: loadParams(*params),
+			}
+			useNNUE(*nnue, e)
+			useSyzygy(*syzygy, e)
+			return e
+		},
+	}
+
+	writeFile(fs.Arg(0), func(w io.Writer) error {
+		positions := 0
+		start := time.Now()
+		return chess.GenerateData(opts, func(game int, ps []chess.TrainingPosition) error {
+			for _, p := range ps {
+				if *format == "binary" {
+					buf := p.Pack()
+					if _, err := w.Write(buf[:]); err != nil {
+						return err
+					}
+				} else if err := p.WriteText(w); err != nil {
+					return err
+				}
+			}
+
+			positions += len(ps)
+			elapsed := time.Since(start)
+			fmt.Printf("Game %d of %d: %d positions, %d in total (%.0f/s)\n", game+1, *games, len(ps), positions, float64(positions)/elapsed.Seconds())
+			return nil
+		})
+	})
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zakkbob/chess"
+)
+
+func epdCommand(args []string) {
+	fs := flag.NewFlagSet("epd", flag.ExitOnError)
+	moveTime := fs.Duration("movetime", 0, "time to search each position for (a second if no limit is given)")
+	depth := fs.Int("depth", 0, "depth to search each position to")
+	nodes := fs.Int("nodes", 0, "nodes to search in each position")
+	minScore := fs.Float64("min-score", 0, "exit with an error if any suite scores below this percentage")
+	quiet := fs.Bool("quiet", false, "only print the score of each suite")
+	params := paramsFlag(fs)
+	nnue := nnueFlag(fs)
+	syzygy := syzygyFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chess epd [flags] <suite.epd>...")
+		fmt.Fprintln(fs.Output(), "Positions are checked against their bm, am and dm operations, or scored by STS style c0 \"Qd2=10, Qe1=5\" ones")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	limits := chess.SearchLimits{Depth: *depth, Nodes: *nodes, MoveTime: *moveTime}
+	if limits == (chess.SearchLimits{}) {
+		limits.MoveTime = time.Second
+	}
+
+	e := &chess.Engine{
+		TT: *chess.NewTranspositionTable(20),
+		PT: *chess.NewPawnTable(12),
+		EP: loadParams(*params),
+	}
+	useNNUE(*nnue, e)
+	useSyzygy(*syzygy, e)
+
+	failed := false
+	for _, path := range fs.Args() {
+		tests := loadEPDTests(path)
+
+		var points, maxPoints, nodes int
+		start := time.Now()
+		for i, t := range tests {
+			r := t.Run(e, limits)
+			score := t.Score(r)
+			points += score
+			maxPoints += t.MaxPoints()
+			nodes += r.Nodes
+
+			if *quiet {
+				continue
+			}
+			status := "ok  "
+			if score < t.MaxPoints() {
+				status = "FAIL"
+			}
+			id := t.ID
+			if id == "" {
+				id = fmt.Sprint(i + 1)
+			}
+			fmt.Printf("%4d %s %-12s %-7s %-20s depth %2d score %7s nodes %9d %v\n", i+1, status, id, t.Board.SAN(r.Move),
+				expectedMoves(&t), r.Depth, scoreString(r.Score), r.Nodes, r.Time.Round(time.Millisecond))
+		}
+
+		elapsed := time.Since(start)
+		percent := 100 * float64(points) / float64(maxPoints)
+		fmt.Printf("%s: %d/%d (%.1f%%), %d nodes in %v\n", path, points, maxPoints, percent, nodes, elapsed.Round(time.Millisecond))
+		if percent < *minScore {
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// Reads every position of a suite, exiting if any can't be used
+func loadEPDTests(path string) []chess.EPDTest {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	es, err := chess.ReadEPD(f)
+	if err != nil {
+		fmt.Printf("%s: %s\n", path, err.Error())
+		os.Exit(1)
+	}
+
+	tests := make([]chess.EPDTest, 0, len(es))
+	for i, e := range es {
+		t, err := chess.NewEPDTest(e)
+		if err != nil {
+			fmt.Printf("%s: position %d: %s\n", path, i+1, err.Error())
+			os.Exit(1)
+		}
+		tests = append(tests, t)
+	}
+	return tests
+}
+
+// Describes what a position expects, e.g. "bm Qg6" or "am Rxb2 dm 3"
+func expectedMoves(t *chess.EPDTest) string {
+	var parts []string
+	if len(t.Best) > 0 {
+		parts = append(parts, "bm")
+		for _, m := range t.Best {
+			parts = append(parts, t.Board.SAN(m))
+		}
+	}
+	if len(t.Avoid) > 0 {
+		parts = append(parts, "am")
+		for _, m := range t.Avoid {
+			parts = append(parts, t.Board.SAN(m))
+		}
+	}
+	if t.Mate > 0 {
+		parts = append(parts, "dm", fmt.Sprint(t.Mate))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "c0")
+	}
+	return strings.Join(parts, " ")
+}
+
+// Formats a search score in centipawns, or as a mate distance such as #3 or #-2
+func scoreString(score int) string {
+	if moves, ok := chess.MateIn(score); ok {
+		return fmt.Sprintf("#%d", moves)
+	}
+	return fmt.Sprint(score)
+}
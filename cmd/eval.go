@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zakkbob/chess"
+)
+
+func evalCommand(args []string) {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	all := fs.Bool("all", false, "also show terms which are zero for both sides")
+	params := paramsFlag(fs)
+	nnue := nnueFlag(fs)
+	syzygy := syzygyFlag(fs)
+	book := bookFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chess eval [flags] <fen>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	args = fs.Args()
+
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	b, err := chess.BoardFromFEN(args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	e := chess.Engine{B: b, EP: loadParams(*params)}
+	trace := e.EvaluateTrace()
+
+	fmt.Println(b.String())
+	fmt.Println()
+	printEvalTrace(trace, *all)
+
+	if *nnue != "" {
+		useNNUE(*nnue, &e)
+		fmt.Printf("%-26s %d\n", "NNUE (side to move)", e.Evaluate())
+	}
+
+	if *syzygy != "" {
+		useSyzygy(*syzygy, &e)
+		printTablebase(e.TB, &e.B)
+	}
+
+	if *book != "" {
+		useBook(*book, &e)
+		printBook(e.Book, &e.B)
+	}
+}
+
+func printTablebase(tb *chess.Tablebase, b *chess.Board) {
+	wdl, err := tb.ProbeWDL(b)
+	if err != nil {
+		fmt.Printf("%-26s %s\n", "Tablebase", err.Error())
+		return
+	}
+	fmt.Printf("%-26s %s\n", "Tablebase (side to move)", wdl)
+
+	if dtz, err := tb.ProbeDTZ(b); err == nil {
+		fmt.Printf("%-26s %d\n", "DTZ", dtz)
+	}
+	if moves, err := tb.ProbeRoot(b); err == nil && len(moves) > 0 {
+		fmt.Printf("%-26s %s (%s, DTZ %d)\n", "Best move", moves[0].Move.String(), moves[0].WDL, moves[0].DTZ)
+	}
+}
+
+func printEvalTrace(t chess.EvalTrace, all bool) {
+	row := "%-26s %7v %7v   %7v %7v   %7v %7v\n"
+
+	fmt.Printf(row, "", "White", "", "Black", "", "Total", "")
+	fmt.Printf(row, "Term", "MG", "EG", "MG", "EG", "MG", "EG")
+	for _, term := range t.Terms {
+		total := term.Total()
+		if !all && term.White == [2]int{} && term.Black == [2]int{} {
+			continue
+		}
+		fmt.Printf(row, term.Name, term.White[chess.MG], term.White[chess.EG], term.Black[chess.MG], term.Black[chess.EG], total[chess.MG], total[chess.EG])
+	}
+	fmt.Printf(row, "Total", "", "", "", "", t.Total[chess.MG], t.Total[chess.EG])
+
+	fmt.Println()
+	fmt.Printf("%-26s %d/%d\n", "Phase", t.Phase, chess.MaxPhase)
+	if t.Endgame != "" {
+		fmt.Printf("%-26s %s\n", "Endgame", t.Endgame)
+	}
+	fmt.Printf("%-26s %d\n", "Score (side to move)", t.Score)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zakkbob/chess"
+)
+
+// Returns the exit code, rather than exiting itself, so UCI engines are always closed first
+func matchCommand(args []string) int {
+	fs := flag.NewFlagSet("match", flag.ExitOnError)
+	games := fs.Int("games", 10, "number of games, rounded up so each opening is played with both colours")
+	tc := fs.String("tc", "10+0.1", "time control in seconds, base+increment, or none to only use the other limits")
+	moveTime := fs.Duration("movetime", 0, "time limit for every move")
+	depth := fs.Int("depth", 0, "depth limit for every move")
+	nodes := fs.Int("nodes", 0, "node limit for every move")
+	openings := fs.String("openings", "", "EPD or PGN file of starting positions, each played with both colours")
+	pgnOut := fs.String("pgn", "", "write every game to this PGN file")
+	event := fs.String("event", "", "event name for the PGN")
+	drawAfter := fs.Int("draw-after", 40, "don't adjudicate draws before this move number")
+	drawMoves := fs.Int("draw-moves", 0, "adjudicate a draw once both scores have been small for this many moves (0 disables)")
+	drawScore := fs.Int("draw-score", 10, "largest score counted as drawn, in centipawns")
+	resignMoves := fs.Int("resign-moves", 0, "adjudicate a loss once a side's score has been bad for this many moves (0 disables)")
+	resignScore := fs.Int("resign-score", 600, "score a side resigns at, in centipawns")
+	maxMoves := fs.Int("max-moves", 0, "adjudicate a draw after this many moves (0 for no limit)")
+	sprt := fs.Bool("sprt", false, "stop as soon as a sequential probability ratio test finishes, -games 0 for no other limit")
+	elo0 := fs.Float64("elo0", 0, "SPRT null hypothesis, the first engine's Elo advantage if a change makes no difference")
+	elo1 := fs.Float64("elo1", 5, "SPRT alternative hypothesis, the first engine's Elo advantage if a change is an improvement")
+	alpha := fs.Float64("alpha", 0.05, "SPRT false positive rate")
+	beta := fs.Float64("beta", 0.05, "SPRT false negative rate")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chess match [flags] <engine> <engine>")
+		fmt.Fprintln(fs.Output(), "Each engine is a comma separated list of settings, either this engine:")
+		fmt.Fprintln(fs.Output(), "  name=new,params=new.json,nnue=net.bin,syzygy=dir,book=book.bin,depth=8,nodes=100000,hash=20")
+		fmt.Fprintln(fs.Output(), "or an external UCI engine, with any options to set:")
+		fmt.Fprintln(fs.Output(), "  uci=/usr/bin/stockfish,name=sf,option.Hash=64,option.Threads=1")
+		fmt.Fprintln(fs.Output(), "Results are from the first engine's point of view")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	args = fs.Args()
+
+	if len(args) != 2 {
+		fs.Usage()
+		return 1
+	}
+
+	opts := chess.MatchOptions{
+		Games:           *games,
+		Limits:          chess.SearchLimits{Depth: *depth, Nodes: *nodes, MoveTime: *moveTime},
+		DrawMoveNumber:  *drawAfter,
+		DrawMoveCount:   *drawMoves,
+		DrawScore:       *drawScore,
+		ResignMoveCount: *resignMoves,
+		ResignScore:     *resignScore,
+		MaxMoves:        *maxMoves,
+		Event:           *event,
+	}
+	if *sprt {
+		opts.SPRT = &chess.SPRT{Elo0: *elo0, Elo1: *elo1, Alpha: *alpha, Beta: *beta}
+	}
+	if *tc != "none" {
+		var err error
+		if opts.TimeControl, err = chess.ParseTimeControl(*tc); err != nil {
+			fmt.Println(err.Error())
+			return 1
+		}
+	}
+	if *openings != "" {
+		opts.Openings = loadOpenings(*openings)
+	}
+
+	if *pgnOut != "" {
+		f, err := os.Create(*pgnOut)
+		if err != nil {
+			fmt.Println(err.Error())
+			return 1
+		}
+		defer f.Close()
+		opts.PGN = f
+	}
+
+	var settings [2]map[string]string
+	for i, spec := range args {
+		var err error
+		if settings[i], err = parseEngineSpec(spec); err != nil {
+			fmt.Printf("Engine %d: %s\n", i+1, err.Error())
+			return 1
+		}
+	}
+
+	// This engine's players are set up first, since loading their files exits on failure, and that mustn't leave a UCI
+	// engine running
+	var players [2]chess.Player
+	for _, uci := range []bool{false, true} {
+		for i := range players {
+			if _, ok := settings[i]["uci"]; ok != uci {
+				continue
+			}
+			p, err := newPlayer(settings[i], i+1)
+			if err != nil {
+				fmt.Printf("Engine %d: %s\n", i+1, err.Error())
+				return 1
+			}
+			players[i] = p
+			if c, ok := p.(io.Closer); ok {
+				defer c.Close()
+			}
+		}
+	}
+
+	total := *games + *games%2
+	opts.Progress = func(r *chess.MatchResult) {
+		g := r.Games[len(r.Games)-1]
+		white, black := r.Players[0], r.Players[1]
+		if !g.FirstIsWhite {
+			white, black = black, white
+		}
+		if total > 0 {
+			fmt.Printf("Game %d of %d: %s vs %s %s (%s)\n", len(r.Games), total, white, black, g.Result, g.Reason)
+		} else {
+			fmt.Printf("Game %d: %s vs %s %s (%s)\n", len(r.Games), white, black, g.Result, g.Reason)
+		}
+		printMatchScore(r, opts.SPRT)
+	}
+
+	r, err := chess.RunMatch(players, opts)
+	if err != nil {
+		fmt.Println("Match stopped:", err.Error())
+		printMatchScore(r, opts.SPRT)
+		return 1
+	}
+	if r.SPRT != chess.SPRTContinue {
+		fmt.Println("SPRT:", r.SPRT)
+	}
+	return 0
+}
+
+func printMatchScore(r *chess.MatchResult, sprt *chess.SPRT) {
+	elo, margin := r.Elo()
+	fmt.Printf("Score of %s vs %s: %d - %d - %d [%.3f] Elo %.1f +/- %.1f\n",
+		r.Players[0], r.Players[1], r.Wins, r.Losses, r.Draws, r.Score(), elo, margin)
+
+	if sprt != nil {
+		p := r.Pentanomial()
+		lower, upper := sprt.Bounds()
+		fmt.Printf("Ptnml(0-2): %d, %d, %d, %d, %d  LLR: %.2f (%.2f, %.2f) [%.1f, %.1f]\n",
+			p[0], p[1], p[2], p[3], p[4], sprt.LLR(p), lower, upper, sprt.Elo0, sprt.Elo1)
+	}
+}
+
+// Reads starting positions from an EPD file, or from the moves of each game in a PGN
+func loadOpenings(path string) []chess.Board {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	var boards []chess.Board
+	if strings.EqualFold(filepath.Ext(path), ".pgn") {
+		boards, err = pgnOpenings(f)
+	} else {
+		var es []chess.EPD
+		es, err = chess.ReadEPD(f)
+		for _, e := range es {
+			boards = append(boards, e.Board)
+		}
+	}
+	if err != nil {
+		fmt.Printf("Cannot load openings: %s: %s\n", path, err.Error())
+		os.Exit(1)
+	}
+	if len(boards) == 0 {
+		fmt.Printf("Cannot load openings: %s: no positions\n", path)
+		os.Exit(1)
+	}
+	return boards
+}
+
+func pgnOpenings(r io.Reader) ([]chess.Board, error) {
+	var boards []chess.Board
+	pr := chess.NewPGNReader(r)
+	for {
+		g, err := pr.Next()
+		if err == io.EOF {
+			return boards, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := g.Board()
+		if err != nil {
+			return nil, err
+		}
+		for _, san := range g.Moves {
+			if _, err := b.TryMove(san); err != nil {
+				return nil, fmt.Errorf("game %d: %s: %w", len(boards)+1, san, err)
+			}
+		}
+		boards = append(boards, b)
+	}
+}
+
+// Splits an engine specification into its settings, see the usage message for the format
+func parseEngineSpec(spec string) (map[string]string, error) {
+	settings := map[string]string{}
+	for _, s := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value, got %q", s)
+		}
+		settings[key] = value
+	}
+	return settings, nil
+}
+
+// Creates the nth player from its settings, a UCI engine is closed again if it can't be set up
+func newPlayer(settings map[string]string, n int) (chess.Player, error) {
+	if path, ok := settings["uci"]; ok {
+		u, err := chess.StartUCIEngine(path)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range settings {
+			if name, ok := strings.CutPrefix(key, "option."); ok {
+				if err := u.SetOption(name, value); err != nil {
+					u.Close()
+					return nil, err
+				}
+			}
+		}
+		if name, ok := settings["name"]; ok {
+			return namedPlayer{u, name}, nil
+		}
+		return u, nil
+	}
+
+	var err error
+	number := func(key string, fallback int) int {
+		v, ok := settings[key]
+		if !ok || err != nil {
+			return fallback
+		}
+		i, convErr := strconv.Atoi(v)
+		if convErr != nil {
+			err = fmt.Errorf("%s isn't a number: %q", key, v)
+		}
+		return i
+	}
+	hash, depth, nodes := number("hash", 20), number("depth", 0), number("nodes", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &chess.Engine{
+		TT: *chess.NewTranspositionTable(hash),
+		PT: *chess.NewPawnTable(12),
+		EP: loadParams(settings["params"]),
+	}
+	useNNUE(settings["nnue"], e)
+	useSyzygy(settings["syzygy"], e)
+	useBook(settings["book"], e)
+
+	p := &chess.EnginePlayer{
+		Label:  "engine" + strconv.Itoa(n),
+		Engine: e,
+		Limits: chess.SearchLimits{Depth: depth, Nodes: nodes},
+	}
+	if name, ok := settings["name"]; ok {
+		p.Label = name
+	}
+	return p, nil
+}
+
+// Renames a UCI engine, which normally goes by the name it gives itself
+type namedPlayer struct {
+	*chess.UCIEngine
+	name string
+}
+
+func (p namedPlayer) Name() string {
+	return p.name
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zakkbob/chess"
+)
+
+func nnueFlag(fs *flag.FlagSet) *string {
+	return fs.String("nnue", "", "evaluate with the network in this file instead of the classic evaluation")
+}
+
+// Loads the -nnue file given to a command and attaches it to e's board, does nothing if there wasn't one
+func useNNUE(path string, e *chess.Engine) {
+	if path == "" {
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Println("Cannot load network:", err.Error())
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	net, err := chess.LoadNetwork(f)
+	if err != nil {
+		fmt.Println("Cannot load network:", err.Error())
+		os.Exit(1)
+	}
+
+	e.SetEvaluator(chess.NewNNUE(net))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zakkbob/chess"
+)
+
+// Loads the -params file given to a command, or the defaults if there wasn't one
+func loadParams(path string) chess.EvalParams {
+	if path == "" {
+		return chess.DefaultParams
+	}
+
+	ep, err := chess.LoadParams(path)
+	if err != nil {
+		fmt.Println("Cannot load params:", err.Error())
+		os.Exit(1)
+	}
+	return ep
+}
+
+func paramsFlag(fs *flag.FlagSet) *string {
+	return fs.String("params", "", "load evaluation parameters from a .json or .yaml file, missing fields keep their defaults")
+}
+
+// Writes out a complete parameter file, useful as a starting point for a new set
+func paramsCommand(args []string) {
+	fs := flag.NewFlagSet("params", flag.ExitOnError)
+	params := paramsFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chess params [flags] <output .json or .yaml file>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	args = fs.Args()
+
+	if len(args) != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if err := loadParams(*params).Save(args[0]); err != nil {
+		fmt.Println("Cannot save params:", err.Error())
+		os.Exit(1)
+	}
+}
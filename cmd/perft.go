@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zakkbob/chess"
+)
+
+// Output is kept compatible with perftree (see testing/perft.sh), extra information goes to stderr unless -stats is given
+func perftCommand(args []string) {
+	fs := flag.NewFlagSet("perft", flag.ExitOnError)
+	workers := fs.Int("workers", runtime.NumCPU(), "number of goroutines to split the root moves across")
+	hashExp := fs.Int("hash", 0, "cache subtree counts in a table with 2^n entries (0 disables)")
+	stats := fs.Bool("stats", false, "break the total down by captures, en passant, castles, promotions, checks and mates")
+	params := paramsFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chess perft [flags] <depth> <fen> [moves]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Move generation doesn't depend on the evaluation, but the file is still checked so every command takes the same flags
+	loadParams(*params)
+
+	args = fs.Args()
+
+	if len(args) != 2 && len(args) != 3 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	depth, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Println("Cannot parse depth: ", args[0])
+		os.Exit(1)
+	}
+
+	fen := args[1]
+	b, err := chess.BoardFromFEN(fen)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if len(args) == 3 {
+		moves := args[2]
+
+		for _, a := range strings.Fields(moves) {
+			if _, err := b.TryMove(a); err != nil {
+				fmt.Println("Cannot play move:", a, err.Error())
+				os.Exit(1)
+			}
+		}
+	}
+
+	start := time.Now()
+	divide, total := chess.Perft(&b, depth, chess.PerftOptions{
+		Workers:  *workers,
+		HashExp:  *hashExp,
+		Detailed: *stats,
+	})
+	elapsed := time.Since(start)
+
+	for _, d := range divide {
+		fmt.Println(d.Move.String(), d.Stats.Nodes)
+	}
+
+	fmt.Println()
+	fmt.Println(total.Nodes)
+
+	if *stats {
+		fmt.Println()
+		printPerftStats(total)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d nodes in %v (%.0f nps)\n", total.Nodes, elapsed.Round(time.Millisecond), float64(total.Nodes)/elapsed.Seconds())
+}
+
+func printPerftStats(s chess.PerftStats) {
+	fmt.Printf("%-12s %d\n", "Nodes", s.Nodes)
+	fmt.Printf("%-12s %d\n", "Captures", s.Captures)
+	fmt.Printf("%-12s %d\n", "E.p.", s.EnPassants)
+	fmt.Printf("%-12s %d\n", "Castles", s.Castles)
+	fmt.Printf("%-12s %d\n", "Promotions", s.Promotions)
+	fmt.Printf("%-12s %d\n", "Checks", s.Checks)
+	fmt.Printf("%-12s %d\n", "Checkmates", s.Checkmates)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zakkbob/chess"
+)
+
+func perftSuiteCommand(args []string) {
+	fs := flag.NewFlagSet("perft-suite", flag.ExitOnError)
+	maxDepth := fs.Int("depth", 4, "maximum depth to check each position to")
+	workers := fs.Int("workers", runtime.NumCPU(), "number of goroutines to split the root moves across")
+	hashExp := fs.Int("hash", 16, "cache subtree counts in a table with 2^n entries (0 disables)")
+	reference := fs.String("reference", "", "perftree compatible command (e.g. 'bash ./stockfish.sh') used to find the failing move path")
+	params := paramsFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chess perft-suite [flags] <file.epd>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Move generation doesn't depend on the evaluation, but the file is still checked so every command takes the same flags
+	loadParams(*params)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	positions, err := chess.ParsePerftEPD(f)
+	f.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	opts := chess.PerftOptions{Workers: *workers, HashExp: *hashExp}
+
+	var (
+		failures int
+		nodes    uint64
+		start    = time.Now()
+	)
+
+	for i, p := range positions {
+		b, _ := chess.BoardFromFEN(p.FEN)
+
+		failedDepth := 0
+		for d := 1; d <= min(*maxDepth, len(p.Depths)); d++ {
+			expected := p.Depths[d-1]
+			if expected == 0 {
+				continue
+			}
+
+			_, total := chess.Perft(&b, d, opts)
+			nodes += total.Nodes
+
+			if total.Nodes != expected {
+				fmt.Printf("%3d FAIL D%d expected %d, got %d: %s\n", i+1, d, expected, total.Nodes, p.FEN)
+				failedDepth = d
+				break
+			}
+		}
+
+		if failedDepth == 0 {
+			fmt.Printf("%3d ok   %s\n", i+1, p.FEN)
+			continue
+		}
+		failures++
+
+		if *reference == "" {
+			continue
+		}
+
+		mismatch, found, err := chess.FindPerftMismatch(p.FEN, failedDepth, perftreeReference(*reference), opts)
+		switch {
+		case err != nil:
+			fmt.Println("    reference failed:", err.Error())
+		case !found:
+			fmt.Println("    reference agrees with our counts, is the suite correct?")
+		default:
+			fmt.Println("    first difference", mismatch.String())
+		}
+	}
+
+	elapsed := time.Since(start)
+	fmt.Println()
+	fmt.Printf("%d/%d positions passed, %d nodes in %v (%.0f nps)\n", len(positions)-failures, len(positions), nodes, elapsed.Round(time.Millisecond), float64(nodes)/elapsed.Seconds())
+
+	if failures != 0 {
+		os.Exit(1)
+	}
+}
+
+// Runs a command following the perftree script interface: <command> <depth> <fen> [moves]
+// which prints "<move> <count>" lines, a blank line, then the total
+func perftreeReference(command string) chess.PerftReference {
+	return func(fen string, moves []string, depth int) (map[string]uint64, error) {
+		parts := strings.Fields(command)
+		args := append(parts[1:], strconv.Itoa(depth), fen)
+		if len(moves) != 0 {
+			args = append(args, strings.Join(moves, " "))
+		}
+
+		out, err := exec.Command(parts[0], args...).Output()
+		if err != nil {
+			return nil, err
+		}
+
+		divide := map[string]uint64{}
+		s := bufio.NewScanner(strings.NewReader(string(out)))
+		for s.Scan() {
+			fields := strings.Fields(s.Text())
+			if len(fields) == 0 {
+				break
+			}
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("unexpected reference output %q", s.Text())
+			}
+			n, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected reference output %q", s.Text())
+			}
+			divide[fields[0]] = n
+		}
+
+		return divide, nil
+	}
+}
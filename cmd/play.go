@@ -0,0 +1,479 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zakkbob/chess"
+)
+
+func playCommand(args []string) {
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	fen := fs.String("fen", "", "start from this position instead of the standard one")
+	colour := fs.String("colour", "white", "the side you play: white, black, both for two players, or none to watch the engine play itself")
+	tc := fs.String("tc", "", "time control in seconds for both sides, e.g. 300+2, 40/5400+30:1800+30 or 300+5d for a delay (default no clock)")
+	moveTime := fs.Duration("movetime", 0, "time limit for every engine move (default 1s if there's no other limit)")
+	depth := fs.Int("depth", 0, "depth limit for every engine move")
+	params := paramsFlag(fs)
+	nnue := nnueFlag(fs)
+	syzygy := syzygyFlag(fs)
+	book := bookFlag(fs)
+	bookBest := fs.Bool("book-best", false, "always play the book's highest weighted move, rather than a weighted random one")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chess play [flags]")
+		fmt.Fprintln(fs.Output(), "Moves can be given in SAN (Nf3, exd5, O-O) or coordinates (g1f3, e7e8q), type help for the other commands")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	b := chess.NewBoard()
+	if *fen != "" {
+		var err error
+		if b, err = chess.BoardFromFEN(*fen); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	s := playSession{
+		game:   chess.NewGame(&b),
+		limits: chess.SearchLimits{Depth: *depth, MoveTime: *moveTime},
+		input:  bufio.NewScanner(os.Stdin),
+	}
+	switch *colour {
+	case "white":
+		s.humans = [2]bool{true, false}
+	case "black":
+		s.humans = [2]bool{false, true}
+		s.flipped = true
+	case "both":
+		s.humans = [2]bool{true, true}
+	case "none":
+	default:
+		fmt.Printf("Unknown colour %q, expected white, black, both or none\n", *colour)
+		os.Exit(1)
+	}
+	if *tc != "" {
+		control, err := chess.ParseStagedTimeControl(*tc)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		s.clock = chess.NewClock(control, b.Turn)
+		s.game.Tags["TimeControl"] = control.String()
+	}
+	if *moveTime == 0 && *depth == 0 && s.clock == nil {
+		s.limits.MoveTime = time.Second
+	}
+
+	s.engine = chess.Engine{
+		TT: *chess.NewTranspositionTable(20),
+		PT: *chess.NewPawnTable(12),
+		EP: loadParams(*params),
+	}
+	useNNUE(*nnue, &s.engine)
+	useSyzygy(*syzygy, &s.engine)
+	useBook(*book, &s.engine)
+	if s.engine.Book != nil {
+		s.engine.Book.BestMove = *bookBest
+	}
+
+	for i, name := range []string{"White", "Black"} {
+		s.game.Tags[name] = "Engine"
+		if s.humans[i] {
+			s.game.Tags[name] = "Human"
+		}
+	}
+	s.game.Tags["Date"] = time.Now().Format("2006.01.02")
+
+	s.run()
+}
+
+// An interactive game between people at the terminal and the engine
+type playSession struct {
+	game    *chess.Game
+	engine  chess.Engine
+	clock   *chess.Clock // nil for an untimed game
+	limits  chess.SearchLimits
+	humans  [2]bool         // whether white and black are played at the terminal
+	flipped bool            // shows the board from black's side
+	shown   *chess.GameNode // the position last shown, so it's only shown again once it changes
+	input   *bufio.Scanner
+}
+
+func (s *playSession) run() {
+	announced := false
+	watching := !s.humans[0] && !s.humans[1]
+	for {
+		b := s.game.Board()
+		over := s.game.Result != "*"
+		humanTurn := !over && s.humans[side(b.Turn)]
+
+		// The board is shown again once it has changed, when someone at the terminal is about to look at it
+		if s.game.Node() != s.shown && (over || humanTurn || watching) {
+			s.printPosition()
+			s.shown = s.game.Node()
+		}
+		if over && !announced {
+			fmt.Printf("Game over: %s (%s)\n", s.game.Result, s.game.Termination)
+		}
+		announced = over
+
+		switch {
+		case over && watching:
+			return
+		case !over && !humanTurn:
+			s.engineMove()
+			continue
+		case over:
+			fmt.Print("> ")
+		default:
+			if s.clock != nil {
+				s.clock.Start()
+			}
+			fmt.Printf("%s to move: ", turnName(b.Turn))
+		}
+		if !s.input.Scan() {
+			fmt.Println()
+			return
+		}
+		if !s.command(strings.Fields(s.input.Text())) {
+			return
+		}
+	}
+}
+
+// Carries out a line typed at the prompt, returning false to end the session
+func (s *playSession) command(fields []string) bool {
+	b := s.game.Board()
+	// A flag that fell while the command was being typed ends the game instead, so a lost game can't be drawn or
+	// resigned
+	if s.clock != nil && s.game.Result == "*" {
+		if side, flagged := s.clock.Flagged(); flagged {
+			s.game.Result, s.game.Termination = chess.FlagResult(side, &b)
+			return true
+		}
+	}
+	if len(fields) == 0 {
+		return true
+	}
+	over := s.game.Result != "*"
+
+	switch fields[0] {
+	case "help":
+		fmt.Println("Enter a move in SAN (Nf3, exd5, O-O) or coordinates (g1f3, e7e8q), or one of:")
+		fmt.Println("  moves            list the legal moves")
+		fmt.Println("  undo             take back your last move, along with the engine's reply")
+		fmt.Println("  hint             ask the engine for a move")
+		fmt.Println("  flip             turn the board around")
+		fmt.Println("  fen              show the position's FEN")
+		fmt.Println("  pgn              show the game so far in PGN")
+		fmt.Println("  pgn save <file>  write the game to a PGN file")
+		fmt.Println("  draw             offer a draw")
+		fmt.Println("  resign           resign the game")
+		fmt.Println("  quit             leave without finishing the game")
+	case "quit", "exit":
+		return false
+	case "flip":
+		s.flipped = !s.flipped
+		s.shown = nil
+	case "fen":
+		fmt.Println(b.FEN())
+	case "pgn":
+		if len(fields) == 1 {
+			s.game.PGN().Write(os.Stdout)
+			break
+		}
+		if len(fields) != 3 || fields[1] != "save" {
+			fmt.Println("Expected pgn or pgn save <file>")
+			break
+		}
+		if err := s.savePGN(fields[2]); err != nil {
+			fmt.Println(err.Error())
+			break
+		}
+		fmt.Println("Saved to", fields[2])
+	case "moves":
+		ms, _ := b.LegalMoves()
+		sans := make([]string, len(ms))
+		for i, m := range ms {
+			sans[i] = b.SAN(m)
+		}
+		fmt.Printf("%s (%d)\n", strings.Join(sans, " "), len(ms))
+	case "undo":
+		s.undo()
+	case "hint":
+		if over {
+			fmt.Println("The game is over")
+			break
+		}
+		r := s.analyse()
+		fmt.Printf("Hint: %s (%s)\n", b.SAN(r.Move), searchSummary(r, b.Turn))
+		fmt.Println("PV:", pvString(b, r.PV))
+	case "draw":
+		if over {
+			fmt.Println("The game is over")
+			break
+		}
+		s.offerDraw()
+	case "resign":
+		if over {
+			fmt.Println("The game is over")
+			break
+		}
+		s.game.Result = "1-0"
+		if b.Turn == chess.WhiteTurn {
+			s.game.Result = "0-1"
+		}
+		s.game.Termination = strings.ToLower(turnName(b.Turn)) + " resigns"
+	default:
+		if over {
+			fmt.Println("The game is over, type help for the commands you can still use")
+			break
+		}
+		if len(fields) > 1 {
+			fmt.Println("Unknown command, type help for a list")
+			break
+		}
+		m, err := b.ParseMove(fields[0])
+		switch {
+		case errors.Is(err, chess.ErrIllegalMove):
+			fmt.Println("That move isn't legal here")
+		case errors.Is(err, chess.ErrAmbiguousMove):
+			fmt.Println("That could be more than one move, say which piece moves, e.g. Nbd2")
+		case err != nil:
+			fmt.Println("Not a move or command, type help for a list")
+		default:
+			s.play(m)
+		}
+	}
+	return true
+}
+
+// Presses the clock and plays m, or ends the game on time if the mover's flag has fallen
+func (s *playSession) play(m chess.Move) {
+	if s.clock != nil && !s.clock.Press() {
+		b := s.game.Board()
+		s.game.Result, s.game.Termination = chess.FlagResult(s.clock.Turn(), &b)
+		return
+	}
+	mover := s.game.Board().Turn
+	s.game.Move(m)
+	if s.clock != nil {
+		s.game.Node().Clock = s.clock.Remaining(mover)
+	}
+}
+
+// Searches the current position for the engine's move, with the clock's times if there is a clock
+func (s *playSession) search() chess.SearchResult {
+	l := s.limits
+	if s.clock != nil {
+		l = s.clock.Limits()
+		l.Depth, l.MoveTime = s.limits.Depth, s.limits.MoveTime
+	}
+	return s.searchWith(l)
+}
+
+// How long hints and draw offers are thought about when there are no fixed limits
+const analysisTime = time.Second
+
+// Searches for a hint or a draw offer, with the clock paused so the player isn't charged for the engine's thinking
+func (s *playSession) analyse() chess.SearchResult {
+	if s.clock != nil {
+		s.clock.Pause()
+		defer s.clock.Start()
+	}
+	l := chess.SearchLimits{Depth: s.limits.Depth, MoveTime: s.limits.MoveTime}
+	if l.Depth == 0 && l.MoveTime == 0 {
+		l.MoveTime = analysisTime
+	}
+	return s.searchWith(l)
+}
+
+func (s *playSession) searchWith(l chess.SearchLimits) chess.SearchResult {
+	s.engine.B = s.game.Board()
+	if s.engine.Evaluator != nil {
+		s.engine.SetEvaluator(s.engine.Evaluator) // attaches an incremental evaluator to the new board
+	}
+	return s.engine.SearchWith(l)
+}
+
+func (s *playSession) engineMove() {
+	b := s.game.Board()
+	if s.clock != nil {
+		s.clock.Start()
+	}
+	r := s.search()
+	fmt.Printf("%s plays %s (%s)\n", turnName(b.Turn), b.SAN(r.Move), searchSummary(r, b.Turn))
+	if len(r.PV) > 1 {
+		fmt.Println("PV:", pvString(b, r.PV))
+	}
+	s.play(r.Move)
+}
+
+// The engine accepts a draw unless it thinks it's better, two players at the terminal agree to one straight away
+func (s *playSession) offerDraw() {
+	b := s.game.Board()
+	if !s.humans[side(!b.Turn)] {
+		// Scores are from the side offering's point of view, so the engine is better when they're negative
+		if r := s.analyse(); r.Score < 0 {
+			fmt.Println("The engine declines the draw")
+			return
+		}
+		fmt.Println("The engine accepts the draw")
+	}
+	s.game.Result, s.game.Termination = "1/2-1/2", "draw agreed"
+}
+
+// Takes back moves until it's a player at the terminal's turn again, removing them from the game
+func (s *playSession) undo() {
+	if s.clock != nil {
+		fmt.Println("Moves can't be taken back in a timed game")
+		return
+	}
+
+	last := s.game.Node()
+	for s.game.Prev() {
+		if s.humans[side(s.game.Board().Turn)] {
+			break
+		}
+	}
+	if s.game.Node() == last {
+		fmt.Println("There are no moves to take back")
+		return
+	}
+
+	for last.Parent != s.game.Node() {
+		last = last.Parent
+	}
+	s.game.DeleteVariation(last) // which resets the result from the position that's left
+}
+
+func (s *playSession) savePGN(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = s.game.PGN().Write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (s *playSession) printPosition() {
+	b := s.game.Board()
+	fmt.Println()
+	fmt.Println(boardString(&b, s.flipped))
+	if s.clock != nil {
+		fmt.Printf("White %s, Black %s\n", clockString(s.clock.Remaining(chess.WhiteTurn)), clockString(s.clock.Remaining(chess.BlackTurn)))
+	}
+	if b.InCheck() {
+		fmt.Println("Check!")
+	}
+}
+
+var pieceSymbols = map[byte]string{
+	'P': "♙", 'N': "♘", 'B': "♗", 'R': "♖", 'Q': "♕", 'K': "♔",
+	'p': "♟", 'n': "♞", 'b': "♝", 'r': "♜", 'q': "♛", 'k': "♚",
+}
+
+// Draws the board like Board.String, from black's side if flipped
+func boardString(b *chess.Board, flipped bool) string {
+	var sb strings.Builder
+	ranks := b.RankStrings()
+	files := "abcdefgh"
+	for i := range 8 {
+		r := i
+		if flipped {
+			r = 7 - i
+		}
+		fmt.Fprintf(&sb, "%d|", 8-r)
+		for j := range 8 {
+			f := j
+			if flipped {
+				f = 7 - j
+			}
+			if symbol, ok := pieceSymbols[ranks[r][f]]; ok {
+				sb.WriteString(symbol)
+			} else {
+				sb.WriteByte('.')
+			}
+			sb.WriteByte(' ')
+		}
+		sb.WriteByte('\n')
+	}
+	if flipped {
+		files = "hgfedcba"
+	}
+	sb.WriteString("  ---------------\n ")
+	for _, f := range files {
+		sb.WriteString(" " + string(f))
+	}
+	return sb.String()
+}
+
+// Describes a search's score from white's point of view, in pawns, along with how far it got
+func searchSummary(r chess.SearchResult, turn chess.Turn) string {
+	if r.Depth == 0 {
+		return "book or tablebase move"
+	}
+	score := r.Score
+	if turn == chess.BlackTurn {
+		score = -score
+	}
+	eval := fmt.Sprintf("%+.2f", float64(score)/100)
+	if moves, ok := chess.MateIn(score); ok {
+		eval = fmt.Sprintf("#%d", moves)
+	}
+	return fmt.Sprintf("eval %s, depth %d, %d nodes in %v", eval, r.Depth, r.Nodes, r.Time.Round(time.Millisecond))
+}
+
+// Writes a line of moves from b in SAN with move numbers, e.g. 12... Nc6 13. d4
+func pvString(b chess.Board, pv []chess.Move) string {
+	var parts []string
+	for i, m := range pv {
+		number := b.HalfMoves/2 + 1
+		switch {
+		case b.Turn == chess.WhiteTurn:
+			parts = append(parts, fmt.Sprintf("%d.", number))
+		case i == 0:
+			parts = append(parts, fmt.Sprintf("%d...", number))
+		}
+		parts = append(parts, b.SAN(m))
+		b.Move(m)
+	}
+	return strings.Join(parts, " ")
+}
+
+func clockString(d time.Duration) string {
+	d = d.Round(time.Second / 10)
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+func turnName(t chess.Turn) string {
+	if t == chess.WhiteTurn {
+		return "White"
+	}
+	return "Black"
+}
+
+// Returns the index of a side in arrays of white then black
+func side(t chess.Turn) int {
+	if t == chess.WhiteTurn {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zakkbob/chess"
+)
+
+func syzygyFlag(fs *flag.FlagSet) *string {
+	return fs.String("syzygy", "", "directory of Syzygy tablebase files, only shown by eval for now")
+}
+
+// Loads the -syzygy directory given to a command into e, does nothing if there wasn't one
+func useSyzygy(dir string, e *chess.Engine) {
+	if dir == "" {
+		return
+	}
+
+	tb, err := chess.LoadTablebase(dir)
+	if err != nil {
+		fmt.Println("Cannot load tablebases:", err.Error())
+		os.Exit(1)
+	}
+	e.TB = tb
+}
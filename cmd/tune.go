@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/zakkbob/chess"
+)
+
+func tuneCommand(args []string) {
+	fs := flag.NewFlagSet("tune", flag.ExitOnError)
+	params := paramsFlag(fs)
+	k := fs.Float64("k", 0, "sigmoid scaling constant (0 fits it to the dataset first)")
+	step := fs.Int("step", 1, "amount each weight is nudged by")
+	iterations := fs.Int("iterations", 0, "maximum passes over every weight (0 runs until nothing improves)")
+	workers := fs.Int("workers", runtime.NumCPU(), "number of goroutines to split the dataset across")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chess tune [flags] <dataset> <output .json or .yaml file>")
+		fmt.Fprintln(fs.Output(), "The dataset has one quiet position per line, either EPD with a c9 result, 'fen;result' or 'fen | score | result'")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	args = fs.Args()
+
+	if len(args) != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	ps, err := chess.ParseTuningData(f)
+	f.Close()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	start := loadParams(*params)
+	if *k == 0 {
+		*k = chess.FitK(ps, &start, *workers)
+	}
+	fmt.Printf("%d positions, K = %.4f, error = %.6f\n", len(ps), *k, chess.TuningError(ps, &start, *k, *workers))
+
+	began := time.Now()
+	chess.Tune(ps, start, chess.TuneOptions{
+		K:          *k,
+		Step:       *step,
+		Iterations: *iterations,
+		Workers:    *workers,
+		Progress: func(iteration int, e float64, ep chess.EvalParams) {
+			fmt.Printf("Iteration %d, error = %.6f (%v)\n", iteration, e, time.Since(began).Round(time.Second))
+
+			// Saved after every pass so a long run can be stopped at any point
+			if err := ep.Save(args[1]); err != nil {
+				fmt.Println("Cannot save params:", err.Error())
+				os.Exit(1)
+			}
+		},
+	})
+}
@@ -0,0 +1,332 @@
+package chess
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var ErrInvalidTrainingData = errors.New("Invalid training data")
+
+// A quiet position from a self-play game, labelled for tuning or training a network
+type TrainingPosition struct {
+	Board  Board
+	Score  int     // the search's score in centipawns, from white's point of view
+	Result float64 // 1 for a white win, 0.5 for a draw, 0 for a black win
+}
+
+// Writes the position as a "fen | score | result" line, which ParseTuningData reads
+func (p *TrainingPosition) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "%s | %d | %.1f\n", p.Board.FEN(), p.Score, p.Result)
+	return err
+}
+
+const PackedPositionSize = 32
+
+// Packs the position in the marlinformat layout read by common network trainers, all values little endian:
+//
+//	occupancy  uint64, bit 0 is a1 and bit 63 is h8
+//	pieces     16 bytes, a nibble for each occupied square in order, low nibble first
+//	           pawn 0, knight 1, bishop 2, rook 3, queen 4, king 5, rook which can still castle 6, +8 for black
+//	turn, ep   uint8, black to move in the top bit, en passant square below it (64 for none)
+//	halfmove   uint8
+//	fullmove   uint16
+//	score      int16, from white's point of view
+//	result     uint8, 0 for a black win, 1 for a draw, 2 for a white win
+//	unused     uint8
+//
+// Positions from legal games have at most 32 pieces, any beyond that are left out
+func (p *TrainingPosition) Pack() [PackedPositionSize]byte {
+	var buf [PackedPositionSize]byte
+	b := &p.Board
+
+	var occupancy uint64
+	n := 0
+	ranks := b.RankStrings()
+	for sq := range 64 {
+		c := ranks[7-sq/8][sq%8]
+		if c == ' ' || n == 32 {
+			continue
+		}
+
+		code := byte(strings.IndexByte("pnbrqk", c|0x20))
+		if code == 3 && castlingRook(b.CastleRights, sq, c) {
+			code = 6
+		}
+		if c >= 'a' {
+			code |= 8
+		}
+
+		occupancy |= 1 << sq
+		buf[8+n/2] |= code << (4 * (n % 2))
+		n++
+	}
+	binary.LittleEndian.PutUint64(buf[0:], occupancy)
+
+	ep := byte(64)
+	if b.CanEnPassant {
+		rank := 5
+		if b.Turn == BlackTurn {
+			rank = 2
+		}
+		ep = byte(Index(rank, b.EnPassantFile) ^ 7)
+	}
+	if b.Turn == BlackTurn {
+		ep |= 0x80
+	}
+	buf[24] = ep
+
+	buf[25] = byte(min(b.QuietMoveCounter(), math.MaxUint8))
+	binary.LittleEndian.PutUint16(buf[26:], uint16(min(b.HalfMoves/2+1, math.MaxUint16)))
+	binary.LittleEndian.PutUint16(buf[28:], uint16(int16(max(math.MinInt16, min(p.Score, math.MaxInt16)))))
+	buf[30] = byte(math.Round(p.Result * 2))
+	return buf
+}
+
+// Reports whether the rook of symbol c on sq, with a1 as 0, is one which castle rights still allow to castle
+func castlingRook(cr CastleRights, sq int, c byte) bool {
+	switch {
+	case c == 'R' && sq == 0:
+		return cr.CanWhiteQueen()
+	case c == 'R' && sq == 7:
+		return cr.CanWhiteKing()
+	case c == 'r' && sq == 56:
+		return cr.CanBlackQueen()
+	case c == 'r' && sq == 63:
+		return cr.CanBlackKing()
+	}
+	return false
+}
+
+// The inverse of Pack
+func UnpackTrainingPosition(buf [PackedPositionSize]byte) (TrainingPosition, error) {
+	var squares [64]byte
+	var castling string
+	occupancy := binary.LittleEndian.Uint64(buf[0:])
+	n := 0
+	for sq := range 64 {
+		if occupancy&(1<<sq) == 0 {
+			continue
+		}
+		if n == 32 {
+			return TrainingPosition{}, fmt.Errorf("%w: more than 32 pieces", ErrInvalidTrainingData)
+		}
+
+		code := buf[8+n/2] >> (4 * (n % 2)) & 0xf
+		n++
+		if code&7 > 6 {
+			return TrainingPosition{}, fmt.Errorf("%w: piece code %d", ErrInvalidTrainingData, code)
+		}
+
+		c := "pnbrqkr"[code&7]
+		if code&8 == 0 {
+			c -= 0x20
+		}
+		if code&7 == 6 {
+			right, ok := map[int]string{0: "Q", 7: "K", 56: "q", 63: "k"}[sq]
+			if !ok || !castlingRook(CastleRightsFromString(right), sq, c) {
+				return TrainingPosition{}, fmt.Errorf("%w: castling rook on %s", ErrInvalidTrainingData, AlgebraicFromIndex(sq^7))
+			}
+			castling += right
+		}
+		squares[sq] = c
+	}
+
+	var fen strings.Builder
+	for r := 7; r >= 0; r-- {
+		empty := 0
+		for f := range 8 {
+			c := squares[r*8+f]
+			if c == 0 {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				fen.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			fen.WriteByte(c)
+		}
+		if empty > 0 {
+			fen.WriteString(strconv.Itoa(empty))
+		}
+		if r > 0 {
+			fen.WriteByte('/')
+		}
+	}
+
+	if buf[24]&0x80 == 0 {
+		fen.WriteString(" w ")
+	} else {
+		fen.WriteString(" b ")
+	}
+	fen.WriteString(CastleRightsFromString(castling).String())
+	if ep := int(buf[24] & 0x7f); ep == 64 {
+		fen.WriteString(" -")
+	} else {
+		fen.WriteString(" " + AlgebraicFromIndex(ep^7))
+	}
+	fen.WriteString(fmt.Sprintf(" %d %d", buf[25], binary.LittleEndian.Uint16(buf[26:])))
+
+	b, err := BoardFromFEN(fen.String())
+	if err != nil {
+		return TrainingPosition{}, fmt.Errorf("%w: %s: %w", ErrInvalidTrainingData, fen.String(), err)
+	}
+	if buf[30] > 2 {
+		return TrainingPosition{}, fmt.Errorf("%w: result %d", ErrInvalidTrainingData, buf[30])
+	}
+	return TrainingPosition{
+		Board:  b,
+		Score:  int(int16(binary.LittleEndian.Uint16(buf[28:]))),
+		Result: float64(buf[30]) / 2,
+	}, nil
+}
+
+type DatagenOptions struct {
+	Games       int
+	RandomPlies int          // random legal moves played from the starting position before the engine takes over
+	Limits      SearchLimits // for every move, only depth and node limits keep the games reproducible
+	Workers     int
+	Seed        int64 // game i's opening is chosen with a source seeded by Seed+i, whatever the number of workers
+
+	// Creates an engine for each worker, which gets its board and tables reset before every game
+	// Engines with the default parameters are used when nil
+	NewEngine func() *Engine
+}
+
+// Plays self-play games across workers goroutines, passing the positions worth keeping from each to emit in the
+// order the games were numbered
+// Positions in check, where the best move is a capture or promotion, or with a mate, tablebase or known endgame win score
+// are left out
+// Stops at the first error returned by emit
+func GenerateData(opts DatagenOptions, emit func(game int, ps []TrainingPosition) error) error {
+	newEngine := opts.NewEngine
+	if newEngine == nil {
+		newEngine = func() *Engine {
+			return &Engine{TT: *NewTranspositionTable(16), PT: *NewPawnTable(12), EP: DefaultParams}
+		}
+	}
+
+	type finished struct {
+		game int
+		ps   []TrainingPosition
+	}
+	jobs := make(chan int)
+	results := make(chan finished)
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range max(1, min(opts.Workers, opts.Games)) {
+		e := newEngine()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for g := range jobs {
+				rng := rand.New(rand.NewSource(opts.Seed + int64(g)))
+				select {
+				case results <- finished{g, playDatagenGame(e, &opts, rng)}:
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for g := range opts.Games {
+			select {
+			case jobs <- g:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Games finish out of order, so later ones wait here until the ones before them are done
+	pending := map[int][]TrainingPosition{}
+	next := 0
+	var err error
+	for f := range results {
+		if err != nil {
+			continue
+		}
+		pending[f.game] = f.ps
+		for ps, ok := pending[next]; ok; ps, ok = pending[next] {
+			delete(pending, next)
+			if err = emit(next, ps); err != nil {
+				close(stop)
+				break
+			}
+			next++
+		}
+	}
+	return err
+}
+
+// Plays a game with e against itself from a random opening, returning the positions worth keeping
+func playDatagenGame(e *Engine, opts *DatagenOptions, rng *rand.Rand) []TrainingPosition {
+	b := randomOpening(opts.RandomPlies, rng)
+	e.TT.Clear()
+	e.PT.Clear()
+
+	var ps []TrainingPosition
+	result, _ := b.Result()
+	for result == "*" {
+		e.B = b.Copy()
+		if e.Evaluator != nil {
+			e.SetEvaluator(e.Evaluator) // attaches an incremental evaluator to the new board
+		}
+		sr := e.SearchWith(opts.Limits)
+
+		if keepForTraining(&b, sr) {
+			score := sr.Score
+			if b.Turn == BlackTurn {
+				score = -score
+			}
+			ps = append(ps, TrainingPosition{Board: b.Copy(), Score: score})
+		}
+
+		b.Move(sr.Move)
+		result, _ = b.Result()
+	}
+
+	value := map[string]float64{"1-0": 1, "1/2-1/2": 0.5, "0-1": 0}[result]
+	for i := range ps {
+		ps[i].Result = value
+	}
+	return ps
+}
+
+// Reports whether a position is worth training on, leaving out those where the score isn't a quiet evaluation
+// Known wins are offset by knownWinEval, and mate and tablebase scores are far beyond it
+func keepForTraining(b *Board, sr SearchResult) bool {
+	quiet := sr.Move.Capture() == NoCapture && sr.Move.Promotion() == NoPromotion
+	return quiet && abs(sr.Score) <= knownWinEval && sr.Depth > 0 && !b.InCheck()
+}
+
+// Plays random legal moves from the starting position, trying again if they end the game
+func randomOpening(plies int, rng *rand.Rand) Board {
+	for {
+		b := NewBoard()
+		for range plies {
+			ms, status := b.LegalMoves()
+			if status != InProgress {
+				break
+			}
+			b.Move(ms[rng.Intn(len(ms))])
+		}
+		if result, _ := b.Result(); result == "*" {
+			return b
+		}
+	}
+}
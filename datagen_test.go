@@ -0,0 +1,94 @@
+package chess_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestTrainingPositionPack(t *testing.T) {
+	for _, fen := range []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w Kq - 3 10",
+		"rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 3",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 99 300",
+	} {
+		b, err := chess.BoardFromFEN(fen)
+		require.NoError(t, err)
+
+		p := chess.TrainingPosition{Board: b, Score: -123, Result: 0.5}
+		buf := p.Pack()
+		unpacked, err := chess.UnpackTrainingPosition(buf)
+		require.NoError(t, err, fen)
+		assert.Equal(t, fen, unpacked.Board.FEN())
+		assert.Equal(t, -123, unpacked.Score)
+		assert.Equal(t, 0.5, unpacked.Result)
+	}
+
+	b := chess.NewBoard()
+	buf := (&chess.TrainingPosition{Board: b, Score: 50000, Result: 1}).Pack()
+	assert.Equal(t, []byte{0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff}, buf[:8])
+	assert.Equal(t, byte(0x16), buf[8], "a1 castling rook then b1 knight")
+	assert.Equal(t, byte(64), buf[24])
+	assert.Equal(t, []byte{0xff, 0x7f, 2}, buf[28:31], "the score is clamped")
+
+	buf[30] = 3
+	_, err := chess.UnpackTrainingPosition(buf)
+	assert.ErrorIs(t, err, chess.ErrInvalidTrainingData)
+}
+
+func TestTrainingPositionWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	p := chess.TrainingPosition{Board: chess.NewBoard(), Score: 35, Result: 0}
+	require.NoError(t, p.WriteText(&buf))
+	assert.Equal(t, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 | 35 | 0.0\n", buf.String())
+
+	ps, err := chess.ParseTuningData(&buf)
+	require.NoError(t, err)
+	require.Len(t, ps, 1)
+	assert.Equal(t, 0.0, ps[0].Result)
+}
+
+func TestGenerateData(t *testing.T) {
+	generate := func(workers int) string {
+		var out bytes.Buffer
+		var games []int
+		err := chess.GenerateData(chess.DatagenOptions{
+			Games:       4,
+			RandomPlies: 8,
+			Limits:      chess.SearchLimits{Depth: 1},
+			Workers:     workers,
+			Seed:        7,
+		}, func(game int, ps []chess.TrainingPosition) error {
+			games = append(games, game)
+			for _, p := range ps {
+				assert.False(t, p.Board.InCheck(), p.Board.FEN())
+				assert.Contains(t, []float64{0, 0.5, 1}, p.Result)
+				require.NoError(t, p.WriteText(&out))
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int{0, 1, 2, 3}, games, "games are emitted in order")
+		return out.String()
+	}
+
+	single := generate(1)
+	assert.NotEmpty(t, single)
+	assert.Equal(t, single, generate(3), "the output doesn't depend on the number of workers")
+
+	stop := errors.New("stop")
+	calls := 0
+	err := chess.GenerateData(chess.DatagenOptions{Games: 10, Limits: chess.SearchLimits{Depth: 1}, Workers: 2},
+		func(game int, ps []chess.TrainingPosition) error {
+			calls++
+			return fmt.Errorf("game %d: %w", game, stop)
+		})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,231 @@
+package chess
+
+import "math/bits"
+
+// Specialised evaluation of endgames the general evaluation gets wrong, picked by material signature
+
+// Added to won endgames so they score above anything the general evaluation can reach, but below mate and tablebase wins
+const knownWinEval = 10000
+
+// Scale of 1, scaled scores are multiplied by scale/scaleNormal
+const scaleNormal = 64
+
+const darkSquares uint64 = 0x55AA55AA55AA55AA
+
+type endgame struct {
+	name  string
+	score int  // from the side to move's point of view, only used if exact
+	exact bool // replaces the evaluation rather than scaling it
+	scale int
+}
+
+// Returns the endgame's score, given the evaluation's score
+func (eg endgame) apply(score int) int {
+	if eg.exact {
+		return eg.score
+	}
+	return score * eg.scale / scaleNormal
+}
+
+// Number of each piece, indexed by colour (white first) then pawn, knight, bishop, rook, queen, king
+func (b *Board) materialCounts() [2][6]int {
+	var counts [2][6]int
+	for p, bb := range b.pieceBoards() {
+		counts[p/6][p%6] = bits.OnesCount64(bb)
+	}
+	return counts
+}
+
+// Pawns, knights, bishops, rooks, queens and king of one side
+func (b *Board) sidePieces(white bool) [6]uint64 {
+	pieces := b.pieceBoards()
+	if white {
+		return [6]uint64(pieces[:6])
+	}
+	return [6]uint64(pieces[6:])
+}
+
+type endgameRecogniser func(b *Board, counts [2][6]int) (endgame, bool)
+
+var endgameRecognisers = []endgameRecogniser{
+	recogniseKnownDraw,
+	recogniseKPK,
+	recogniseKBNK,
+	recogniseKXK,
+	recogniseWrongBishop,
+	recogniseOppositeBishops,
+}
+
+// Returns the specialised evaluation for b's material, or false if there isn't one
+func evaluateEndgame(b *Board) (endgame, bool) {
+	counts := b.materialCounts()
+	for _, recognise := range endgameRecognisers {
+		if eg, ok := recognise(b, counts); ok {
+			return eg, true
+		}
+	}
+	return endgame{}, false
+}
+
+// A win for white (or black, if white is false), scored from the side to move's point of view
+func wonEndgame(b *Board, name string, white bool, score int) endgame {
+	score += knownWinEval
+	if white != (b.Turn == WhiteTurn) {
+		score = -score
+	}
+	return endgame{name: name, score: score, exact: true}
+}
+
+func drawnEndgame(name string) endgame {
+	return endgame{name: name, exact: true}
+}
+
+// Material other than pawns and the king
+func pieceCount(side [6]int) int {
+	return side[1] + side[2] + side[3] + side[4]
+}
+
+func bareKing(side [6]int) bool {
+	return side[0] == 0 && pieceCount(side) == 0
+}
+
+func nonPawnMaterial(side [6]int) int {
+	return side[1]*knightValue + side[2]*bishopValue + side[3]*rookValue + side[4]*queenValue
+}
+
+// Manhattan distance from the four centre squares, 0 to 6
+func centreDistance(i int) int {
+	rank, file := i/8, i%8
+	return max(3-rank, rank-4) + max(3-file, file-4)
+}
+
+func manhattanDistance(a, b int) int {
+	return abs(a/8-b/8) + abs(a%8-b%8)
+}
+
+// Neither side can mate with at most a minor piece each, or two knights against a bare king
+func recogniseKnownDraw(b *Board, counts [2][6]int) (endgame, bool) {
+	for _, side := range counts {
+		if side[0] != 0 || side[3] != 0 || side[4] != 0 {
+			return endgame{}, false
+		}
+	}
+	white, black := counts[0], counts[1]
+	if pieceCount(white) <= 1 && pieceCount(black) <= 1 {
+		return drawnEndgame("Known draw"), true
+	}
+	if (white[1] == 2 && white[2] == 0 && bareKing(black)) || (black[1] == 2 && black[2] == 0 && bareKing(white)) {
+		return drawnEndgame("KNNK"), true
+	}
+	return endgame{}, false
+}
+
+// Probes the KPK bitbase, a won position scores more the further up the pawn is
+func recogniseKPK(b *Board, counts [2][6]int) (endgame, bool) {
+	white := counts[0][0] == 1
+	strong, weak := counts[0], counts[1]
+	if !white {
+		strong, weak = weak, strong
+	}
+	if strong[0] != 1 || pieceCount(strong) != 0 || !bareKing(weak) {
+		return endgame{}, false
+	}
+
+	us, them := b.sidePieces(white), b.sidePieces(!white)
+	king, pawn, enemyKing := bits.TrailingZeros64(us[5]), bits.TrailingZeros64(us[0]), bits.TrailingZeros64(them[5])
+	weakToMove := white == (b.Turn == BlackTurn)
+	if !white {
+		king, pawn, enemyKing = king^56, pawn^56, enemyKing^56
+	}
+
+	if !kpkProbe(weakToMove, king, pawn, enemyKing) {
+		return drawnEndgame("KPK"), true
+	}
+	return wonEndgame(b, "KPK", white, pawnValue+10*(pawn/8)), true
+}
+
+// Bishop and knight mate, which has to happen in a corner the bishop covers
+func recogniseKBNK(b *Board, counts [2][6]int) (endgame, bool) {
+	for c, white := range []bool{true, false} {
+		strong, weak := counts[c], counts[1-c]
+		if strong[0] != 0 || strong[1] != 1 || strong[2] != 1 || pieceCount(strong) != 2 || !bareKing(weak) {
+			continue
+		}
+
+		us, them := b.sidePieces(white), b.sidePieces(!white)
+		king, enemyKing := bits.TrailingZeros64(us[5]), bits.TrailingZeros64(them[5])
+
+		// a1 and h8 are dark, h1 and a8 are light
+		corners := [2]int{0, 63}
+		if us[2]&darkSquares != 0 {
+			corners = [2]int{7, 56}
+		}
+		cornerDistance := min(manhattanDistance(enemyKing, corners[0]), manhattanDistance(enemyKing, corners[1]))
+
+		score := nonPawnMaterial(strong) + 20*(14-cornerDistance) + 10*(7-squareDistances[king][enemyKing])
+		return wonEndgame(b, "KBNK", white, score), true
+	}
+	return endgame{}, false
+}
+
+// A rook or queen against a bare king, mated by driving the king to the edge
+func recogniseKXK(b *Board, counts [2][6]int) (endgame, bool) {
+	for c, white := range []bool{true, false} {
+		strong, weak := counts[c], counts[1-c]
+		if (strong[3] == 0 && strong[4] == 0) || !bareKing(weak) {
+			continue
+		}
+
+		us, them := b.sidePieces(white), b.sidePieces(!white)
+		king, enemyKing := bits.TrailingZeros64(us[5]), bits.TrailingZeros64(them[5])
+
+		score := nonPawnMaterial(strong) + strong[0]*pawnValue +
+			20*centreDistance(enemyKing) + 10*(7-squareDistances[king][enemyKing])
+		return wonEndgame(b, "KXK", white, score), true
+	}
+	return endgame{}, false
+}
+
+// Bishop and rook pawns can't win if the bishop doesn't cover the promotion square and the king gets to the corner
+func recogniseWrongBishop(b *Board, counts [2][6]int) (endgame, bool) {
+	for c, white := range []bool{true, false} {
+		strong, weak := counts[c], counts[1-c]
+		if strong[0] == 0 || strong[2] != 1 || pieceCount(strong) != 1 || !bareKing(weak) {
+			continue
+		}
+
+		us, them := b.sidePieces(white), b.sidePieces(!white)
+		var promotion int
+		switch {
+		case us[0]&^fileMasks[0] == 0: // h-file
+			promotion = relativeSquare(56, white)
+		case us[0]&^fileMasks[7] == 0: // a-file
+			promotion = relativeSquare(63, white)
+		default:
+			continue
+		}
+
+		bishopDark, promotionDark := us[2]&darkSquares != 0, darkSquares&(1<<promotion) != 0
+		if bishopDark != promotionDark && squareDistances[bits.TrailingZeros64(them[5])][promotion] <= 1 {
+			return drawnEndgame("Wrong bishop"), true
+		}
+	}
+	return endgame{}, false
+}
+
+// Bishops on opposite colours with only pawns otherwise are very drawish, though less so the more pawns are up
+func recogniseOppositeBishops(b *Board, counts [2][6]int) (endgame, bool) {
+	white, black := counts[0], counts[1]
+	if white[2] != 1 || black[2] != 1 || pieceCount(white) != 1 || pieceCount(black) != 1 {
+		return endgame{}, false
+	}
+	if (b.whiteBishops&darkSquares == 0) == (b.blackBishops&darkSquares == 0) {
+		return endgame{}, false
+	}
+
+	scale := 16
+	if abs(white[0]-black[0]) > 1 {
+		scale = 32
+	}
+	return endgame{name: "Opposite-coloured bishops", scale: scale}, true
+}
@@ -0,0 +1,166 @@
+package chess
+
+import (
+	"math/bits"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func evaluateFEN(t *testing.T, fen string) int {
+	t.Helper()
+	b, err := BoardFromFEN(fen)
+	require.NoError(t, err)
+	e := Engine{B: b, EP: DefaultParams}
+	return e.Evaluate()
+}
+
+func TestKPK(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want int // 1 if the side to move wins, -1 if it loses, 0 if drawn
+	}{
+		{"outside the square", "8/8/8/8/8/7k/P7/K7 w - - 0 1", 1},
+		{"outside the square, black to move", "8/8/8/8/8/7k/P7/K7 b - - 0 1", -1},
+		{"rook pawn", "k7/8/K7/P7/8/8/8/8 w - - 0 1", 0},
+		{"king in front", "4k3/8/4K3/4P3/8/8/8/8 w - - 0 1", 1},
+		{"king in front, black to move", "4k3/8/4K3/4P3/8/8/8/8 b - - 0 1", -1},
+		{"opposition", "8/3k4/8/3K4/3P4/8/8/8 w - - 0 1", 0},
+		{"opposition, black to move", "8/3k4/8/3K4/3P4/8/8/8 b - - 0 1", -1},
+		{"pawn can be taken", "8/8/8/8/8/8/kP6/7K b - - 0 1", 0},
+		{"black pawn", "8/8/8/3p4/3k4/8/3K4/8 b - - 0 1", 0},
+		{"black pawn, white to move", "8/8/8/3p4/3k4/8/3K4/8 w - - 0 1", -1},
+		{"black pawn on the h-file", "7k/7p/8/8/8/K7/8/8 b - - 0 1", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := evaluateFEN(t, tt.fen)
+			switch tt.want {
+			case 1:
+				assert.Greater(t, score, knownWinEval)
+			case -1:
+				assert.Less(t, score, -knownWinEval)
+			default:
+				assert.Zero(t, score)
+			}
+		})
+	}
+}
+
+// Count of won positions from an independent solver
+func TestKPKBitbase(t *testing.T) {
+	wins := 0
+	for _, bb := range kpkBitbase {
+		wins += bits.OnesCount64(bb)
+	}
+	assert.Equal(t, 111282, wins)
+}
+
+func TestKnownDraws(t *testing.T) {
+	for _, fen := range []string{
+		"8/8/8/4k3/8/8/8/K7 w - - 0 1",
+		"8/8/8/4k3/8/8/8/KN6 w - - 0 1",
+		"8/8/8/4k3/8/8/8/KB6 b - - 0 1",
+		"8/8/3n4/4k3/8/8/8/KB6 w - - 0 1",
+		"8/8/8/4k3/8/8/8/KNN5 w - - 0 1",
+		"8/8/8/4k3/8/8/8/KB5b w - - 0 1",
+	} {
+		assert.Zero(t, evaluateFEN(t, fen), fen)
+	}
+
+	// Mating material, so the general evaluation applies
+	b, err := BoardFromFEN("8/8/8/4k3/8/8/8/KBB5 w - - 0 1")
+	require.NoError(t, err)
+	_, ok := evaluateEndgame(&b)
+	assert.False(t, ok)
+}
+
+func TestKXK(t *testing.T) {
+	centre := evaluateFEN(t, "8/8/8/4k3/8/8/8/KR6 w - - 0 1")
+	edge := evaluateFEN(t, "4k3/8/8/8/8/8/8/KR6 w - - 0 1")
+	corner := evaluateFEN(t, "7k/8/8/8/8/8/8/KR6 w - - 0 1")
+	closer := evaluateFEN(t, "7k/8/5K2/8/8/8/8/1R6 w - - 0 1")
+
+	assert.Greater(t, centre, knownWinEval)
+	assert.Greater(t, edge, centre)
+	assert.Greater(t, corner, edge)
+	assert.Greater(t, closer, corner)
+	assert.Greater(t, evaluateFEN(t, "8/8/8/4k3/8/8/8/KQ6 w - - 0 1"), centre)
+
+	assert.Equal(t, -corner, evaluateFEN(t, "7k/8/8/8/8/8/8/KR6 b - - 0 1"))
+	assert.Equal(t, corner, evaluateFEN(t, "kr6/8/8/8/8/8/8/7K b - - 0 1"))
+}
+
+func TestKBNK(t *testing.T) {
+	// Dark squared bishop, so a1 and h8 are the corners to mate in
+	right := evaluateFEN(t, "7k/8/5K2/8/8/8/8/2B1N3 w - - 0 1")
+	wrong := evaluateFEN(t, "k7/8/2K5/8/8/8/8/2B1N3 w - - 0 1")
+	centre := evaluateFEN(t, "8/8/8/3k4/8/8/8/K1B1N3 w - - 0 1")
+
+	assert.Greater(t, wrong, knownWinEval)
+	assert.Greater(t, right, wrong)
+	assert.Greater(t, right, centre)
+	assert.Equal(t, -right, evaluateFEN(t, "2b1n3/8/8/8/8/5k2/8/7K w - - 0 1"))
+}
+
+func TestWrongBishop(t *testing.T) {
+	assert.Zero(t, evaluateFEN(t, "k7/8/8/8/8/8/P7/K1B5 w - - 0 1"))
+	assert.Zero(t, evaluateFEN(t, "k7/7p/8/8/8/8/8/4b2K w - - 0 1"))
+
+	for _, fen := range []string{
+		"k7/8/8/8/8/8/P7/KB6 w - - 0 1",   // covers a8
+		"8/8/8/4k3/8/8/P7/K1B5 w - - 0 1", // the king is too far away
+		"k7/8/8/8/8/8/PP6/K1B5 w - - 0 1", // not just rook pawns
+		"k7/8/8/8/8/8/P6P/K1B5 w - - 0 1", // both rook files
+	} {
+		b, err := BoardFromFEN(fen)
+		require.NoError(t, err)
+		eg, ok := evaluateEndgame(&b)
+		assert.False(t, ok && eg.name == "Wrong bishop", fen)
+	}
+}
+
+func TestOppositeBishops(t *testing.T) {
+	tests := []struct {
+		fen   string
+		scale int
+	}{
+		{"4k3/4b3/8/3p4/8/8/2PP4/4KB2 w - - 0 1", 16},
+		{"4k3/4b3/8/8/8/8/1PPP4/4KB2 w - - 0 1", 32},
+		{"4k3/5b2/8/3p4/8/8/2PP4/4KB2 w - - 0 1", scaleNormal}, // same colour
+		{"4k3/4b3/8/3p4/8/8/2PP4/3RKB2 w - - 0 1", scaleNormal},
+	}
+
+	for _, tt := range tests {
+		b, err := BoardFromFEN(tt.fen)
+		require.NoError(t, err)
+		e := Engine{B: b, EP: DefaultParams}
+		assert.Equal(t, evaluateTerms(&b, &DefaultParams, nil, nil)*tt.scale/scaleNormal, e.Evaluate(), tt.fen)
+	}
+}
+
+// A skewer wins the rook, so the search scores the quiet check as a known KQvK win
+func TestKeepForTrainingKnownWin(t *testing.T) {
+	b, err := BoardFromFEN("8/8/8/3k4/8/8/r7/6QK w - - 0 1")
+	require.NoError(t, err)
+	e := Engine{B: b.Copy(), TT: *NewTranspositionTable(10), PT: *NewPawnTable(10), EP: DefaultParams}
+	sr := e.SearchWith(SearchLimits{Depth: 4})
+	require.Equal(t, NoCapture, sr.Move.Capture())
+	_, mate := MateIn(sr.Score)
+	require.False(t, mate)
+	require.Greater(t, sr.Score, knownWinEval)
+	assert.False(t, keepForTraining(&b, sr))
+}
+
+func TestEndgameTrace(t *testing.T) {
+	b, err := BoardFromFEN("4k3/4b3/8/3p4/8/8/2PP4/4KB2 w - - 0 1")
+	require.NoError(t, err)
+	e := Engine{B: b, EP: DefaultParams}
+
+	trace := e.EvaluateTrace()
+	assert.Equal(t, "Opposite-coloured bishops", trace.Endgame)
+	assert.Equal(t, e.Evaluate(), trace.Score)
+}
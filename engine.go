@@ -1,79 +1,263 @@
 package chess
 
+import "time"
+
 type Engine struct {
 	B  Board
 	TT TranspositionTable
+	PT PawnTable
 	EP EvalParams
+
+	// Replaces the classic evaluation using EP and PT when set, see SetEvaluator
+	Evaluator Evaluator
+
+	// Not used by the search until probing has been checked against real Syzygy files, can be nil
+	TB *Tablebase
+
+	// Consulted before searching, can be nil
+	Book *Book
+
+	// Search state, reset by every search
+	nodes    int
+	stopped  bool
+	maxNodes int       // 0 for no limit
+	deadline time.Time // zero for no limit
 }
 
+// Indexes of the middlegame and endgame values in each EvalParams pair
+const (
+	MG = 0
+	EG = 1
+)
+
+// Every weight is a {middlegame, endgame} pair, Evaluate interpolates between them using the game phase
+// Piece-square tables are from white's point of view, with a8 first
 type EvalParams struct {
-	PawnVals   [64]int
-	RookVals   [64]int
-	KnightVals [64]int
-	BishopVals [64]int
-	QueenVals  [64]int
-	KingVals   [64]int
+	PawnWt   [2]int
+	KnightWt [2]int
+	BishopWt [2]int
+	RookWt   [2]int
+	QueenWt  [2]int
+
+	PawnVals   [2][64]int
+	RookVals   [2][64]int
+	KnightVals [2][64]int
+	BishopVals [2][64]int
+	QueenVals  [2][64]int
+	KingVals   [2][64]int
+
+	// Pawn structure, penalties are negative
+	DoubledPawn   [2]int // per extra pawn on a file
+	IsolatedPawn  [2]int
+	BackwardPawn  [2]int
+	ConnectedPawn [2]int
+	PassedPawn    [2][8]int // indexed by how far the pawn has advanced
+
+	// Passed pawn terms which depend on the other pieces
+	PassedFreePath          [2]int // nothing stands between the pawn and promotion
+	PassedOwnKingDistance   [2]int // per square between the own king and the pawn's stop square
+	PassedEnemyKingDistance [2]int // per square between the enemy king and the pawn's stop square
+
+	// Per square attacked, excluding squares occupied by our own pieces or defended by enemy pawns
+	KnightMobility [2]int
+	BishopMobility [2]int
+	RookMobility   [2]int
+	QueenMobility  [2]int
+
+	// King safety, from the point of view of the side which owns the king
+	PawnShield         [2]int // per pawn directly in front of the king, or on an adjacent file
+	PawnShieldAdvanced [2]int // per pawn two squares in front
+	KingOpenFile       [2]int // per file next to the king with no pawns
+	KingSemiOpenFile   [2]int // per file next to the king with only enemy pawns
+
+	// Attacks on the enemy king, each knight, bishop, rook or queen hitting the squares around it adds its
+	// weight to an index into KingAttack, which is only used when there are at least two attackers
+	KingAttackWeights [4]int
+	KingAttack        [2][16]int
+
+	BishopPair       [2]int
+	RookOpenFile     [2]int
+	RookSemiOpenFile [2]int
+	RookSeventh      [2]int // only when the enemy king is on the eighth rank, or there are enemy pawns to attack
+	KnightOutpost    [2]int // ranks 4-6, supported by a pawn and can't be attacked by enemy pawns
+	TrappedBishop    [2]int // on a7/h7 or a6/h6, with an enemy pawn on b6/g6 or b5/g5 blocking its escape
+	TrappedRook      [2]int // stuck in the corner by an uncastled king
 }
 
 var DefaultParams = EvalParams{
-	PawnVals: [64]int{
-		00, 00, 00, 00, 00, 00, 00, 00,
-		10, 20, 20, 30, 30, 20, 20, 10,
-		0, 10, 10, 20, 20, 10, 10, 00,
-		-10, 00, 00, 10, 10, 00, 00, -10,
-		-20, -10, -10, 00, 00, -10, -10, -20,
-		-30, -20, -20, -20, -20, -20, -20, -30,
-		-40, -30, -30, -30, -30, -30, -30, -40,
-		00, 00, 00, 00, 00, 00, 00, 00,
+	PawnWt:   [2]int{100, 110},
+	KnightWt: [2]int{300, 290},
+	BishopWt: [2]int{300, 310},
+	RookWt:   [2]int{500, 520},
+	QueenWt:  [2]int{900, 930},
+
+	PawnVals: [2][64]int{
+		{
+			00, 00, 00, 00, 00, 00, 00, 00,
+			10, 20, 20, 30, 30, 20, 20, 10,
+			0, 10, 10, 20, 20, 10, 10, 00,
+			-10, 00, 00, 10, 10, 00, 00, -10,
+			-20, -10, -10, 00, 00, -10, -10, -20,
+			-30, -20, -20, -20, -20, -20, -20, -30,
+			-40, -30, -30, -30, -30, -30, -30, -40,
+			00, 00, 00, 00, 00, 00, 00, 00,
+		},
+		{
+			00, 00, 00, 00, 00, 00, 00, 00,
+			80, 80, 80, 80, 80, 80, 80, 80,
+			50, 50, 50, 50, 50, 50, 50, 50,
+			30, 30, 30, 30, 30, 30, 30, 30,
+			15, 15, 15, 15, 15, 15, 15, 15,
+			5, 5, 5, 5, 5, 5, 5, 5,
+			00, 00, 00, 00, 00, 00, 00, 00,
+			00, 00, 00, 00, 00, 00, 00, 00,
+		},
 	},
-	RookVals: [64]int{
-		-20, -20, -10, 00, 00, -10, -20, -20,
-		-20, -10, 00, 10, 10, 00, -10, -20,
-		-10, 00, 10, 20, 20, 10, 00, -10,
-		00, 10, 20, 30, 30, 20, 10, 00,
-		00, 10, 20, 30, 30, 20, 10, 00,
-		-10, 00, 10, 20, 20, 10, 00, -10,
-		-20, -10, 00, 10, 10, 00, -10, -20,
-		-20, -20, -10, 00, 00, -10, -20, -20,
+	RookVals: [2][64]int{
+		{
+			-20, -20, -10, 00, 00, -10, -20, -20,
+			-20, -10, 00, 10, 10, 00, -10, -20,
+			-10, 00, 10, 20, 20, 10, 00, -10,
+			00, 10, 20, 30, 30, 20, 10, 00,
+			00, 10, 20, 30, 30, 20, 10, 00,
+			-10, 00, 10, 20, 20, 10, 00, -10,
+			-20, -10, 00, 10, 10, 00, -10, -20,
+			-20, -20, -10, 00, 00, -10, -20, -20,
+		},
+		{
+			-20, -20, -10, 00, 00, -10, -20, -20,
+			-20, -10, 00, 10, 10, 00, -10, -20,
+			-10, 00, 10, 20, 20, 10, 00, -10,
+			00, 10, 20, 30, 30, 20, 10, 00,
+			00, 10, 20, 30, 30, 20, 10, 00,
+			-10, 00, 10, 20, 20, 10, 00, -10,
+			-20, -10, 00, 10, 10, 00, -10, -20,
+			-20, -20, -10, 00, 00, -10, -20, -20,
+		},
 	},
-	KnightVals: [64]int{
-		-50, -40, -30, -30, -30, -30, -40, -50,
-		-40, -20, 00, 5, 5, 00, -20, -40,
-		-30, 00, 10, 15, 15, 10, 00, -30,
-		-30, 5, 15, 20, 20, 15, 5, -30,
-		-30, 5, 15, 20, 20, 15, 5, -30,
-		-30, 00, 10, 15, 15, 10, 00, -30,
-		-40, -20, 00, 5, 5, 00, -20, -40,
-		-50, -40, -30, -30, -30, -30, -40, -50,
+	KnightVals: [2][64]int{
+		{
+			-50, -40, -30, -30, -30, -30, -40, -50,
+			-40, -20, 00, 5, 5, 00, -20, -40,
+			-30, 00, 10, 15, 15, 10, 00, -30,
+			-30, 5, 15, 20, 20, 15, 5, -30,
+			-30, 5, 15, 20, 20, 15, 5, -30,
+			-30, 00, 10, 15, 15, 10, 00, -30,
+			-40, -20, 00, 5, 5, 00, -20, -40,
+			-50, -40, -30, -30, -30, -30, -40, -50,
+		},
+		{
+			-50, -40, -30, -30, -30, -30, -40, -50,
+			-40, -20, 00, 5, 5, 00, -20, -40,
+			-30, 00, 10, 15, 15, 10, 00, -30,
+			-30, 5, 15, 20, 20, 15, 5, -30,
+			-30, 5, 15, 20, 20, 15, 5, -30,
+			-30, 00, 10, 15, 15, 10, 00, -30,
+			-40, -20, 00, 5, 5, 00, -20, -40,
+			-50, -40, -30, -30, -30, -30, -40, -50,
+		},
 	},
-	BishopVals: [64]int{
-		-50, -40, -30, -30, -30, -30, -40, -50,
-		-40, -20, 00, 5, 5, 00, -20, -40,
-		-30, 00, 10, 15, 15, 10, 00, -30,
-		-30, 5, 15, 20, 20, 15, 5, -30,
-		-30, 5, 15, 20, 20, 15, 5, -30,
-		-30, 00, 10, 15, 15, 10, 00, -30,
-		-40, -20, 00, 5, 5, 00, -20, -40,
-		-50, -40, -30, -30, -30, -30, -40, -50,
+	BishopVals: [2][64]int{
+		{
+			-50, -40, -30, -30, -30, -30, -40, -50,
+			-40, -20, 00, 5, 5, 00, -20, -40,
+			-30, 00, 10, 15, 15, 10, 00, -30,
+			-30, 5, 15, 20, 20, 15, 5, -30,
+			-30, 5, 15, 20, 20, 15, 5, -30,
+			-30, 00, 10, 15, 15, 10, 00, -30,
+			-40, -20, 00, 5, 5, 00, -20, -40,
+			-50, -40, -30, -30, -30, -30, -40, -50,
+		},
+		{
+			-50, -40, -30, -30, -30, -30, -40, -50,
+			-40, -20, 00, 5, 5, 00, -20, -40,
+			-30, 00, 10, 15, 15, 10, 00, -30,
+			-30, 5, 15, 20, 20, 15, 5, -30,
+			-30, 5, 15, 20, 20, 15, 5, -30,
+			-30, 00, 10, 15, 15, 10, 00, -30,
+			-40, -20, 00, 5, 5, 00, -20, -40,
+			-50, -40, -30, -30, -30, -30, -40, -50,
+		},
 	},
-	QueenVals: [64]int{
-		-20, -20, -10, 00, 00, -10, -20, -20,
-		-20, -10, 00, 10, 10, 00, -10, -20,
-		-10, 00, 10, 20, 20, 10, 00, -10,
-		00, 10, 20, 30, 30, 20, 10, 00,
-		00, 10, 20, 30, 30, 20, 10, 00,
-		-10, 00, 10, 20, 20, 10, 00, -10,
-		-20, -10, 00, 10, 10, 00, -10, -20,
-		-20, -20, -10, 00, 00, -10, -20, -20,
+	QueenVals: [2][64]int{
+		{
+			-20, -20, -10, 00, 00, -10, -20, -20,
+			-20, -10, 00, 10, 10, 00, -10, -20,
+			-10, 00, 10, 20, 20, 10, 00, -10,
+			00, 10, 20, 30, 30, 20, 10, 00,
+			00, 10, 20, 30, 30, 20, 10, 00,
+			-10, 00, 10, 20, 20, 10, 00, -10,
+			-20, -10, 00, 10, 10, 00, -10, -20,
+			-20, -20, -10, 00, 00, -10, -20, -20,
+		},
+		{
+			-20, -20, -10, 00, 00, -10, -20, -20,
+			-20, -10, 00, 10, 10, 00, -10, -20,
+			-10, 00, 10, 20, 20, 10, 00, -10,
+			00, 10, 20, 30, 30, 20, 10, 00,
+			00, 10, 20, 30, 30, 20, 10, 00,
+			-10, 00, 10, 20, 20, 10, 00, -10,
+			-20, -10, 00, 10, 10, 00, -10, -20,
+			-20, -20, -10, 00, 00, -10, -20, -20,
+		},
 	},
-	KingVals: [64]int{
-		-50, -50, -50, -50, -50, -50, -50, -50,
-		-40, -40, -50, -50, -50, -50, -40, -40,
-		-30, -30, -40, -50, -50, -40, -30, -30,
-		-20, -20, -30, -40, -40, -30, -20, -20,
-		-10, -10, -20, -30, -30, -20, -10, -10,
-		0, 0, -10, -20, -20, -10, 0, 0,
-		10, 10, 0, -10, -10, 0, 10, 10,
-		20, 20, 10, 0, 0, 10, 20, 20,
+	KingVals: [2][64]int{
+		{
+			-50, -50, -50, -50, -50, -50, -50, -50,
+			-40, -40, -50, -50, -50, -50, -40, -40,
+			-30, -30, -40, -50, -50, -40, -30, -30,
+			-20, -20, -30, -40, -40, -30, -20, -20,
+			-10, -10, -20, -30, -30, -20, -10, -10,
+			0, 0, -10, -20, -20, -10, 0, 0,
+			10, 10, 0, -10, -10, 0, 10, 10,
+			20, 20, 10, 0, 0, 10, 20, 20,
+		},
+		{
+			-50, -40, -30, -20, -20, -30, -40, -50,
+			-30, -20, -10, 00, 00, -10, -20, -30,
+			-30, -10, 20, 30, 30, 20, -10, -30,
+			-30, -10, 30, 40, 40, 30, -10, -30,
+			-30, -10, 30, 40, 40, 30, -10, -30,
+			-30, -10, 20, 30, 30, 20, -10, -30,
+			-30, -30, 00, 00, 00, 00, -30, -30,
+			-50, -30, -30, -30, -30, -30, -30, -50,
+		},
 	},
+
+	DoubledPawn:   [2]int{-10, -20},
+	IsolatedPawn:  [2]int{-10, -15},
+	BackwardPawn:  [2]int{-8, -10},
+	ConnectedPawn: [2]int{8, 5},
+	PassedPawn: [2][8]int{
+		{0, 5, 5, 10, 20, 35, 55, 0},
+		{0, 10, 15, 25, 45, 70, 100, 0},
+	},
+
+	PassedFreePath:          [2]int{0, 20},
+	PassedOwnKingDistance:   [2]int{0, -4},
+	PassedEnemyKingDistance: [2]int{0, 8},
+
+	KnightMobility: [2]int{4, 4},
+	BishopMobility: [2]int{5, 5},
+	RookMobility:   [2]int{2, 4},
+	QueenMobility:  [2]int{1, 2},
+
+	PawnShield:         [2]int{10, 0},
+	PawnShieldAdvanced: [2]int{5, 0},
+	KingOpenFile:       [2]int{-25, 0},
+	KingSemiOpenFile:   [2]int{-10, 0},
+
+	KingAttackWeights: [4]int{2, 2, 3, 5},
+	KingAttack: [2][16]int{
+		{0, 0, 5, 10, 20, 30, 45, 60, 80, 100, 125, 150, 180, 210, 250, 300},
+		{0, 0, 1, 2, 5, 7, 11, 15, 20, 25, 31, 37, 45, 52, 62, 75},
+	},
+
+	BishopPair:       [2]int{30, 50},
+	RookOpenFile:     [2]int{25, 10},
+	RookSemiOpenFile: [2]int{10, 5},
+	RookSeventh:      [2]int{20, 30},
+	KnightOutpost:    [2]int{20, 10},
+	TrappedBishop:    [2]int{-80, -80},
+	TrappedRook:      [2]int{-40, -10},
 }
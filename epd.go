@@ -0,0 +1,135 @@
+package chess
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidEPD = errors.New("Invalid EPD")
+
+// A position from an Extended Position Description file, with its operations
+//
+//	r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5; id "Ruy Lopez";
+type EPD struct {
+	Board Board
+	Ops   map[string][]string // operands keyed by opcode, with any quotes removed
+}
+
+// Returns the operation's first operand, or an empty string if it isn't there
+func (e *EPD) Op(opcode string) string {
+	if len(e.Ops[opcode]) == 0 {
+		return ""
+	}
+	return e.Ops[opcode][0]
+}
+
+// Splits s into whitespace separated tokens, keeping quoted strings together and ; as a token of its own
+func epdTokens(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == ';':
+			tokens = append(tokens, ";")
+			i++
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end == -1 {
+				return nil, fmt.Errorf("%w: unterminated string", ErrInvalidEPD)
+			}
+			tokens = append(tokens, s[i+1:i+1+end])
+			i += end + 2
+		default:
+			end := strings.IndexAny(s[i:], " \t;")
+			if end == -1 {
+				end = len(s) - i
+			}
+			tokens = append(tokens, s[i:i+end])
+			i += end
+		}
+	}
+	return tokens, nil
+}
+
+// Parses a single line of EPD
+// The halfmove clock and fullmove number can be given as hmvc and fmvn operations, or straight after the position as
+// in a FEN
+func ParseEPD(line string) (EPD, error) {
+	tokens, err := epdTokens(line)
+	if err != nil {
+		return EPD{}, err
+	}
+	if len(tokens) < 4 {
+		return EPD{}, fmt.Errorf("%w: %q", ErrInvalidEPD, line)
+	}
+
+	fen := strings.Join(tokens[:4], " ")
+	tokens = tokens[4:]
+	clocks := []string{"0", "1"}
+	if len(tokens) >= 2 {
+		_, err1 := strconv.Atoi(tokens[0])
+		_, err2 := strconv.Atoi(tokens[1])
+		if err1 == nil && err2 == nil {
+			clocks, tokens = tokens[:2], tokens[2:]
+		}
+	}
+
+	e := EPD{Ops: map[string][]string{}}
+	var op []string
+	for _, t := range append(tokens, ";") {
+		if t != ";" {
+			op = append(op, t)
+			continue
+		}
+		if len(op) > 0 {
+			e.Ops[op[0]] = op[1:]
+		}
+		op = nil
+	}
+
+	if hmvc := e.Op("hmvc"); hmvc != "" {
+		clocks[0] = hmvc
+	}
+	if fmvn := e.Op("fmvn"); fmvn != "" {
+		clocks[1] = fmvn
+	}
+
+	e.Board, err = BoardFromFEN(fen + " " + clocks[0] + " " + clocks[1])
+	if err != nil {
+		return EPD{}, fmt.Errorf("%w: %w", ErrInvalidEPD, err)
+	}
+	return e, nil
+}
+
+// Parses an EPD file, one position per line
+// Blank lines and lines starting with # are ignored
+func ReadEPD(r io.Reader) ([]EPD, error) {
+	var es []EPD
+
+	s := bufio.NewScanner(r)
+	line := 0
+	for s.Scan() {
+		line++
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		e, err := ParseEPD(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		es = append(es, e)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return es, nil
+}
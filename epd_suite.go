@@ -0,0 +1,112 @@
+package chess
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A position from a test suite such as WAC or STS, with the answers to check a search against
+type EPDTest struct {
+	ID     string
+	Board  Board
+	Best   []Move       // bm, any one of them is right
+	Avoid  []Move       // am, none of them may be played
+	Mate   int          // dm, the search must find a mate in at most this many moves
+	Points map[Move]int // STS style partial credit, from c0 "Qd2=10, Qe1=5", nil when the suite doesn't give any
+}
+
+// Reads the answers from the bm, am, dm and c0 operations, moves are given in SAN
+func NewEPDTest(e EPD) (EPDTest, error) {
+	t := EPDTest{ID: e.Op("id"), Board: e.Board}
+
+	parse := func(opcode string) ([]Move, error) {
+		var ms []Move
+		for _, san := range e.Ops[opcode] {
+			m, err := e.Board.ParseSAN(san)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s %s: %w", ErrInvalidEPD, opcode, san, err)
+			}
+			ms = append(ms, m)
+		}
+		return ms, nil
+	}
+
+	var err error
+	if t.Best, err = parse("bm"); err != nil {
+		return EPDTest{}, err
+	}
+	if t.Avoid, err = parse("am"); err != nil {
+		return EPDTest{}, err
+	}
+	if dm := e.Op("dm"); dm != "" {
+		if t.Mate, err = strconv.Atoi(dm); err != nil || t.Mate < 1 {
+			return EPDTest{}, fmt.Errorf("%w: dm %s", ErrInvalidEPD, dm)
+		}
+	}
+
+	// Anything in c0 which isn't a list of scored moves is just a comment
+	if c0 := strings.Join(e.Ops["c0"], " "); strings.Contains(c0, "=") {
+		points := map[Move]int{}
+		for _, entry := range strings.Split(c0, ",") {
+			san, value, _ := strings.Cut(strings.TrimSpace(entry), "=")
+			m, err1 := e.Board.ParseSAN(san)
+			n, err2 := strconv.Atoi(value)
+			if err1 != nil || err2 != nil {
+				points = nil
+				break
+			}
+			points[m] = n
+		}
+		t.Points = points
+	}
+
+	if len(t.Best) == 0 && len(t.Avoid) == 0 && t.Mate == 0 && t.Points == nil {
+		return EPDTest{}, fmt.Errorf("%w: no bm, am, dm or c0 to check", ErrInvalidEPD)
+	}
+	return t, nil
+}
+
+// Returns the most points the position is worth, 1 unless the suite gives partial credit
+func (t *EPDTest) MaxPoints() int {
+	if t.Points == nil {
+		return 1
+	}
+	most := 0
+	for _, n := range t.Points {
+		most = max(most, n)
+	}
+	return most
+}
+
+// Returns the points a search result earns, out of MaxPoints
+func (t *EPDTest) Score(r SearchResult) int {
+	if t.Points != nil {
+		return t.Points[r.Move]
+	}
+
+	if len(t.Best) > 0 && !slices.Contains(t.Best, r.Move) {
+		return 0
+	}
+	if slices.Contains(t.Avoid, r.Move) {
+		return 0
+	}
+	if t.Mate > 0 {
+		if moves, ok := MateIn(r.Score); !ok || moves < 1 || moves > t.Mate {
+			return 0
+		}
+	}
+	return 1
+}
+
+// Searches the position with e from scratch, clearing its tables first so earlier positions can't help
+func (t *EPDTest) Run(e *Engine, l SearchLimits) SearchResult {
+	e.TT.Clear()
+	e.PT.Clear()
+	e.B = t.Board.Copy()
+	if e.Evaluator != nil {
+		e.SetEvaluator(e.Evaluator) // attaches an incremental evaluator to the new board
+	}
+	return e.SearchWith(l)
+}
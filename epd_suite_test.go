@@ -0,0 +1,77 @@
+package chess_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func newEPDTest(t *testing.T, line string) chess.EPDTest {
+	e, err := chess.ParseEPD(line)
+	require.NoError(t, err)
+	et, err := chess.NewEPDTest(e)
+	require.NoError(t, err)
+	return et
+}
+
+func TestEPDTestScore(t *testing.T) {
+	et := newEPDTest(t, `6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - bm Ra8#; dm 1; id "mate";`)
+	assert.Equal(t, "mate", et.ID)
+	assert.Equal(t, 1, et.Mate)
+	assert.Equal(t, 1, et.MaxPoints())
+
+	ra8, _ := et.Board.ParseSAN("Ra8")
+	h3, _ := et.Board.ParseSAN("h3")
+	assert.Equal(t, 1, et.Score(chess.SearchResult{Move: ra8, Score: chess.MateScore(1)}))
+	assert.Equal(t, 0, et.Score(chess.SearchResult{Move: ra8, Score: 900}), "the mate wasn't seen")
+	assert.Equal(t, 0, et.Score(chess.SearchResult{Move: h3, Score: chess.MateScore(1)}))
+
+	et = newEPDTest(t, `4k3/8/2p5/3p4/8/8/8/3QK3 w - - am Qxd5;`)
+	qxd5, _ := et.Board.ParseSAN("Qxd5")
+	qd4, _ := et.Board.ParseSAN("Qd4")
+	assert.Equal(t, 0, et.Score(chess.SearchResult{Move: qxd5}))
+	assert.Equal(t, 1, et.Score(chess.SearchResult{Move: qd4}))
+
+	et = newEPDTest(t, `4k3/8/2p5/3p4/8/8/8/3QK3 w - - bm Qd4; c0 "Qd4=10, Qa4=4, Qxd5=0";`)
+	qa4, _ := et.Board.ParseSAN("Qa4")
+	assert.Equal(t, 10, et.MaxPoints())
+	assert.Equal(t, 10, et.Score(chess.SearchResult{Move: qd4}))
+	assert.Equal(t, 4, et.Score(chess.SearchResult{Move: qa4}))
+	assert.Equal(t, 0, et.Score(chess.SearchResult{Move: qxd5}))
+
+	et = newEPDTest(t, `4k3/8/2p5/3p4/8/8/8/3QK3 w - - bm Qd4; c0 "just a comment, a=b";`)
+	assert.Nil(t, et.Points)
+}
+
+func TestNewEPDTestErrors(t *testing.T) {
+	for _, line := range []string{
+		`4k3/8/8/8/8/8/8/3QK3 w - - id "nothing to check";`,
+		`4k3/8/8/8/8/8/8/3QK3 w - - bm Qd9;`,
+		`4k3/8/8/8/8/8/8/3QK3 w - - am Ke3 Kf8;`,
+		`4k3/8/8/8/8/8/8/3QK3 w - - bm Qd7; dm none;`,
+	} {
+		e, err := chess.ParseEPD(line)
+		require.NoError(t, err)
+		_, err = chess.NewEPDTest(e)
+		assert.ErrorIs(t, err, chess.ErrInvalidEPD, line)
+	}
+}
+
+func TestTacticsSuite(t *testing.T) {
+	f, err := os.Open("testing/tactics.epd")
+	require.NoError(t, err)
+	defer f.Close()
+	es, err := chess.ReadEPD(f)
+	require.NoError(t, err)
+
+	e := &chess.Engine{TT: *chess.NewTranspositionTable(16), PT: *chess.NewPawnTable(12), EP: chess.DefaultParams}
+	for _, ep := range es {
+		et, err := chess.NewEPDTest(ep)
+		require.NoError(t, err)
+		r := et.Run(e, chess.SearchLimits{Depth: 4})
+		assert.Equal(t, et.MaxPoints(), et.Score(r), "%s: played %s", et.ID, r.Move)
+	}
+}
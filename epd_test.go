@@ -0,0 +1,49 @@
+package chess_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestParseEPD(t *testing.T) {
+	e, err := chess.ParseEPD(`r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5 Bc4; id "Ruy; Lopez"; c0 "a" "b";`)
+	require.NoError(t, err)
+	assert.Equal(t, "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 0 1", e.Board.FEN())
+	assert.Equal(t, []string{"Bb5", "Bc4"}, e.Ops["bm"])
+	assert.Equal(t, "Ruy; Lopez", e.Op("id"))
+	assert.Equal(t, []string{"a", "b"}, e.Ops["c0"])
+	assert.Equal(t, "", e.Op("am"))
+
+	e, err = chess.ParseEPD("4k3/8/8/8/8/8/8/4K2R w K - hmvc 7; fmvn 40;")
+	require.NoError(t, err)
+	assert.Equal(t, "4k3/8/8/8/8/8/8/4K2R w K - 7 40", e.Board.FEN())
+
+	e, err = chess.ParseEPD("4k3/8/8/8/8/8/8/4K2R b K - 3 20")
+	require.NoError(t, err)
+	assert.Equal(t, "4k3/8/8/8/8/8/8/4K2R b K - 3 20", e.Board.FEN())
+	assert.Empty(t, e.Ops)
+
+	for _, line := range []string{
+		"4k3/8/8/8/8/8/8/4K2R w K",
+		"4k3/8/8/8/8/8/8/4K2R w K - id \"unterminated",
+		"4k3/8/8/8/8/8/8/4K2X w K - id \"bad piece\"",
+	} {
+		_, err = chess.ParseEPD(line)
+		assert.ErrorIs(t, err, chess.ErrInvalidEPD, line)
+	}
+}
+
+func TestReadEPD(t *testing.T) {
+	es, err := chess.ReadEPD(strings.NewReader("# openings\n\nrnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3\n4k3/8/8/8/8/8/8/4K2R w K - id \"rook\";\n"))
+	require.NoError(t, err)
+	require.Len(t, es, 2)
+	assert.Equal(t, "rook", es[1].Op("id"))
+
+	_, err = chess.ReadEPD(strings.NewReader("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3\nnonsense\n"))
+	assert.ErrorIs(t, err, chess.ErrInvalidEPD)
+	assert.ErrorContains(t, err, "line 2")
+}
@@ -2,6 +2,27 @@ package chess
 
 import "math/bits"
 
+// Material values, used by SEE
+const (
+	pawnValue   = 100
+	knightValue = 300
+	bishopValue = 300
+	rookValue   = 500
+	queenValue  = 900
+	kingValue   = 20000
+)
+
+// Contribution of each piece to the game phase
+const (
+	knightPhase = 1
+	bishopPhase = 1
+	rookPhase   = 2
+	queenPhase  = 4
+)
+
+// The phase of a full set of pieces, see Board.Phase
+const MaxPhase = 4*knightPhase + 4*bishopPhase + 4*rookPhase + 2*queenPhase
+
 func sumWhiteValues(bb uint64, values [64]int) int {
 	val := 0
 	for bb != 0 {
@@ -12,61 +33,110 @@ func sumWhiteValues(bb uint64, values [64]int) int {
 	return val
 }
 
+// Tables are from white's point of view, so are flipped vertically for black
 func sumBlackValues(bb uint64, values [64]int) int {
 	val := 0
 	for bb != 0 {
 		i := bits.TrailingZeros64(bb)
-		val += values[i]
+		val += values[i^7]
 		bb &= bb - 1
 	}
 	return val
 }
 
+// Returns the game phase, from MaxPhase (all pieces on the board) down to 0 (pawns and kings only)
+func (b *Board) Phase() int {
+	phase := knightPhase*bits.OnesCount64(b.whiteKnights|b.blackKnights) +
+		bishopPhase*bits.OnesCount64(b.whiteBishops|b.blackBishops) +
+		rookPhase*bits.OnesCount64(b.whiteRooks|b.blackRooks) +
+		queenPhase*bits.OnesCount64(b.whiteQueens|b.blackQueens)
+
+	return min(phase, MaxPhase) // early promotions can push it over
+}
+
+// Interpolates between the middlegame and endgame scores
+func taper(score [2]int, phase int) int {
+	return (score[MG]*phase + score[EG]*(MaxPhase-phase)) / MaxPhase
+}
+
+// Returns the score from the side to move's point of view
 func (e *Engine) Evaluate() int {
+	if e.Evaluator != nil {
+		return e.Evaluator.Evaluate(&e.B)
+	}
+	return evaluateClassic(&e.B, &e.EP, &e.PT, nil)
+}
+
+// The hand-written evaluation, fills in trace as it goes when it isn't nil
+// Endgames with a specialised evaluation replace or scale the score of the terms
+func evaluateClassic(b *Board, ep *EvalParams, pt *PawnTable, trace *EvalTrace) int {
+	eg, ok := evaluateEndgame(b)
+	if ok && eg.exact && trace == nil {
+		return eg.score
+	}
+
+	score := evaluateTerms(b, ep, pt, trace)
+	if ok {
+		score = eg.apply(score)
+		if trace != nil {
+			trace.Endgame = eg.name
+		}
+	}
+	return score
+}
+
+// Sums every term of the classic evaluation and tapers the total
+func evaluateTerms(b *Board, ep *EvalParams, pt *PawnTable, trace *EvalTrace) int {
 	var multiplier int
-	if e.B.Turn == WhiteTurn {
+	if b.Turn == WhiteTurn {
 		multiplier = 1
 	} else {
 		multiplier = -1
 	}
 
-	var (
-		pawnWt   = 100
-		knightWt = 300
-		bishopWt = 300
-		rookWt   = 500
-		queenWt  = 900
-		kingWt   = 20000
-
-		wP = bits.OnesCount64(e.B.whitePawns)
-		bP = bits.OnesCount64(e.B.blackPawns)
-		wR = bits.OnesCount64(e.B.whiteRooks)
-		bR = bits.OnesCount64(e.B.blackRooks)
-		wN = bits.OnesCount64(e.B.whiteKnights)
-		bN = bits.OnesCount64(e.B.blackKnights)
-		wB = bits.OnesCount64(e.B.whiteBishops)
-		bB = bits.OnesCount64(e.B.blackBishops)
-		wQ = bits.OnesCount64(e.B.whiteQueens)
-		bQ = bits.OnesCount64(e.B.blackQueens)
-		wK = bits.OnesCount64(e.B.whiteKings)
-		bK = bits.OnesCount64(e.B.blackKings)
-	)
-
-	materialScore := pawnWt*(wP-bP) +
-		knightWt*(wN-bN) +
-		bishopWt*(wB-bB) +
-		rookWt*(wR-bR) +
-		queenWt*(wQ-bQ) +
-		kingWt*(wK-bK)
-
-	positionalScore := sumWhiteValues(e.B.whitePawns, e.EP.PawnVals) - sumBlackValues(e.B.blackPawns, e.EP.PawnVals) +
-		sumWhiteValues(e.B.whiteKnights, e.EP.KnightVals) - sumBlackValues(e.B.blackKnights, e.EP.KnightVals) +
-		sumWhiteValues(e.B.whiteRooks, e.EP.RookVals) - sumBlackValues(e.B.blackKnights, e.EP.RookVals) +
-		sumWhiteValues(e.B.whiteKings, e.EP.KingVals) - sumBlackValues(e.B.blackKings, e.EP.KingVals) +
-		sumWhiteValues(e.B.whiteBishops, e.EP.BishopVals) - sumBlackValues(e.B.blackBishops, e.EP.BishopVals) +
-		sumWhiteValues(e.B.whiteQueens, e.EP.QueenVals) - sumBlackValues(e.B.blackQueens, e.EP.QueenVals)
-
-	score := materialScore + positionalScore
-
-	return score * multiplier
+	white := sideScore{white: true, trace: trace}
+	black := sideScore{white: false, trace: trace}
+
+	addPieces := func(material, pst evalTerm, whiteBB, blackBB uint64, wt [2]int, vals [2][64]int) {
+		white.add(material, wt, bits.OnesCount64(whiteBB))
+		black.add(material, wt, bits.OnesCount64(blackBB))
+		white.add(pst, [2]int{sumWhiteValues(whiteBB, vals[MG]), sumWhiteValues(whiteBB, vals[EG])}, 1)
+		black.add(pst, [2]int{sumBlackValues(blackBB, vals[MG]), sumBlackValues(blackBB, vals[EG])}, 1)
+	}
+
+	addPieces(pawnMaterialTerm, pawnPSTTerm, b.whitePawns, b.blackPawns, ep.PawnWt, ep.PawnVals)
+	addPieces(knightMaterialTerm, knightPSTTerm, b.whiteKnights, b.blackKnights, ep.KnightWt, ep.KnightVals)
+	addPieces(bishopMaterialTerm, bishopPSTTerm, b.whiteBishops, b.blackBishops, ep.BishopWt, ep.BishopVals)
+	addPieces(rookMaterialTerm, rookPSTTerm, b.whiteRooks, b.blackRooks, ep.RookWt, ep.RookVals)
+	addPieces(queenMaterialTerm, queenPSTTerm, b.whiteQueens, b.blackQueens, ep.QueenWt, ep.QueenVals)
+
+	// Kings are always on the board, so they have no material term
+	white.add(kingPSTTerm, [2]int{sumWhiteValues(b.whiteKings, ep.KingVals[MG]), sumWhiteValues(b.whiteKings, ep.KingVals[EG])}, 1)
+	black.add(kingPSTTerm, [2]int{sumBlackValues(b.blackKings, ep.KingVals[MG]), sumBlackValues(b.blackKings, ep.KingVals[EG])}, 1)
+
+	// The pawn table only stores the total, so tracing has to recalculate it
+	var pawns PawnEntry
+	if trace != nil {
+		pawns = evaluatePawns(b, ep, trace)
+	} else {
+		pawns = pawnStructure(b, ep, pt)
+	}
+
+	score := [2]int{white.score[MG] - black.score[MG], white.score[EG] - black.score[EG]}
+	add := func(w, b [2]int) {
+		score[MG] += w[MG] - b[MG]
+		score[EG] += w[EG] - b[EG]
+	}
+
+	add(pawns.Score, [2]int{})
+	add(evaluatePassedPawns(b, ep, pawns.WhitePassed, true, trace), evaluatePassedPawns(b, ep, pawns.BlackPassed, false, trace))
+	add(evaluatePieces(b, ep, true, trace), evaluatePieces(b, ep, false, trace))
+
+	phase := b.Phase()
+	if trace != nil {
+		trace.Phase = phase
+		trace.Total = score
+	}
+
+	return taper(score, phase) * multiplier
 }
@@ -0,0 +1,178 @@
+package chess
+
+import "math/bits"
+
+// Pawn structure masks, indexed by file or square
+var (
+	fileMasks         [8]uint64
+	adjacentFileMasks [8]uint64
+	whitePassedMasks  [64]uint64 // squares in front of a white pawn, on its own and adjacent files
+	blackPassedMasks  [64]uint64
+	whiteSupportMasks [64]uint64 // squares on adjacent files, level with or behind a white pawn
+	blackSupportMasks [64]uint64
+	rankMasks         [8]uint64
+	squareDistances   [64][64]int // number of king moves between two squares
+)
+
+func init() {
+	for f := range 8 {
+		fileMasks[f] = 0x0101010101010101 << f
+		rankMasks[f] = 0xff << (8 * f)
+	}
+	for f := range 8 {
+		if f > 0 {
+			adjacentFileMasks[f] |= fileMasks[f-1]
+		}
+		if f < 7 {
+			adjacentFileMasks[f] |= fileMasks[f+1]
+		}
+	}
+
+	for i := range 64 {
+		rank, file := i/8, i%8
+
+		for r := range 8 {
+			switch {
+			case r > rank:
+				whitePassedMasks[i] |= rankMasks[r] & (fileMasks[file] | adjacentFileMasks[file])
+				blackSupportMasks[i] |= rankMasks[r] & adjacentFileMasks[file]
+			case r < rank:
+				blackPassedMasks[i] |= rankMasks[r] & (fileMasks[file] | adjacentFileMasks[file])
+				whiteSupportMasks[i] |= rankMasks[r] & adjacentFileMasks[file]
+			default:
+				whiteSupportMasks[i] |= rankMasks[r] & adjacentFileMasks[file]
+				blackSupportMasks[i] |= rankMasks[r] & adjacentFileMasks[file]
+			}
+		}
+
+		for j := range 64 {
+			squareDistances[i][j] = max(abs(rank-j/8), abs(file-j%8))
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Number of ranks a pawn on square i has advanced from its side's back rank
+func relativeRank(i int, white bool) int {
+	if white {
+		return i / 8
+	}
+	return 7 - i/8
+}
+
+// The square directly in front of a pawn on square i
+func stopSquare(i int, white bool) int {
+	if white {
+		return i + 8
+	}
+	return i - 8
+}
+
+// Scores the pawns of one side, ignoring everything but the pawns so the result can be cached
+func evaluatePawnSide(ep *EvalParams, pawns, enemyPawns, enemyAttacks uint64, white bool, trace *EvalTrace) ([2]int, uint64) {
+	passedMasks, supportMasks, defenders := &whitePassedMasks, &whiteSupportMasks, &blackPawnAttacks
+	if !white {
+		passedMasks, supportMasks, defenders = &blackPassedMasks, &blackSupportMasks, &whitePawnAttacks
+	}
+
+	s := sideScore{white: white, trace: trace}
+	var passed uint64
+
+	for f := range 8 {
+		if n := bits.OnesCount64(pawns & fileMasks[f]); n > 1 {
+			s.add(doubledPawnTerm, ep.DoubledPawn, n-1)
+		}
+	}
+
+	for bb := pawns; bb != 0; bb &= bb - 1 {
+		i := bits.TrailingZeros64(bb)
+		rank, file := relativeRank(i, white), i%8
+
+		switch {
+		case pawns&adjacentFileMasks[file] == 0:
+			s.add(isolatedPawnTerm, ep.IsolatedPawn, 1)
+		case pawns&supportMasks[i] == 0 && enemyAttacks&(1<<stopSquare(i, white)) != 0:
+			// Its neighbours have all advanced past it, and it can't advance to join them without being taken
+			s.add(backwardPawnTerm, ep.BackwardPawn, 1)
+		}
+
+		// Defended by another pawn, or side by side with one
+		if pawns&defenders[i] != 0 || pawns&adjacentFileMasks[file]&rankMasks[i/8] != 0 {
+			s.add(connectedPawnTerm, ep.ConnectedPawn, 1)
+		}
+
+		// Only the frontmost of doubled pawns counts as passed
+		if (enemyPawns|pawns&fileMasks[file])&passedMasks[i] == 0 {
+			passed |= 1 << i
+			s.add(passedPawnTerm, [2]int{ep.PassedPawn[MG][rank], ep.PassedPawn[EG][rank]}, 1)
+		}
+	}
+
+	return s.score, passed
+}
+
+// Scores everything which only depends on the pawns, from white's point of view
+func evaluatePawns(b *Board, ep *EvalParams, trace *EvalTrace) PawnEntry {
+	var whiteAttacks, blackAttacks uint64
+	for bb := b.whitePawns; bb != 0; bb &= bb - 1 {
+		whiteAttacks |= whitePawnAttacks[bits.TrailingZeros64(bb)]
+	}
+	for bb := b.blackPawns; bb != 0; bb &= bb - 1 {
+		blackAttacks |= blackPawnAttacks[bits.TrailingZeros64(bb)]
+	}
+
+	white, whitePassed := evaluatePawnSide(ep, b.whitePawns, b.blackPawns, blackAttacks, true, trace)
+	black, blackPassed := evaluatePawnSide(ep, b.blackPawns, b.whitePawns, whiteAttacks, false, trace)
+
+	return PawnEntry{
+		Key:         b.PawnZobrist(),
+		Score:       [2]int{white[MG] - black[MG], white[EG] - black[EG]},
+		WhitePassed: whitePassed,
+		BlackPassed: blackPassed,
+	}
+}
+
+// Passed pawn terms which depend on the other pieces, so can't be cached in the PawnTable
+func evaluatePassedPawns(b *Board, ep *EvalParams, passed uint64, white bool, trace *EvalTrace) [2]int {
+	s := sideScore{white: white, trace: trace}
+
+	ownKing, enemyKing := bits.TrailingZeros64(b.whiteKings), bits.TrailingZeros64(b.blackKings)
+	passedMasks := &whitePassedMasks
+	if !white {
+		ownKing, enemyKing = enemyKing, ownKing
+		passedMasks = &blackPassedMasks
+	}
+	occupied := b.occupied()
+
+	for bb := passed; bb != 0; bb &= bb - 1 {
+		i := bits.TrailingZeros64(bb)
+		stop := stopSquare(i, white)
+
+		if occupied&passedMasks[i]&fileMasks[i%8] == 0 {
+			s.add(passedFreePathTerm, ep.PassedFreePath, 1)
+		}
+
+		s.add(passedOwnKingDistanceTerm, ep.PassedOwnKingDistance, squareDistances[ownKing][stop])
+		s.add(passedEnemyKingDistanceTerm, ep.PassedEnemyKingDistance, squareDistances[enemyKing][stop])
+	}
+
+	return s.score
+}
+
+// Looks the pawn structure up in the PawnTable, evaluating and saving it on a miss
+func pawnStructure(b *Board, ep *EvalParams, pt *PawnTable) PawnEntry {
+	key := b.PawnZobrist()
+	if p, ok := pt.Get(key); ok {
+		return p
+	}
+
+	p := evaluatePawns(b, ep, nil)
+	pt.Save(p)
+	return p
+}
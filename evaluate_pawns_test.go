@@ -0,0 +1,61 @@
+package chess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluatePawns(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		params EvalParams
+		want   int // middlegame score from white's point of view
+	}{
+		{"doubled", "4k3/8/8/8/4P3/4P3/4P3/4K3 w - - 0 1", EvalParams{DoubledPawn: [2]int{-1}}, -2},
+		{"doubled black", "4k3/pp6/p7/8/8/8/8/4K3 w - - 0 1", EvalParams{DoubledPawn: [2]int{-1}}, 1},
+		{"isolated", "4k3/8/8/8/8/8/P1P4P/4K3 w - - 0 1", EvalParams{IsolatedPawn: [2]int{-1}}, -3},
+		{"not isolated", "4k3/8/8/8/8/8/PPP5/4K3 w - - 0 1", EvalParams{IsolatedPawn: [2]int{-1}}, 0},
+		// d2 has no neighbours level or behind, and d3 is covered by the pawn on e4
+		{"backward", "4k3/8/8/8/2P1p3/8/3P4/4K3 w - - 0 1", EvalParams{BackwardPawn: [2]int{-1}}, -1},
+		{"backward but safe", "4k3/8/8/8/2P5/8/3P4/4K3 w - - 0 1", EvalParams{BackwardPawn: [2]int{-1}}, 0},
+		{"connected", "4k3/8/8/8/8/2P5/1P1P4/4K3 w - - 0 1", EvalParams{ConnectedPawn: [2]int{1}}, 1},
+		{"phalanx", "4k3/8/8/8/8/8/1PP5/4K3 w - - 0 1", EvalParams{ConnectedPawn: [2]int{1}}, 2},
+		{"no wrapping", "4k3/8/8/8/8/8/P6P/4K3 w - - 0 1", EvalParams{ConnectedPawn: [2]int{1}}, 0},
+		{"passed", "4k3/8/1P6/8/8/8/8/4K3 w - - 0 1", EvalParams{PassedPawn: [2][8]int{{0, 0, 0, 0, 0, 5}}}, 5},
+		{"passed black", "4k3/8/8/8/8/1p6/8/4K3 w - - 0 1", EvalParams{PassedPawn: [2][8]int{{0, 0, 0, 0, 0, 5}}}, -5},
+		{"blocked by adjacent file", "4k3/p7/1P6/8/8/8/8/4K3 w - - 0 1", EvalParams{PassedPawn: [2][8]int{{1, 1, 1, 1, 1, 1, 1, 1}}}, 0},
+		{"doubled passed", "4k3/8/8/8/8/P7/P7/4K3 w - - 0 1", EvalParams{PassedPawn: [2][8]int{{1, 1, 1, 1, 1, 1, 1, 1}}}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := BoardFromFEN(tt.fen)
+			require.NoError(t, err)
+			p := evaluatePawns(&b, &tt.params, nil)
+			assert.Equal(t, tt.want, p.Score[MG])
+		})
+	}
+}
+
+func TestEvaluatePassedPawns(t *testing.T) {
+	ep := EvalParams{
+		PassedFreePath:          [2]int{0, 100},
+		PassedOwnKingDistance:   [2]int{0, -1},
+		PassedEnemyKingDistance: [2]int{0, 10},
+	}
+
+	// Stop square is b7, white king is 1 away and black king is 6 away
+	b, err := BoardFromFEN("7k/8/1PK5/8/8/8/8/8 w - - 0 1")
+	require.NoError(t, err)
+	p := evaluatePawns(&b, &ep, nil)
+	assert.Equal(t, 100-1+60, evaluatePassedPawns(&b, &ep, p.WhitePassed, true, nil)[EG])
+
+	// Same again, but with the path blocked
+	b, err = BoardFromFEN("1n5k/8/1PK5/8/8/8/8/8 w - - 0 1")
+	require.NoError(t, err)
+	p = evaluatePawns(&b, &ep, nil)
+	assert.Equal(t, -1+60, evaluatePassedPawns(&b, &ep, p.WhitePassed, true, nil)[EG])
+}
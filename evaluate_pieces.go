@@ -0,0 +1,203 @@
+package chess
+
+import "math/bits"
+
+// Indexes of EvalParams.KingAttackWeights
+const (
+	knightAttacker = iota
+	bishopAttacker
+	rookAttacker
+	queenAttacker
+)
+
+// Squares (from white's point of view) used to spot trapped pieces, flipped vertically for black
+const (
+	a7 = 55
+	h7 = 48
+	b6 = 46
+	g6 = 41
+	a6 = 47
+	h6 = 40
+	b5 = 38
+	g5 = 33
+)
+
+// The pieces of one colour
+type sidePieces struct {
+	pawns, knights, bishops, rooks, queens, king uint64
+	all                                          uint64
+}
+
+func (b *Board) side(white bool) sidePieces {
+	if white {
+		return sidePieces{b.whitePawns, b.whiteKnights, b.whiteBishops, b.whiteRooks, b.whiteQueens, b.whiteKings, b.whitePieces()}
+	}
+	return sidePieces{b.blackPawns, b.blackKnights, b.blackBishops, b.blackRooks, b.blackQueens, b.blackKings, b.blackPieces()}
+}
+
+// Squares attacked by any pawn in pawns
+func pawnAttacks(pawns uint64, white bool) uint64 {
+	table := &whitePawnAttacks
+	if !white {
+		table = &blackPawnAttacks
+	}
+
+	var attacks uint64
+	for bb := pawns; bb != 0; bb &= bb - 1 {
+		attacks |= table[bits.TrailingZeros64(bb)]
+	}
+	return attacks
+}
+
+// Flips a white square to the equivalent black one, so the trapped piece patterns only need writing once
+func relativeSquare(i int, white bool) int {
+	if white {
+		return i
+	}
+	return i ^ 56
+}
+
+// Scores mobility, king safety and the piece specific terms for one side
+func evaluatePieces(b *Board, ep *EvalParams, white bool, trace *EvalTrace) [2]int {
+	s := sideScore{white: white, trace: trace}
+
+	own, enemy := b.side(white), b.side(!white)
+	occupied := own.all | enemy.all
+	passedMasks := &whitePassedMasks
+	if !white {
+		passedMasks = &blackPassedMasks
+	}
+
+	// Squares defended by an enemy pawn, or blocked by our own pieces, don't count towards mobility
+	mobilityArea := ^(own.all | pawnAttacks(enemy.pawns, !white))
+	ownPawnAttacks := pawnAttacks(own.pawns, white)
+
+	enemyKing := bits.TrailingZeros64(enemy.king)
+	kingZone := kingAttacks[enemyKing] | enemy.king
+	attackers, attackUnits := 0, 0
+
+	countAttacks := func(attacks uint64, attacker int) {
+		if attacks&kingZone != 0 {
+			attackers++
+			attackUnits += ep.KingAttackWeights[attacker]
+		}
+	}
+
+	for bb := own.knights; bb != 0; bb &= bb - 1 {
+		i := bits.TrailingZeros64(bb)
+		attacks := knightAttacks[i]
+		s.add(knightMobilityTerm, ep.KnightMobility, bits.OnesCount64(attacks&mobilityArea))
+		countAttacks(attacks, knightAttacker)
+
+		// Supported by a pawn, and no enemy pawn can ever chase it away
+		rank := relativeRank(i, white)
+		if rank >= 3 && rank <= 5 && ownPawnAttacks&(1<<i) != 0 &&
+			enemy.pawns&passedMasks[i]&adjacentFileMasks[i%8] == 0 {
+			s.add(knightOutpostTerm, ep.KnightOutpost, 1)
+		}
+	}
+
+	for bb := own.bishops; bb != 0; bb &= bb - 1 {
+		i := bits.TrailingZeros64(bb)
+		attacks := bishopAttacks(i, occupied)
+		s.add(bishopMobilityTerm, ep.BishopMobility, bits.OnesCount64(attacks&mobilityArea))
+		countAttacks(attacks, bishopAttacker)
+
+		// Bishops which have taken a rook pawn and are shut in by the neighbouring pawn
+		enemyPawnOn := func(sq int) bool { return enemy.pawns&(1<<relativeSquare(sq, white)) != 0 }
+		switch relativeSquare(i, white) {
+		case a7:
+			if enemyPawnOn(b6) {
+				s.add(trappedBishopTerm, ep.TrappedBishop, 1)
+			}
+		case h7:
+			if enemyPawnOn(g6) {
+				s.add(trappedBishopTerm, ep.TrappedBishop, 1)
+			}
+		case a6:
+			if enemyPawnOn(b5) {
+				s.add(trappedBishopTerm, ep.TrappedBishop, 1)
+			}
+		case h6:
+			if enemyPawnOn(g5) {
+				s.add(trappedBishopTerm, ep.TrappedBishop, 1)
+			}
+		}
+	}
+
+	if bits.OnesCount64(own.bishops) >= 2 {
+		s.add(bishopPairTerm, ep.BishopPair, 1)
+	}
+
+	ownKing := bits.TrailingZeros64(own.king)
+	for bb := own.rooks; bb != 0; bb &= bb - 1 {
+		i := bits.TrailingZeros64(bb)
+		attacks := rookAttacks(i, occupied)
+		s.add(rookMobilityTerm, ep.RookMobility, bits.OnesCount64(attacks&mobilityArea))
+		countAttacks(attacks, rookAttacker)
+
+		file := fileMasks[i%8]
+		switch {
+		case (own.pawns|enemy.pawns)&file == 0:
+			s.add(rookOpenFileTerm, ep.RookOpenFile, 1)
+		case own.pawns&file == 0:
+			s.add(rookSemiOpenFileTerm, ep.RookSemiOpenFile, 1)
+		}
+
+		// Only worth anything if it's cutting off the king or attacking pawns
+		if relativeRank(i, white) == 6 && (relativeRank(enemyKing, white) == 7 || enemy.pawns&rankMasks[i/8] != 0) {
+			s.add(rookSeventhTerm, ep.RookSeventh, 1)
+		}
+
+		// Rooks boxed into the corner by a king which has moved without castling
+		if relativeRank(ownKing, white) == 0 && relativeRank(i, white) <= 1 {
+			kingFile, rookFile := ownKing%8, i%8
+			if (kingFile == 1 || kingFile == 2) && rookFile < kingFile ||
+				(kingFile == 5 || kingFile == 6) && rookFile > kingFile {
+				s.add(trappedRookTerm, ep.TrappedRook, 1)
+			}
+		}
+	}
+
+	for bb := own.queens; bb != 0; bb &= bb - 1 {
+		i := bits.TrailingZeros64(bb)
+		attacks := rookAttacks(i, occupied) | bishopAttacks(i, occupied)
+		s.add(queenMobilityTerm, ep.QueenMobility, bits.OnesCount64(attacks&mobilityArea))
+		countAttacks(attacks, queenAttacker)
+	}
+
+	// A lone attacker can't do much on its own
+	if attackers >= 2 {
+		attackUnits = max(0, min(attackUnits, len(ep.KingAttack[MG])-1))
+		s.add(kingAttackTerm, [2]int{ep.KingAttack[MG][attackUnits], ep.KingAttack[EG][attackUnits]}, 1)
+	}
+
+	// Pawn shield and open files around our own king
+	rank := relativeRank(ownKing, white)
+	for f := max(ownKing%8-1, 0); f <= min(ownKing%8+1, 7); f++ {
+		file := fileMasks[f]
+		switch {
+		case (own.pawns|enemy.pawns)&file == 0:
+			s.add(kingOpenFileTerm, ep.KingOpenFile, 1)
+		case own.pawns&file == 0:
+			s.add(kingSemiOpenFileTerm, ep.KingSemiOpenFile, 1)
+		}
+
+		if rank <= 6 && own.pawns&file&rankMasks[(rank+1)^rankFlip(white)] != 0 {
+			s.add(pawnShieldTerm, ep.PawnShield, 1)
+		}
+		if rank <= 5 && own.pawns&file&rankMasks[(rank+2)^rankFlip(white)] != 0 {
+			s.add(pawnShieldAdvancedTerm, ep.PawnShieldAdvanced, 1)
+		}
+	}
+
+	return s.score
+}
+
+// XORing a relative rank with this gives the absolute rank
+func rankFlip(white bool) int {
+	if white {
+		return 0
+	}
+	return 7
+}
@@ -0,0 +1,76 @@
+package chess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluatePieces(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		params EvalParams
+		want   int // white's middlegame score for its own pieces
+	}{
+		{"knight mobility", "4k3/8/8/8/8/8/8/N3K3 w - - 0 1", EvalParams{KnightMobility: [2]int{1}}, 2},
+		{"mobility excludes pawn defended squares", "4k3/8/8/8/p7/8/8/N3K3 w - - 0 1", EvalParams{KnightMobility: [2]int{1}}, 1},
+		{"mobility excludes own pieces", "4k3/8/8/8/8/1P6/8/N3K3 w - - 0 1", EvalParams{KnightMobility: [2]int{1}}, 1},
+		{"bishop mobility", "4k3/8/8/8/8/8/1P6/B3K3 w - - 0 1", EvalParams{BishopMobility: [2]int{1}}, 0},
+		{"rook mobility", "4k3/8/8/8/8/8/P7/R3K3 w - - 0 1", EvalParams{RookMobility: [2]int{1}}, 3},
+		{"queen mobility", "4k3/8/8/8/8/8/PP6/Q3K3 w - - 0 1", EvalParams{QueenMobility: [2]int{1}}, 3},
+		{"bishop pair", "4k3/8/8/8/8/8/8/2B1KB2 w - - 0 1", EvalParams{BishopPair: [2]int{1}}, 1},
+		{"no bishop pair", "4k3/8/8/8/8/8/8/2B1K3 w - - 0 1", EvalParams{BishopPair: [2]int{1}}, 0},
+		{"rook open file", "4k3/p7/8/8/8/8/8/3RK3 w - - 0 1", EvalParams{RookOpenFile: [2]int{1}}, 1},
+		{"rook semi-open file", "4k3/3p4/8/8/8/8/8/3RK3 w - - 0 1", EvalParams{RookSemiOpenFile: [2]int{1}, RookOpenFile: [2]int{10}}, 1},
+		{"rook closed file", "4k3/8/8/8/8/8/3P4/3RK3 w - - 0 1", EvalParams{RookSemiOpenFile: [2]int{1}, RookOpenFile: [2]int{1}}, 0},
+		{"rook seventh", "4k3/R7/8/8/8/8/8/4K3 w - - 0 1", EvalParams{RookSeventh: [2]int{1}}, 1},
+		{"rook seventh, nothing to attack", "8/R7/4k3/8/8/8/8/4K3 w - - 0 1", EvalParams{RookSeventh: [2]int{1}}, 0},
+		{"knight outpost", "4k3/8/8/4N3/3P4/8/8/4K3 w - - 0 1", EvalParams{KnightOutpost: [2]int{1}}, 1},
+		{"knight can be chased", "4k3/5p2/8/4N3/3P4/8/8/4K3 w - - 0 1", EvalParams{KnightOutpost: [2]int{1}}, 0},
+		{"trapped bishop", "4k3/B7/1p6/8/8/8/8/4K3 w - - 0 1", EvalParams{TrappedBishop: [2]int{1}}, 1},
+		{"trapped rook", "4k3/8/8/8/8/8/8/5K1R w - - 0 1", EvalParams{TrappedRook: [2]int{1}}, 1},
+		{"castled rook", "4k3/8/8/8/8/8/8/5RK1 w - - 0 1", EvalParams{TrappedRook: [2]int{1}}, 0},
+		{"pawn shield", "4k3/8/8/8/8/7P/5PP1/6K1 w - - 0 1", EvalParams{PawnShield: [2]int{10}, PawnShieldAdvanced: [2]int{1}}, 21},
+		{"king open files", "4k3/8/8/8/8/8/5P2/6K1 w - - 0 1", EvalParams{KingOpenFile: [2]int{1}}, 2},
+		{"king semi-open file", "4k3/6p1/8/8/8/8/5P1P/6K1 w - - 0 1", EvalParams{KingSemiOpenFile: [2]int{1}}, 1},
+		{
+			"king attack",
+			"6k1/8/6N1/8/8/8/8/4K2R w - - 0 1",
+			EvalParams{KingAttackWeights: [4]int{2, 0, 3, 0}, KingAttack: [2][16]int{{0, 0, 0, 0, 0, 7}}},
+			7,
+		},
+		{
+			"lone attacker",
+			"6k1/8/8/8/8/8/8/4K2R w - - 0 1",
+			EvalParams{KingAttackWeights: [4]int{2, 2, 3, 5}, KingAttack: [2][16]int{{1, 1, 1, 1, 1, 1}}},
+			0,
+		},
+		{
+			"negative attack weights",
+			"r5k1/ppp2pp1/6N1/8/8/8/PPP2PP1/4K2R w - - 0 1",
+			EvalParams{KingAttackWeights: [4]int{-1, -1, -1, -1}, KingAttack: [2][16]int{{5, 1}}},
+			5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := BoardFromFEN(tt.fen)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, evaluatePieces(&b, &tt.params, true, nil)[MG])
+		})
+	}
+}
+
+// Black's terms should mirror white's
+func TestEvaluatePiecesBlack(t *testing.T) {
+	b, err := BoardFromFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	require.NoError(t, err)
+	m, err := BoardFromFEN("r3k2r/pppbbppp/2n2q1P/1P2p3/3pn3/BN2PNP1/P1PPQPB1/R3K2R b KQkq - 0 1")
+	require.NoError(t, err)
+
+	assert.Equal(t, evaluatePieces(&b, &DefaultParams, true, nil), evaluatePieces(&m, &DefaultParams, false, nil))
+	assert.Equal(t, evaluatePieces(&b, &DefaultParams, false, nil), evaluatePieces(&m, &DefaultParams, true, nil))
+}
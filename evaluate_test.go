@@ -0,0 +1,171 @@
+package chess_test
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+// Flips the board vertically and swaps the colours of every piece, the side to move and castle rights
+func mirrorFEN(fen string) string {
+	parts := strings.Fields(fen)
+
+	swapCase := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsUpper(r) {
+				return unicode.ToLower(r)
+			}
+			return unicode.ToUpper(r)
+		}, s)
+	}
+
+	ranks := strings.Split(parts[0], "/")
+	for i, j := 0, len(ranks)-1; i < j; i, j = i+1, j-1 {
+		ranks[i], ranks[j] = ranks[j], ranks[i]
+	}
+	parts[0] = swapCase(strings.Join(ranks, "/"))
+
+	if parts[1] == "w" {
+		parts[1] = "b"
+	} else {
+		parts[1] = "w"
+	}
+
+	if parts[2] != "-" {
+		parts[2] = swapCase(parts[2])
+	}
+
+	if parts[3] != "-" {
+		rank := map[byte]byte{'3': '6', '6': '3'}[parts[3][1]]
+		parts[3] = string([]byte{parts[3][0], rank})
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func evaluateFEN(t *testing.T, fen string) int {
+	t.Helper()
+	b, err := chess.BoardFromFEN(fen)
+	require.NoError(t, err)
+	e := chess.Engine{B: b, EP: chess.DefaultParams}
+	return e.Evaluate()
+}
+
+var evalFENs = []string{
+	"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+	"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq -",
+	"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+	"1r4k1/5ppp/8/8/8/8/5PPP/2R3K1 b - - 0 1",
+	"8/p4k2/1p1p4/2pP2p1/P1P3P1/1P6/5K2/8 w - - 0 40",
+	"6k1/5p2/4p3/1P6/8/8/p4PPP/6K1 b - - 0 1",
+}
+
+// A position and its colour-flipped mirror should evaluate the same for the side to move
+func TestEvaluateSymmetry(t *testing.T) {
+	for _, fen := range evalFENs {
+		t.Run(fen, func(t *testing.T) {
+			assert.Equal(t, evaluateFEN(t, fen), evaluateFEN(t, mirrorFEN(fen)))
+		})
+	}
+}
+
+func TestPhase(t *testing.T) {
+	b := chess.NewBoard()
+	assert.Equal(t, chess.MaxPhase, b.Phase())
+
+	b, err := chess.BoardFromFEN("4k3/pppppppp/8/8/8/8/PPPPPPPP/4K3 w - - 0 1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, b.Phase())
+
+	b, err = chess.BoardFromFEN("r3k3/8/8/8/8/8/8/3QK3 w - - 0 1")
+	require.NoError(t, err)
+	assert.Equal(t, 6, b.Phase())
+}
+
+func TestEndgameKingCentralisation(t *testing.T) {
+	corner := evaluateFEN(t, "4k3/p7/8/8/8/8/4P3/K7 w - - 0 1")
+	centre := evaluateFEN(t, "4k3/p7/8/8/3K4/8/4P3/8 w - - 0 1")
+	assert.Greater(t, centre, corner, "king should be centralised in a pawn ending")
+
+	// But should stay tucked away with the queens on
+	corner = evaluateFEN(t, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQ1BKR w kq - 0 1")
+	centre = evaluateFEN(t, "rnbqkbnr/pppppppp/8/8/4K3/8/PPPPPPPP/RNBQ1B1R w kq - 0 1")
+	assert.Greater(t, corner, centre, "king should stay safe in the middlegame")
+}
+
+func TestEvaluateTrace(t *testing.T) {
+	for _, fen := range evalFENs {
+		t.Run(fen, func(t *testing.T) {
+			b, err := chess.BoardFromFEN(fen)
+			require.NoError(t, err)
+			e := chess.Engine{B: b, EP: chess.DefaultParams}
+			trace := e.EvaluateTrace()
+
+			assert.Equal(t, e.Evaluate(), trace.Score)
+			assert.Equal(t, b.Phase(), trace.Phase)
+
+			var total [2]int
+			for _, term := range trace.Terms {
+				total[chess.MG] += term.Total()[chess.MG]
+				total[chess.EG] += term.Total()[chess.EG]
+			}
+			assert.Equal(t, trace.Total, total)
+
+			// Every term should be mirrored, which catches mistakes like scoring black rooks with the knight bitboard
+			m, err := chess.BoardFromFEN(mirrorFEN(fen))
+			require.NoError(t, err)
+			mirror := chess.Engine{B: m, EP: chess.DefaultParams}
+			mirrorTrace := mirror.EvaluateTrace()
+
+			require.Len(t, mirrorTrace.Terms, len(trace.Terms))
+			for i, term := range trace.Terms {
+				assert.Equal(t, term.Name, mirrorTrace.Terms[i].Name)
+				assert.Equal(t, term.White, mirrorTrace.Terms[i].Black, term.Name)
+				assert.Equal(t, term.Black, mirrorTrace.Terms[i].White, term.Name)
+			}
+		})
+	}
+}
+
+// Each parameter should be traced under its own term, so a score can be put down to the weight which caused it
+func TestEvaluateTraceTerms(t *testing.T) {
+	tests := []struct {
+		fen    string
+		params chess.EvalParams
+		term   string
+	}{
+		{"4k3/8/8/8/8/8/8/2B1K1N1 w - - 0 1", chess.EvalParams{BishopWt: [2]int{1, 1}}, "Bishop material"},
+		{"4k3/8/8/8/8/8/8/2B1K1N1 w - - 0 1", chess.EvalParams{KnightWt: [2]int{1, 1}}, "Knight material"},
+		{"4k3/p7/8/8/8/8/8/3RK3 w - - 0 1", chess.EvalParams{RookOpenFile: [2]int{1, 1}, RookSemiOpenFile: [2]int{1, 1}}, "Rooks on open files"},
+		{"4k3/3p4/8/8/8/8/8/3RK3 w - - 0 1", chess.EvalParams{RookOpenFile: [2]int{1, 1}, RookSemiOpenFile: [2]int{1, 1}}, "Rooks on semi-open files"},
+		{"4k3/8/8/8/8/8/8/5K1R w - - 0 1", chess.EvalParams{TrappedBishop: [2]int{1, 1}, TrappedRook: [2]int{1, 1}}, "Trapped rooks"},
+		{"4k3/B7/1p6/8/8/8/8/4K3 w - - 0 1", chess.EvalParams{TrappedBishop: [2]int{1, 1}, TrappedRook: [2]int{1, 1}}, "Trapped bishops"},
+		{"k7/pp4p1/8/8/8/8/5P1P/6K1 w - - 0 1", chess.EvalParams{KingOpenFile: [2]int{1, 1}, KingSemiOpenFile: [2]int{1, 1}}, "King semi-open files"},
+		{"k7/pp6/8/8/8/8/5P2/6K1 w - - 0 1", chess.EvalParams{KingOpenFile: [2]int{1, 1}, KingSemiOpenFile: [2]int{1, 1}}, "King open files"},
+		{"4k3/8/8/8/8/6P1/8/6K1 w - - 0 1", chess.EvalParams{PawnShield: [2]int{1, 1}, PawnShieldAdvanced: [2]int{1, 1}}, "Advanced pawn shield"},
+		{"4k3/8/8/8/8/8/6P1/6K1 w - - 0 1", chess.EvalParams{PawnShield: [2]int{1, 1}, PawnShieldAdvanced: [2]int{1, 1}}, "Pawn shield"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			b, err := chess.BoardFromFEN(tt.fen)
+			require.NoError(t, err)
+			e := chess.Engine{B: b, EP: tt.params}
+
+			scored := map[string]bool{}
+			for _, term := range e.EvaluateTrace().Terms {
+				if term.White != [2]int{} || term.Black != [2]int{} {
+					scored[term.Name] = true
+				}
+			}
+			assert.Equal(t, map[string]bool{tt.term: true}, scored)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package chess
+
+// Identifies a term in an EvalTrace, there's one for each EvalParams field which adds to the score
+// KingAttackWeights only decides which KingAttack entry is used, so both are traced as the king attack term
+type evalTerm int
+
+const (
+	pawnMaterialTerm evalTerm = iota
+	knightMaterialTerm
+	bishopMaterialTerm
+	rookMaterialTerm
+	queenMaterialTerm
+	pawnPSTTerm
+	knightPSTTerm
+	bishopPSTTerm
+	rookPSTTerm
+	queenPSTTerm
+	kingPSTTerm
+	doubledPawnTerm
+	isolatedPawnTerm
+	backwardPawnTerm
+	connectedPawnTerm
+	passedPawnTerm
+	passedFreePathTerm
+	passedOwnKingDistanceTerm
+	passedEnemyKingDistanceTerm
+	knightMobilityTerm
+	bishopMobilityTerm
+	rookMobilityTerm
+	queenMobilityTerm
+	pawnShieldTerm
+	pawnShieldAdvancedTerm
+	kingOpenFileTerm
+	kingSemiOpenFileTerm
+	kingAttackTerm
+	bishopPairTerm
+	rookOpenFileTerm
+	rookSemiOpenFileTerm
+	rookSeventhTerm
+	knightOutpostTerm
+	trappedBishopTerm
+	trappedRookTerm
+	numEvalTerms
+)
+
+var evalTermNames = [numEvalTerms]string{
+	pawnMaterialTerm:            "Pawn material",
+	knightMaterialTerm:          "Knight material",
+	bishopMaterialTerm:          "Bishop material",
+	rookMaterialTerm:            "Rook material",
+	queenMaterialTerm:           "Queen material",
+	pawnPSTTerm:                 "Pawn PST",
+	knightPSTTerm:               "Knight PST",
+	bishopPSTTerm:               "Bishop PST",
+	rookPSTTerm:                 "Rook PST",
+	queenPSTTerm:                "Queen PST",
+	kingPSTTerm:                 "King PST",
+	doubledPawnTerm:             "Doubled pawns",
+	isolatedPawnTerm:            "Isolated pawns",
+	backwardPawnTerm:            "Backward pawns",
+	connectedPawnTerm:           "Connected pawns",
+	passedPawnTerm:              "Passed pawns",
+	passedFreePathTerm:          "Passed pawn free path",
+	passedOwnKingDistanceTerm:   "Passed pawn own king distance",
+	passedEnemyKingDistanceTerm: "Passed pawn enemy king distance",
+	knightMobilityTerm:          "Knight mobility",
+	bishopMobilityTerm:          "Bishop mobility",
+	rookMobilityTerm:            "Rook mobility",
+	queenMobilityTerm:           "Queen mobility",
+	pawnShieldTerm:              "Pawn shield",
+	pawnShieldAdvancedTerm:      "Advanced pawn shield",
+	kingOpenFileTerm:            "King open files",
+	kingSemiOpenFileTerm:        "King semi-open files",
+	kingAttackTerm:              "King attack",
+	bishopPairTerm:              "Bishop pair",
+	rookOpenFileTerm:            "Rooks on open files",
+	rookSemiOpenFileTerm:        "Rooks on semi-open files",
+	rookSeventhTerm:             "Rooks on seventh",
+	knightOutpostTerm:           "Knight outposts",
+	trappedBishopTerm:           "Trapped bishops",
+	trappedRookTerm:             "Trapped rooks",
+}
+
+// One evaluation term, split by side and phase
+type EvalTerm struct {
+	Name  string
+	White [2]int
+	Black [2]int
+}
+
+// Middlegame and endgame contribution of the term from white's point of view
+func (t EvalTerm) Total() [2]int {
+	return [2]int{t.White[MG] - t.Black[MG], t.White[EG] - t.Black[EG]}
+}
+
+// Breakdown of how Evaluate arrived at its score
+type EvalTrace struct {
+	Terms []EvalTerm // every term, whether or not it applied to this position
+	Phase int        // from MaxPhase in the opening down to 0 in a pawn ending
+	Total [2]int     // middlegame and endgame sums of every term, from white's point of view
+	Score int        // tapered score from the side to move's point of view, as returned by Evaluate
+
+	Endgame string // name of the specialised endgame evaluation which replaced or scaled Score, if any
+}
+
+func (t *EvalTrace) add(i evalTerm, white bool, score [2]int) {
+	if white {
+		t.Terms[i].White[MG] += score[MG]
+		t.Terms[i].White[EG] += score[EG]
+	} else {
+		t.Terms[i].Black[MG] += score[MG]
+		t.Terms[i].Black[EG] += score[EG]
+	}
+}
+
+// Accumulates the score of one side, recording every term when there is a trace
+type sideScore struct {
+	score [2]int
+	white bool
+	trace *EvalTrace
+}
+
+func (s *sideScore) add(term evalTerm, wt [2]int, n int) {
+	score := [2]int{wt[MG] * n, wt[EG] * n}
+	s.score[MG] += score[MG]
+	s.score[EG] += score[EG]
+	if s.trace != nil {
+		s.trace.add(term, s.white, score)
+	}
+}
+
+// Returns a breakdown of every term of the classic evaluation, bypassing the pawn table
+// This ignores Evaluator, since other evaluators have no terms to break down
+func (e *Engine) EvaluateTrace() EvalTrace {
+	return traceClassic(&e.B, &e.EP)
+}
+
+func traceClassic(b *Board, ep *EvalParams) EvalTrace {
+	t := EvalTrace{Terms: make([]EvalTerm, numEvalTerms)}
+	for i := range t.Terms {
+		t.Terms[i].Name = evalTermNames[i]
+	}
+	t.Score = evaluateClassic(b, ep, nil, &t)
+	return t
+}
@@ -0,0 +1,67 @@
+package chess
+
+import "math/bits"
+
+// Scores a position from the side to move's point of view
+// Search only ever sees positions through an Evaluator, so any implementation can be plugged into an Engine
+type Evaluator interface {
+	Evaluate(b *Board) int
+}
+
+// Evaluators which keep state in step with a board, such as NNUE accumulators
+// Attach should start tracking b, normally by calling b.SetObserver, after which every move and unmove made on b is
+// reported through the MoveObserver methods
+type IncrementalEvaluator interface {
+	Evaluator
+	MoveObserver
+	Attach(b *Board)
+}
+
+// Makes ev the engine's evaluation, attaching it to the engine's board if it is incremental
+// Passing nil goes back to the classic evaluation using EP and PT
+func (e *Engine) SetEvaluator(ev Evaluator) {
+	e.B.SetObserver(nil)
+	e.Evaluator = ev
+	if ie, ok := ev.(IncrementalEvaluator); ok {
+		ie.Attach(&e.B)
+	}
+}
+
+// The hand-written evaluation, with its own parameters and pawn table
+type ClassicEvaluator struct {
+	Params EvalParams
+	Pawns  PawnTable // the zero value is fine, it just never hits
+}
+
+func NewClassicEvaluator(ep EvalParams, pawnTableExp int) *ClassicEvaluator {
+	return &ClassicEvaluator{
+		Params: ep,
+		Pawns:  *NewPawnTable(pawnTableExp),
+	}
+}
+
+func (c *ClassicEvaluator) Evaluate(b *Board) int {
+	return evaluateClassic(b, &c.Params, &c.Pawns, nil)
+}
+
+// Breakdown of every term, see Engine.EvaluateTrace
+func (c *ClassicEvaluator) Trace(b *Board) EvalTrace {
+	return traceClassic(b, &c.Params)
+}
+
+// Counts material and nothing else, using the same values as SEE
+// Mostly useful for testing search, where a simple, predictable evaluation makes results easy to check
+type MaterialEvaluator struct{}
+
+func (MaterialEvaluator) Evaluate(b *Board) int {
+	score := pawnValue*(bits.OnesCount64(b.whitePawns)-bits.OnesCount64(b.blackPawns)) +
+		knightValue*(bits.OnesCount64(b.whiteKnights)-bits.OnesCount64(b.blackKnights)) +
+		bishopValue*(bits.OnesCount64(b.whiteBishops)-bits.OnesCount64(b.blackBishops)) +
+		rookValue*(bits.OnesCount64(b.whiteRooks)-bits.OnesCount64(b.blackRooks)) +
+		queenValue*(bits.OnesCount64(b.whiteQueens)-bits.OnesCount64(b.blackQueens))
+
+	if b.Turn == BlackTurn {
+		return -score
+	}
+	return score
+}
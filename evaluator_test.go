@@ -0,0 +1,96 @@
+package chess_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestMaterialEvaluator(t *testing.T) {
+	b, err := chess.BoardFromFEN("4k3/ppp5/8/8/8/8/PPPP4/RN2K3 w - - 0 1")
+	require.NoError(t, err)
+	assert.Equal(t, 100+500+300, chess.MaterialEvaluator{}.Evaluate(&b))
+
+	b, err = chess.BoardFromFEN("4k3/ppp5/8/8/8/8/PPPP4/RN2K3 b - - 0 1")
+	require.NoError(t, err)
+	assert.Equal(t, -900, chess.MaterialEvaluator{}.Evaluate(&b))
+}
+
+// Specialised endgames belong to the classic evaluation, so a plugged evaluator has the final say
+func TestEvaluatorEndgames(t *testing.T) {
+	for fen, want := range map[string]int{
+		"8/8/8/4k3/8/8/8/KQ6 w - - 0 1": 900, // a known win
+		"8/8/8/4k3/8/8/8/KN6 w - - 0 1": 300, // a known draw
+	} {
+		b, err := chess.BoardFromFEN(fen)
+		require.NoError(t, err)
+		e := chess.Engine{B: b, EP: chess.DefaultParams}
+		classic := e.Evaluate()
+		assert.NotEqual(t, want, classic, fen)
+		assert.Equal(t, classic, chess.NewClassicEvaluator(chess.DefaultParams, 4).Evaluate(&b), fen)
+
+		e.SetEvaluator(chess.MaterialEvaluator{})
+		assert.Equal(t, want, e.Evaluate(), fen)
+	}
+}
+
+func TestClassicEvaluator(t *testing.T) {
+	c := chess.NewClassicEvaluator(chess.DefaultParams, 4)
+	for _, fen := range evalFENs {
+		b, err := chess.BoardFromFEN(fen)
+		require.NoError(t, err)
+		e := chess.Engine{B: b, EP: chess.DefaultParams}
+
+		assert.Equal(t, e.Evaluate(), c.Evaluate(&b), fen)
+		assert.Equal(t, e.EvaluateTrace(), c.Trace(&b), fen)
+	}
+}
+
+// Counts every call, to check search drives the hooks properly
+type countingEvaluator struct {
+	chess.MaterialEvaluator
+	board                       *chess.Board
+	attached                    int
+	evaluations, moves, unmoves int
+}
+
+func (c *countingEvaluator) Attach(b *chess.Board) {
+	c.board = b
+	c.attached++
+	b.SetObserver(c)
+}
+
+func (c *countingEvaluator) Evaluate(b *chess.Board) int {
+	c.evaluations++
+	return c.MaterialEvaluator.Evaluate(b)
+}
+
+func (c *countingEvaluator) Moved(b *chess.Board, m chess.Move)   { c.moves++ }
+func (c *countingEvaluator) Unmoved(b *chess.Board, m chess.Move) { c.unmoves++ }
+
+func TestSearchWithEvaluator(t *testing.T) {
+	// Black's queen is hanging
+	b, err := chess.BoardFromFEN("4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1")
+	require.NoError(t, err)
+
+	e := chess.Engine{B: b, TT: *chess.NewTranspositionTable(10)}
+	c := &countingEvaluator{}
+	e.SetEvaluator(c)
+
+	assert.Equal(t, 1, c.attached)
+	assert.Same(t, &e.B, c.board)
+
+	m := e.Search(0)
+	assert.Equal(t, "d1d5", m.String())
+	assert.Positive(t, c.evaluations)
+	assert.Positive(t, c.moves)
+	assert.Equal(t, c.moves, c.unmoves)
+
+	// Back to the classic evaluation, the old evaluator shouldn't hear about any more moves
+	e.SetEvaluator(nil)
+	moves := c.moves
+	e.B.Move(m)
+	assert.Equal(t, moves, c.moves)
+}
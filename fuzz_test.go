@@ -0,0 +1,181 @@
+package chess
+
+import (
+	"math/bits"
+	"slices"
+	"testing"
+)
+
+var fuzzFENs = []string{
+	"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+	"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq -",
+	"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	"8/8/1k6/2b5/2pP4/8/5K2/8 b - d3 0 1",
+	"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+	"8/P1k5/K7/8/8/8/8/8 w - - 0 1",
+}
+
+// Checks the board is in a state move generation can cope with
+func checkInvariants(t *testing.T, b *Board) {
+	t.Helper()
+
+	boards := []uint64{
+		b.whitePawns, b.whiteRooks, b.whiteKnights, b.whiteBishops, b.whiteQueens, b.whiteKings,
+		b.blackPawns, b.blackRooks, b.blackKnights, b.blackBishops, b.blackQueens, b.blackKings,
+	}
+	for i := range boards {
+		for j := i + 1; j < len(boards); j++ {
+			if boards[i]&boards[j] != 0 {
+				t.Fatalf("bitboards %d and %d overlap\n%s", i, j, b.String())
+			}
+		}
+	}
+
+	if bits.OnesCount64(b.whiteKings) != 1 || bits.OnesCount64(b.blackKings) != 1 {
+		t.Fatalf("expected exactly one king per side\n%s", b.String())
+	}
+
+	if (b.whitePawns|b.blackPawns)&0xff000000000000ff != 0 {
+		t.Fatalf("pawn on back rank\n%s", b.String())
+	}
+
+	if !b.CanEnPassant && b.EnPassantFile != 0 {
+		t.Fatalf("EnPassantFile is %d, but CanEnPassant is false", b.EnPassantFile)
+	}
+
+	b.Turn = !b.Turn
+	inCheck := b.InCheck()
+	b.Turn = !b.Turn
+	if inCheck {
+		t.Fatalf("side which just moved is in check\n%s", b.String())
+	}
+}
+
+func checkBoardsEqual(t *testing.T, want, got *Board) {
+	t.Helper()
+
+	if want.whitePawns != got.whitePawns || want.whiteRooks != got.whiteRooks || want.whiteKnights != got.whiteKnights ||
+		want.whiteBishops != got.whiteBishops || want.whiteQueens != got.whiteQueens || want.whiteKings != got.whiteKings ||
+		want.blackPawns != got.blackPawns || want.blackRooks != got.blackRooks || want.blackKnights != got.blackKnights ||
+		want.blackBishops != got.blackBishops || want.blackQueens != got.blackQueens || want.blackKings != got.blackKings {
+		t.Fatalf("bitboards differ, want\n%s\ngot\n%s", want.String(), got.String())
+	}
+
+	switch {
+	case want.Turn != got.Turn:
+		t.Fatalf("Turn differs, want %v got %v", want.Turn, got.Turn)
+	case want.HalfMoves != got.HalfMoves:
+		t.Fatalf("HalfMoves differs, want %d got %d", want.HalfMoves, got.HalfMoves)
+	case want.CastleRights != got.CastleRights:
+		t.Fatalf("CastleRights differs, want %b got %b", want.CastleRights, got.CastleRights)
+	case want.CanEnPassant != got.CanEnPassant || want.EnPassantFile != got.EnPassantFile:
+		t.Fatalf("en passant differs, want %v %d got %v %d", want.CanEnPassant, want.EnPassantFile, got.CanEnPassant, got.EnPassantFile)
+	case !slices.Equal(want.Moves, got.Moves):
+		t.Fatalf("Moves differs, want %v got %v", want.Moves, got.Moves)
+	case !slices.Equal(want.noisyMoves, got.noisyMoves):
+		t.Fatalf("noisyMoves differs, want %v got %v", want.noisyMoves, got.noisyMoves)
+	case want.Zobrist() != got.Zobrist():
+		t.Fatalf("Zobrist differs, want %x got %x", want.Zobrist(), got.Zobrist())
+	}
+}
+
+func FuzzBoardFromFEN(f *testing.F) {
+	for _, fen := range fuzzFENs {
+		f.Add(fen)
+	}
+	f.Add("")
+	f.Add("8/8/8/8/8/8/8/8 w - - 0 1")
+	f.Add("rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	f.Add("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w XYZ - 0 1")
+
+	f.Fuzz(func(t *testing.T, fen string) {
+		b, err := BoardFromFEN(fen)
+		if err != nil {
+			return
+		}
+		checkInvariants(t, &b)
+
+		ms, _ := b.LegalMoves()
+		for _, m := range ms {
+			before := b.Copy()
+			b.Move(m)
+			checkInvariants(t, &b)
+			b.Unmove()
+			checkBoardsEqual(t, &before, &b)
+		}
+	})
+}
+
+func FuzzMoveSequence(f *testing.F) {
+	for _, fen := range fuzzFENs {
+		f.Add(fen, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+		f.Add(fen, []byte{255, 17, 33, 100, 3, 91, 5, 7, 64, 12, 0, 0, 1, 200, 42})
+	}
+
+	f.Fuzz(func(t *testing.T, fen string, choices []byte) {
+		b, err := BoardFromFEN(fen)
+		if err != nil {
+			return
+		}
+
+		history := []Board{}
+		for _, c := range choices {
+			ms, _ := b.LegalMoves()
+			if len(ms) == 0 {
+				break
+			}
+
+			history = append(history, b.Copy())
+			b.Move(ms[int(c)%len(ms)])
+			checkInvariants(t, &b)
+		}
+
+		for i := len(history) - 1; i >= 0; i-- {
+			b.Unmove()
+			checkBoardsEqual(t, &history[i], &b)
+		}
+	})
+}
+
+func FuzzParseAlgebraicMove(f *testing.F) {
+	for _, s := range []string{"e2e4", "e7e8q", "a1h8", "", "e2", "e7e8x", "i9a1", "e2e4e5"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		from, to, _, err := ParseAlgebraicMove(s)
+		if err != nil {
+			return
+		}
+		if from < 0 || from > 63 || to < 0 || to > 63 {
+			t.Fatalf("%q parsed to out of range squares %d %d", s, from, to)
+		}
+	})
+}
+
+func FuzzTryMove(f *testing.F) {
+	for _, s := range []string{"e2e4", "e4", "Nf3", "O-O", "exd5", "e8=Q+", "Qh4e1", "a7-a8=N", "", "x", "0-0-0", "e.p."} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, fen := range fuzzFENs {
+			b, _ := BoardFromFEN(fen)
+			before := b.Copy()
+
+			m, err := b.TryMove(s)
+			if err != nil {
+				checkBoardsEqual(t, &before, &b)
+				continue
+			}
+
+			ms, _ := before.LegalMoves()
+			if !slices.Contains(ms, m) {
+				t.Fatalf("%q was parsed to illegal move %s", s, m.String())
+			}
+			checkInvariants(t, &b)
+		}
+	})
+}
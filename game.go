@@ -0,0 +1,405 @@
+package chess
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A game from its starting position, with tags such as the players and event, and a tree of annotated moves
+// The main line follows the first child of each move, any other children are variations
+// Navigating moves the current position around the tree, new moves are added after it
+type Game struct {
+	Tags        map[string]string // PGN tags, the FEN, SetUp and Result tags are kept in sync with the game instead
+	Result      string            // 1-0, 0-1, 1/2-1/2 or * while the game is in progress
+	Termination string            // how the game ended, e.g. checkmate or "white resigns", empty while it's in progress
+
+	start   Board
+	root    *GameNode // the starting position, which has no move
+	current *GameNode
+	board   Board // the position at current
+}
+
+// A move in a game, with its annotations and the moves played after it
+type GameNode struct {
+	Move    Move // zero for the starting position
+	SAN     string
+	NAGs    []int
+	Comment string        // after the move, or before the first move for the starting position
+	Clock   time.Duration // the mover's time left after the move, 0 if it isn't known
+
+	Parent   *GameNode   // nil for the starting position
+	Children []*GameNode // the main continuation first, then any variations
+}
+
+// Reports whether the move is on its game's main line
+func (n *GameNode) IsMainLine() bool {
+	for ; n.Parent != nil; n = n.Parent {
+		if n.Parent.Children[0] != n {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns a game made up of b's moves, from the position b started in, with the current position at the end
+func NewGame(b *Board) *Game {
+	start := b.Copy()
+	for len(start.Moves) > 0 {
+		start.Unmove()
+	}
+
+	root := &GameNode{}
+	g := &Game{Tags: map[string]string{}, Result: "*", start: start, root: root, current: root, board: start.Copy()}
+	for _, m := range b.Moves {
+		g.Move(m)
+	}
+	return g
+}
+
+// Returns the position the game started from
+func (g *Game) Start() Board {
+	return g.start.Copy()
+}
+
+// Returns the current position, which is a copy, so moves made on it don't affect the game
+func (g *Game) Board() Board {
+	return g.board.Copy()
+}
+
+// Returns the current move, or the root of the tree at the start of the game
+func (g *Game) Node() *GameNode {
+	return g.current
+}
+
+// Returns the root of the move tree, which holds the starting position
+func (g *Game) Root() *GameNode {
+	return g.root
+}
+
+// Returns the number of moves played to reach the current position
+func (g *Game) Ply() int {
+	ply := 0
+	for n := g.current; n.Parent != nil; n = n.Parent {
+		ply++
+	}
+	return ply
+}
+
+// Returns the moves played to reach the current position
+func (g *Game) Moves() []Move {
+	var ms []Move
+	for n := g.current; n.Parent != nil; n = n.Parent {
+		ms = append(ms, n.Move)
+	}
+	slices.Reverse(ms)
+	return ms
+}
+
+// Plays m from the current position, going to the existing move if it has already been played here
+// A new move continues the line if nothing has been played from here yet, otherwise it starts a variation
+// Playing the last move of the main line updates the result if it ends the game
+func (g *Game) Move(m Move) error {
+	for _, c := range g.current.Children {
+		if c.Move == m {
+			g.board.Move(m)
+			g.current = c
+			return nil
+		}
+	}
+
+	ms, err := g.board.legalMovesInProgress()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(ms, m) {
+		return ErrIllegalMove
+	}
+
+	n := &GameNode{Move: m, SAN: g.board.SAN(m), Parent: g.current}
+	g.current.Children = append(g.current.Children, n)
+	g.board.Move(m)
+	g.current = n
+
+	if n.IsMainLine() {
+		if result, reason := g.board.Result(); result != "*" {
+			g.Result, g.Termination = result, reason
+		}
+	}
+	return nil
+}
+
+// Parses and plays a move in any notation ParseMove accepts, see Move
+func (g *Game) TryMove(s string) (Move, error) {
+	m, err := g.board.ParseMove(s)
+	if err != nil {
+		return 0, err
+	}
+	return m, g.Move(m)
+}
+
+// Goes back a move, returning false at the start of the game
+func (g *Game) Prev() bool {
+	if g.current.Parent == nil {
+		return false
+	}
+	g.board.Unmove()
+	g.current = g.current.Parent
+	return true
+}
+
+// Goes forward along the main continuation, returning false at the end of the line
+func (g *Game) Next() bool {
+	if len(g.current.Children) == 0 {
+		return false
+	}
+	g.current = g.current.Children[0]
+	g.board.Move(g.current.Move)
+	return true
+}
+
+// Goes back to the starting position
+func (g *Game) First() {
+	for g.Prev() {
+	}
+}
+
+// Goes forward to the end of the current line
+func (g *Game) Last() {
+	for g.Next() {
+	}
+}
+
+// Goes to the position after ply moves along the current line, going back or following the main continuation
+// Returns false, without moving, if the line is shorter than that
+func (g *Game) Goto(ply int) bool {
+	if ply < 0 {
+		return false
+	}
+	n := g.current
+	depth := g.Ply()
+	for ; depth < ply; depth++ {
+		if len(n.Children) == 0 {
+			return false
+		}
+		n = n.Children[0]
+	}
+	for ; depth > ply; depth-- {
+		n = n.Parent
+	}
+	g.GotoNode(n)
+	return true
+}
+
+// Goes to a move anywhere in the game's tree
+func (g *Game) GotoNode(n *GameNode) {
+	var path []*GameNode
+	for ; n.Parent != nil; n = n.Parent {
+		path = append(path, n)
+	}
+
+	g.First()
+	for _, n := range slices.Backward(path) {
+		g.board.Move(n.Move)
+		g.current = n
+	}
+}
+
+// Makes the variation starting with n the main continuation from its position
+// Returns false, changing nothing, if n is the starting position or isn't in the tree
+func (g *Game) PromoteVariation(n *GameNode) bool {
+	if n.Parent == nil {
+		return false
+	}
+	siblings := n.Parent.Children
+	i := slices.Index(siblings, n)
+	if i < 0 {
+		return false
+	}
+	copy(siblings[1:i+1], siblings[:i])
+	siblings[0] = n
+
+	if i > 0 && n.IsMainLine() {
+		g.mainLineChanged()
+	}
+	return true
+}
+
+// Removes n and every move after it, going back to the position before n if the current position was among them
+// Returns false, changing nothing, if n is the starting position or isn't in the tree
+func (g *Game) DeleteVariation(n *GameNode) bool {
+	if n.Parent == nil || !slices.Contains(n.Parent.Children, n) {
+		return false
+	}
+	for c := g.current; c != nil; c = c.Parent {
+		if c == n {
+			g.GotoNode(n.Parent)
+			break
+		}
+	}
+
+	mainLine := n.IsMainLine()
+	n.Parent.Children = slices.DeleteFunc(n.Parent.Children, func(c *GameNode) bool { return c == n })
+	if mainLine {
+		g.mainLineChanged()
+	}
+	return true
+}
+
+// Sets the result from the position at the end of the main line, which forgets any resignation or agreed draw
+func (g *Game) mainLineChanged() {
+	b := g.start.Copy()
+	for n := firstChild(g.root); n != nil; n = firstChild(n) {
+		b.Move(n.Move)
+	}
+	g.Result, g.Termination = b.Result()
+}
+
+// Returns the game in PGN form, with every variation and annotation
+// Clock times are written into the comments as [%clk h:mm:ss] commands
+func (g *Game) PGN() *PGNGame {
+	p := &PGNGame{Tags: maps.Clone(g.Tags), Result: g.Result}
+	if p.Tags == nil {
+		p.Tags = map[string]string{}
+	}
+	delete(p.Tags, "SetUp")
+	delete(p.Tags, "FEN")
+	if fen := g.start.FEN(); fen != startFEN {
+		p.Tags["SetUp"] = "1"
+		p.Tags["FEN"] = fen
+	}
+
+	if len(g.root.Children) > 0 {
+		p.PGNLine = pgnLine(g.root.Children[0])
+	}
+	p.Comment = g.root.Comment
+	return p
+}
+
+// Returns the line starting with n, which follows the first child of each move after it
+func pgnLine(n *GameNode) PGNLine {
+	var l PGNLine
+	for ; n != nil; n = firstChild(n) {
+		a := PGNAnnotation{NAGs: slices.Clone(n.NAGs), Comment: n.Comment}
+		if n.Clock > 0 {
+			a.Comment = joinComments("[%clk "+formatClock(n.Clock)+"]", a.Comment)
+		}
+		if n.Parent.Children[0] == n { // the first move of a variation is one of its parent's other children
+			for _, v := range n.Parent.Children[1:] {
+				a.Variations = append(a.Variations, pgnLine(v))
+			}
+		}
+
+		l.Moves = append(l.Moves, n.SAN)
+		if len(a.NAGs) > 0 || a.Comment != "" || len(a.Variations) > 0 {
+			if l.Annotations == nil {
+				l.Annotations = map[int]*PGNAnnotation{}
+			}
+			l.Annotations[len(l.Moves)-1] = &a
+		}
+	}
+	return l
+}
+
+func firstChild(n *GameNode) *GameNode {
+	if len(n.Children) == 0 {
+		return nil
+	}
+	return n.Children[0]
+}
+
+// Formats a clock time as h:mm:ss, with tenths of a second if there are any
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second / 10)
+	s := fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+	if tenths := d % time.Second / (time.Second / 10); tenths > 0 {
+		s += "." + strconv.Itoa(int(tenths))
+	}
+	return s
+}
+
+var clockCommand = regexp.MustCompile(`\[%clk\s+(\d+):(\d+):(\d+(?:\.\d+)?)\]`)
+
+// Takes a [%clk h:mm:ss] command out of a comment, returning the time and the rest of the comment
+func parseClock(comment string) (time.Duration, string) {
+	match := clockCommand.FindStringSubmatchIndex(comment)
+	if match == nil {
+		return 0, comment
+	}
+	h, _ := strconv.Atoi(comment[match[2]:match[3]])
+	m, _ := strconv.Atoi(comment[match[4]:match[5]])
+	s, _ := strconv.ParseFloat(comment[match[6]:match[7]], 64)
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s*float64(time.Second))
+	return d, strings.Join(strings.Fields(comment[:match[0]]+" "+comment[match[1]:]), " ")
+}
+
+// Builds a game from a PGN one, checking every move in it, with the current position at the end of the main line
+// A comment at the start of a variation is joined onto the comment after its first move
+func GameFromPGN(p *PGNGame) (*Game, error) {
+	b, err := p.Board()
+	if err != nil {
+		return nil, err
+	}
+
+	g := NewGame(&b)
+	g.Tags = maps.Clone(p.Tags)
+	delete(g.Tags, "SetUp")
+	delete(g.Tags, "FEN")
+	delete(g.Tags, "Result")
+	g.root.Comment = p.Comment
+
+	main := p.PGNLine
+	main.Comment = ""
+	if err := g.addPGNLine(main); err != nil {
+		return nil, err
+	}
+
+	g.First()
+	g.Last()
+	g.Result = p.Result
+	if result, reason := g.board.Result(); result != "*" && result == p.Result {
+		g.Termination = reason
+	}
+	return g, nil
+}
+
+// Plays a line from the current position, along with its variations, leaving the current position at its end
+func (g *Game) addPGNLine(l PGNLine) error {
+	for i, san := range l.Moves {
+		before := g.current
+		m, err := g.board.ParseSAN(san)
+		if err == nil {
+			err = g.Move(m)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: move %d %s: %w", ErrInvalidPGN, g.Ply()+1, san, err)
+		}
+
+		n := g.current
+		comment := ""
+		if i == 0 {
+			comment = l.Comment
+		}
+		a := l.Annotations[i]
+		if a == nil {
+			a = &PGNAnnotation{}
+		}
+		n.NAGs = append(n.NAGs, a.NAGs...)
+		n.Clock, n.Comment = parseClock(joinComments(comment, a.Comment))
+
+		for _, v := range a.Variations {
+			g.GotoNode(before)
+			if err := g.addPGNLine(v); err != nil {
+				return err
+			}
+		}
+		if len(a.Variations) > 0 {
+			g.GotoNode(n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package chess_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func playGameMoves(t *testing.T, g *chess.Game, moves string) {
+	t.Helper()
+	for _, m := range strings.Fields(moves) {
+		_, err := g.TryMove(m)
+		require.NoError(t, err, m)
+	}
+}
+
+func TestGameNavigation(t *testing.T) {
+	b := chess.NewBoard()
+	g := chess.NewGame(&b)
+	playGameMoves(t, g, "e4 e5 Nf3 Nc6")
+	assert.Equal(t, 4, g.Ply())
+
+	require.True(t, g.Prev())
+	require.True(t, g.Prev())
+	playGameMoves(t, g, "Bc4") // a variation on 2. Nf3
+	assert.False(t, g.Node().IsMainLine())
+	assert.Equal(t, "Bc4", g.Node().SAN)
+	assert.Len(t, g.Root().Children[0].Children[0].Children, 2)
+
+	g.First()
+	assert.Equal(t, 0, g.Ply())
+	assert.False(t, g.Prev())
+	board := g.Board()
+	assert.Equal(t, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", board.FEN())
+
+	g.Last()
+	assert.Equal(t, "Nc6", g.Node().SAN, "the main line is followed")
+
+	require.True(t, g.Goto(2))
+	assert.Equal(t, "e5", g.Node().SAN)
+	assert.False(t, g.Goto(10))
+	assert.Equal(t, 2, g.Ply(), "a failed goto doesn't move")
+
+	// Playing a move that's already there follows it rather than adding another
+	playGameMoves(t, g, "Nf3")
+	assert.Len(t, g.Node().Parent.Children, 2)
+
+	bc4 := g.Node().Parent.Children[1]
+	g.PromoteVariation(bc4)
+	assert.True(t, bc4.IsMainLine())
+	g.GotoNode(bc4)
+	assert.Equal(t, []string{"e4", "e5", "Bc4"}, sans(g))
+	board = g.Board()
+	assert.Equal(t, "rnbqkbnr/pppp1ppp/8/4p3/2B1P3/8/PPPP1PPP/RNBQK1NR b KQkq - 1 2", board.FEN())
+
+	g.DeleteVariation(bc4)
+	assert.Equal(t, 2, g.Ply(), "the current position was in the deleted variation")
+	g.Last()
+	assert.Equal(t, []string{"e4", "e5", "Nf3", "Nc6"}, sans(g))
+
+	_, err := g.TryMove("Ke3")
+	assert.ErrorIs(t, err, chess.ErrIllegalMove)
+
+	// Nodes which aren't variations are left alone
+	assert.False(t, g.DeleteVariation(g.Root()))
+	assert.False(t, g.PromoteVariation(g.Root()))
+	assert.False(t, g.DeleteVariation(bc4), "already deleted")
+	assert.False(t, g.PromoteVariation(bc4), "already deleted")
+	assert.Equal(t, []string{"e4", "e5", "Nf3", "Nc6"}, sans(g))
+}
+
+func TestGameResultAfterEditing(t *testing.T) {
+	b := chess.NewBoard()
+	g := chess.NewGame(&b)
+	playGameMoves(t, g, "f3 e5 g4 Qh4")
+	require.Equal(t, "0-1", g.Result)
+
+	// Taking back the mate
+	g.Prev()
+	mate := g.Node().Children[0]
+	require.True(t, g.DeleteVariation(mate))
+	assert.Equal(t, "*", g.Result)
+	assert.Equal(t, "", g.Termination)
+
+	// Promoting a variation over the mate
+	playGameMoves(t, g, "Qh4")
+	assert.Equal(t, "0-1", g.Result)
+	g.Prev()
+	playGameMoves(t, g, "d6")
+	g.Prev()
+	require.True(t, g.PromoteVariation(g.Node().Children[1]))
+	assert.Equal(t, "*", g.Result)
+
+	// Deleting it leaves the mate as the main line again, forgetting the resignation
+	g.Result, g.Termination = "1-0", "black resigns"
+	require.True(t, g.DeleteVariation(g.Node().Children[0]))
+	assert.Equal(t, "0-1", g.Result)
+	assert.Equal(t, "checkmate", g.Termination)
+}
+
+func sans(g *chess.Game) []string {
+	var s []string
+	for n := g.Node(); n.Parent != nil; n = n.Parent {
+		s = append([]string{n.SAN}, s...)
+	}
+	return s
+}
+
+func TestGameResult(t *testing.T) {
+	b := chess.NewBoard()
+	g := chess.NewGame(&b)
+	playGameMoves(t, g, "f3 e5 g4")
+	assert.Equal(t, "*", g.Result)
+
+	// Mate in a variation isn't the game's result
+	g.Prev()
+	playGameMoves(t, g, "g3 Qg5 g4 Qxg4")
+	assert.Equal(t, "*", g.Result)
+
+	g.GotoNode(g.Root())
+	g.Last()
+	playGameMoves(t, g, "Qh4#")
+	assert.Equal(t, "0-1", g.Result)
+	assert.Equal(t, "checkmate", g.Termination)
+
+	_, err := g.TryMove("a3")
+	assert.ErrorIs(t, err, chess.ErrGameOver)
+}
+
+func TestNewGameFromBoard(t *testing.T) {
+	b, err := chess.BoardFromFEN("4k3/8/8/8/8/8/4P3/4K3 b - - 0 30")
+	require.NoError(t, err)
+	playMoves(t, &b, "Kd7 e4")
+
+	g := chess.NewGame(&b)
+	assert.Equal(t, 2, g.Ply())
+	start := g.Start()
+	assert.Equal(t, "4k3/8/8/8/8/8/4P3/4K3 b - - 0 30", start.FEN())
+	assert.Equal(t, b.Moves, g.Moves())
+
+	p := g.PGN()
+	assert.Equal(t, "4k3/8/8/8/8/8/4P3/4K3 b - - 0 30", p.Tags["FEN"])
+	assert.Equal(t, []string{"Kd7", "e4"}, p.Moves)
+}
+
+func TestGamePGN(t *testing.T) {
+	const pgn = `[Event "Annotated"]
+[White "A"]
+[Black "B"]
+[Result "1-0"]
+
+{Opening} 1. e4 {[%clk 0:04:59]} e5 {[%clk 0:04:58.5] solid} 2. Nf3 $1 (2. Bc4 Nf6 (2... Bc5) 3. d3)
+2... Nc6 3. Bb5 1-0
+`
+	p, err := chess.NewPGNReader(strings.NewReader(pgn)).Next()
+	require.NoError(t, err)
+	g, err := chess.GameFromPGN(p)
+	require.NoError(t, err)
+
+	assert.Equal(t, "A", g.Tags["White"])
+	assert.Equal(t, "1-0", g.Result)
+	assert.Equal(t, 5, g.Ply(), "the current position is the end of the main line")
+	assert.Equal(t, "Opening", g.Root().Comment)
+
+	e4 := g.Root().Children[0]
+	assert.Equal(t, 4*time.Minute+59*time.Second, e4.Clock)
+	assert.Empty(t, e4.Comment)
+	e5 := e4.Children[0]
+	assert.Equal(t, 4*time.Minute+58*time.Second+500*time.Millisecond, e5.Clock)
+	assert.Equal(t, "solid", e5.Comment)
+	require.Len(t, e5.Children, 2)
+	assert.Equal(t, []int{1}, e5.Children[0].NAGs)
+	bc4 := e5.Children[1]
+	assert.Equal(t, "Bc4", bc4.SAN)
+	require.Len(t, bc4.Children, 2)
+	assert.Equal(t, "Bc5", bc4.Children[1].SAN)
+
+	var buf bytes.Buffer
+	require.NoError(t, g.PGN().Write(&buf))
+	assert.Contains(t, buf.String(), `{Opening} 1. e4 {[%clk 0:04:59]} 1... e5 {[%clk 0:04:58.5] solid} 2. Nf3 $1 (2.
+Bc4 Nf6 (2... Bc5) 3. d3) 2... Nc6 3. Bb5 1-0`)
+
+	read, err := chess.NewPGNReader(&buf).Next()
+	require.NoError(t, err)
+	assert.Equal(t, p.PGNLine, read.PGNLine)
+
+	p, err = chess.NewPGNReader(strings.NewReader("1. e4 e5 (1... Ke3) *")).Next()
+	require.NoError(t, err)
+	_, err = chess.GameFromPGN(p)
+	assert.ErrorIs(t, err, chess.ErrInvalidPGN)
+}
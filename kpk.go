@@ -0,0 +1,149 @@
+package chess
+
+import "math/bits"
+
+// King and pawn vs king bitbase, one bit per position set when the side with the pawn wins
+// It's generated at init by retrograde analysis, which takes a few tens of milliseconds
+// Positions are stored with white's pawn on files a-d, using a1 = 0 square numbering (Board indexes ^ 7)
+
+const kpkSize = 2 * 24 * 64 * 64 // side to move, pawn square, white king, black king
+
+var kpkBitbase [kpkSize / 64]uint64
+
+// Positions are classified by OR-ing the results of their successors together
+const (
+	kpkInvalid = 0
+	kpkUnknown = 1
+	kpkDraw    = 2
+	kpkWin     = 4
+)
+
+func kpkIndex(blackToMove bool, bk, wk, pawn int) int {
+	stm := 0
+	if blackToMove {
+		stm = 1
+	}
+	return stm | bk<<1 | wk<<7 | (pawn&7)<<13 | (6-pawn>>3)<<15
+}
+
+// Squares attacked by a king, a1 = 0 numbering
+func kpkKingAttacks(s int) uint64 {
+	return mirrorFiles(kingAttacks[s^7])
+}
+
+// Swaps bit i with bit i^7, converting between Board indexes and a1 = 0 numbering
+func mirrorFiles(bb uint64) uint64 {
+	bb = bb>>1&0x5555555555555555 | bb&0x5555555555555555<<1
+	bb = bb>>2&0x3333333333333333 | bb&0x3333333333333333<<2
+	return bb>>4&0x0f0f0f0f0f0f0f0f | bb&0x0f0f0f0f0f0f0f0f<<4
+}
+
+// Squares attacked by a white pawn, a1 = 0 numbering
+func kpkPawnAttacks(s int) uint64 {
+	var attacks uint64
+	if s&7 > 0 {
+		attacks |= 1 << (s + 7)
+	}
+	if s&7 < 7 {
+		attacks |= 1 << (s + 9)
+	}
+	return attacks
+}
+
+func kpkDistance(a, b int) int {
+	return max(abs(a>>3-b>>3), abs(a&7-b&7))
+}
+
+func init() {
+	db := make([]uint8, kpkSize)
+
+	for idx := range db {
+		blackToMove := idx&1 == 1
+		bk, wk := idx>>1&63, idx>>7&63
+		pawn := idx>>13&3 + 8*(6-idx>>15)
+
+		switch {
+		case kpkDistance(wk, bk) <= 1 || wk == pawn || bk == pawn:
+			db[idx] = kpkInvalid
+		case !blackToMove && kpkPawnAttacks(pawn)&(1<<bk) != 0:
+			db[idx] = kpkInvalid // black is in check with white to move
+		case !blackToMove && pawn>>3 == 6 && wk != pawn+8 && bk != pawn+8 &&
+			(kpkDistance(bk, pawn+8) > 1 || kpkDistance(wk, pawn+8) == 1):
+			db[idx] = kpkWin // promotes safely
+		case blackToMove && kpkKingAttacks(bk)&^(kpkKingAttacks(wk)|kpkPawnAttacks(pawn)) == 0:
+			db[idx] = kpkDraw // stalemate
+		case blackToMove && kpkKingAttacks(bk)&^kpkKingAttacks(wk)&(1<<pawn) != 0:
+			db[idx] = kpkDraw // the pawn can be taken
+		default:
+			db[idx] = kpkUnknown
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for idx := range db {
+			if db[idx] != kpkUnknown {
+				continue
+			}
+			if db[idx] = kpkClassify(db, idx); db[idx] != kpkUnknown {
+				changed = true
+			}
+		}
+	}
+
+	for idx, result := range db {
+		if result == kpkWin {
+			kpkBitbase[idx/64] |= 1 << (idx % 64)
+		}
+	}
+}
+
+// White wins if any move wins, black draws if any move draws
+func kpkClassify(db []uint8, idx int) uint8 {
+	blackToMove := idx&1 == 1
+	bk, wk := idx>>1&63, idx>>7&63
+	pawn := idx>>13&3 + 8*(6-idx>>15)
+
+	var r uint8
+	if blackToMove {
+		for b := kpkKingAttacks(bk); b != 0; b &= b - 1 {
+			r |= db[kpkIndex(false, bits.TrailingZeros64(b), wk, pawn)]
+		}
+		switch {
+		case r&kpkDraw != 0:
+			return kpkDraw
+		case r&kpkUnknown != 0:
+			return kpkUnknown
+		default:
+			return kpkWin
+		}
+	}
+
+	for b := kpkKingAttacks(wk); b != 0; b &= b - 1 {
+		r |= db[kpkIndex(true, bk, bits.TrailingZeros64(b), pawn)]
+	}
+	if pawn>>3 < 6 {
+		r |= db[kpkIndex(true, bk, wk, pawn+8)]
+	}
+	if pawn>>3 == 1 && pawn+8 != wk && pawn+8 != bk {
+		r |= db[kpkIndex(true, bk, wk, pawn+16)]
+	}
+	switch {
+	case r&kpkWin != 0:
+		return kpkWin
+	case r&kpkUnknown != 0:
+		return kpkUnknown
+	default:
+		return kpkDraw
+	}
+}
+
+// Reports whether white wins, given squares in Board indexes
+func kpkProbe(blackToMove bool, wk, pawn, bk int) bool {
+	wk, pawn, bk = wk^7, pawn^7, bk^7
+	if pawn&7 > 3 {
+		wk, pawn, bk = wk^7, pawn^7, bk^7
+	}
+	idx := kpkIndex(blackToMove, bk, wk, pawn)
+	return kpkBitbase[idx/64]&(1<<(idx%64)) != 0
+}
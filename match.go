@@ -0,0 +1,311 @@
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidTimeControl = errors.New("Invalid time control")
+
+// Anything which can choose moves in a match, such as an EnginePlayer or a UCIEngine
+type Player interface {
+	Name() string
+	NewGame() error
+	Go(b *Board, l SearchLimits) (SearchResult, error)
+}
+
+// Plays moves with an Engine, clearing its tables before each game
+type EnginePlayer struct {
+	Label  string
+	Engine *Engine
+	Limits SearchLimits // depth and node limits for this player only, used if they're tighter than the match's
+}
+
+func (p *EnginePlayer) Name() string {
+	return p.Label
+}
+
+func (p *EnginePlayer) NewGame() error {
+	p.Engine.TT.Clear()
+	p.Engine.PT.Clear()
+	return nil
+}
+
+func (p *EnginePlayer) Go(b *Board, l SearchLimits) (SearchResult, error) {
+	e := p.Engine
+	e.B = b.Copy()
+	if e.Evaluator != nil {
+		e.SetEvaluator(e.Evaluator) // attaches an incremental evaluator to the new board
+	}
+
+	if p.Limits.Depth > 0 && (l.Depth == 0 || p.Limits.Depth < l.Depth) {
+		l.Depth = p.Limits.Depth
+	}
+	if p.Limits.Nodes > 0 && (l.Nodes == 0 || p.Limits.Nodes < l.Nodes) {
+		l.Nodes = p.Limits.Nodes
+	}
+	return e.SearchWith(l), nil
+}
+
+// Time for the whole game, with an increment added after every move
+type TimeControl struct {
+	Base      time.Duration
+	Increment time.Duration
+}
+
+// Parses a time control in seconds, as in the PGN TimeControl tag, e.g. 60+0.5 or 300
+func ParseTimeControl(s string) (TimeControl, error) {
+	base, inc, _ := strings.Cut(s, "+")
+	if inc == "" {
+		inc = "0"
+	}
+
+	b, err1 := strconv.ParseFloat(base, 64)
+	i, err2 := strconv.ParseFloat(inc, 64)
+	if err1 != nil || err2 != nil || b <= 0 || i < 0 {
+		return TimeControl{}, fmt.Errorf("%w: %q", ErrInvalidTimeControl, s)
+	}
+	return TimeControl{
+		Base:      time.Duration(b * float64(time.Second)),
+		Increment: time.Duration(i * float64(time.Second)),
+	}, nil
+}
+
+func (tc TimeControl) String() string {
+	s := strconv.FormatFloat(tc.Base.Seconds(), 'f', -1, 64)
+	if tc.Increment > 0 {
+		s += "+" + strconv.FormatFloat(tc.Increment.Seconds(), 'f', -1, 64)
+	}
+	return s
+}
+
+type MatchOptions struct {
+	Games    int     // rounded up to an even number, so each opening is played with both colours
+	SPRT     *SPRT   // stops the match as soon as the test finishes, Games can then be 0 for no limit
+	Openings []Board // used in turn, the standard starting position if empty
+
+	TimeControl TimeControl  // the zero value means there's no clock
+	Limits      SearchLimits // applied to every move, on top of the clock
+
+	// Adjudication, each is turned off by a zero move count
+	DrawMoveNumber  int // draws aren't adjudicated before this move
+	DrawMoveCount   int // moves in a row both sides' scores must be within DrawScore of 0
+	DrawScore       int
+	ResignMoveCount int // moves in a row a side's score must be at or below -ResignScore for it to resign
+	ResignScore     int
+	MaxMoves        int // the game is drawn once this many moves have been played
+
+	Event    string
+	PGN      io.Writer            // every game is written here as it finishes, can be nil
+	Progress func(r *MatchResult) // called after every game, can be nil
+}
+
+type MatchGame struct {
+	Opening      int  // index into the openings
+	FirstIsWhite bool // whether the first player had white
+	Result       string
+	Reason       string // how the game ended, e.g. checkmate or "black loses on time"
+	PGN          *PGNGame
+}
+
+type MatchResult struct {
+	Players             [2]string
+	Wins, Draws, Losses int // from the first player's point of view
+	Games               []MatchGame
+	SPRT                SPRTDecision // updated after every pair of games when MatchOptions.SPRT is set
+}
+
+// Returns the first player's score, as a fraction of the points available
+func (r *MatchResult) Score() float64 {
+	n := r.Wins + r.Draws + r.Losses
+	if n == 0 {
+		return 0.5
+	}
+	return (float64(r.Wins) + float64(r.Draws)/2) / float64(n)
+}
+
+// Returns the Elo difference expected to give score, a fraction of the points available
+func EloFromScore(score float64) float64 {
+	return 400 * math.Log10(score/(1-score))
+}
+
+// Returns the first player's Elo advantage, and the margin of its 95% confidence interval
+func (r *MatchResult) Elo() (elo, margin float64) {
+	n := float64(r.Wins + r.Draws + r.Losses)
+	if n == 0 {
+		return 0, 0
+	}
+
+	s := r.Score()
+	if s == 0 || s == 1 {
+		return EloFromScore(s), math.Inf(1) // no losses or no wins, so there's no telling how big the difference is
+	}
+	variance := (float64(r.Wins)*math.Pow(1-s, 2) + float64(r.Draws)*math.Pow(0.5-s, 2) + float64(r.Losses)*math.Pow(s, 2)) / n
+	deviation := 1.959964 * math.Sqrt(variance/n)
+	if s-deviation <= 0 || s+deviation >= 1 {
+		return EloFromScore(s), math.Inf(1) // too few games for the interval to fit between losing and winning them all
+	}
+	return EloFromScore(s), (EloFromScore(s+deviation) - EloFromScore(s-deviation)) / 2
+}
+
+func (r *MatchResult) add(g MatchGame) {
+	r.Games = append(r.Games, g)
+	switch {
+	case g.Result == "1/2-1/2":
+		r.Draws++
+	case (g.Result == "1-0") == g.FirstIsWhite:
+		r.Wins++
+	default:
+		r.Losses++
+	}
+}
+
+// Plays a match between two players, alternating colours
+// An error means a player couldn't start a game, the games finished so far are still returned
+func RunMatch(players [2]Player, opts MatchOptions) (*MatchResult, error) {
+	openings := opts.Openings
+	if len(openings) == 0 {
+		openings = []Board{NewBoard()}
+	}
+
+	r := &MatchResult{Players: [2]string{players[0].Name(), players[1].Name()}}
+	for i := 0; i < opts.Games || i%2 == 1 || opts.Games == 0 && opts.SPRT != nil; i++ {
+		first := i%2 == 0
+		white, black := players[0], players[1]
+		if !first {
+			white, black = black, white
+		}
+
+		for _, p := range []Player{white, black} {
+			if err := p.NewGame(); err != nil {
+				return r, fmt.Errorf("%s: %w", p.Name(), err)
+			}
+		}
+
+		opening := (i / 2) % len(openings)
+		g := playMatchGame(white, black, openings[opening], &opts)
+		g.Opening = opening
+		g.FirstIsWhite = first
+		g.PGN.Tags["Round"] = strconv.Itoa(i + 1)
+		r.add(g)
+
+		if opts.PGN != nil {
+			if err := g.PGN.Write(opts.PGN); err != nil {
+				return r, err
+			}
+		}
+		if opts.SPRT != nil && i%2 == 1 {
+			r.SPRT = opts.SPRT.Decide(r.Pentanomial())
+		}
+		if opts.Progress != nil {
+			opts.Progress(r)
+		}
+		if r.SPRT != SPRTContinue {
+			break
+		}
+	}
+	return r, nil
+}
+
+// Plays a single game, returning it with everything but the opening details filled in
+func playMatchGame(white, black Player, opening Board, opts *MatchOptions) MatchGame {
+	b := opening.Copy()
+	b.SetObserver(nil)
+	date := time.Now()
+
+	clocks := [2]time.Duration{opts.TimeControl.Base, opts.TimeControl.Base}
+	timed := opts.TimeControl.Base > 0
+	var drawStreak int
+	var resignStreaks [2]int
+
+	names := [2]string{"white", "black"}
+	players := [2]Player{white, black}
+	plies := 0
+
+	result, reason := b.Result()
+	termination := "normal"
+	for result == "*" {
+		side := 0
+		if b.Turn == BlackTurn {
+			side = 1
+		}
+
+		l := opts.Limits
+		if timed {
+			l.WhiteTime, l.BlackTime = clocks[0], clocks[1]
+			l.WhiteInc, l.BlackInc = opts.TimeControl.Increment, opts.TimeControl.Increment
+		}
+
+		start := time.Now()
+		sr, err := players[side].Go(&b, l)
+		elapsed := time.Since(start)
+
+		loss := "1-0"
+		if side == 0 {
+			loss = "0-1"
+		}
+		switch {
+		case errors.Is(err, ErrUCITimeout) || timed && elapsed > clocks[side]:
+			result, reason, termination = loss, names[side]+" loses on time", "time forfeit"
+			continue
+		case err != nil:
+			result, reason, termination = loss, names[side]+": "+err.Error(), "rules infraction"
+			continue
+		}
+
+		clocks[side] += opts.TimeControl.Increment - elapsed
+		b.Move(sr.Move)
+		plies++
+
+		if result, reason = b.Result(); result != "*" {
+			break
+		}
+
+		// Book and tablebase moves don't have a meaningful score
+		if sr.Depth == 0 {
+			drawStreak, resignStreaks = 0, [2]int{}
+			continue
+		}
+
+		if abs(sr.Score) <= opts.DrawScore {
+			drawStreak++
+		} else {
+			drawStreak = 0
+		}
+		if sr.Score <= -opts.ResignScore {
+			resignStreaks[side]++
+		} else {
+			resignStreaks[side] = 0
+		}
+
+		switch {
+		case opts.ResignMoveCount > 0 && resignStreaks[side] >= opts.ResignMoveCount:
+			result, reason, termination = loss, names[side]+" resigns", "adjudication"
+		case opts.DrawMoveCount > 0 && drawStreak >= 2*opts.DrawMoveCount && b.HalfMoves/2+1 >= opts.DrawMoveNumber:
+			result, reason, termination = "1/2-1/2", "draw adjudicated", "adjudication"
+		case opts.MaxMoves > 0 && plies >= 2*opts.MaxMoves:
+			result, reason, termination = "1/2-1/2", "move limit", "adjudication"
+		}
+	}
+
+	pgn := NewPGNGame(&b)
+	pgn.Result = result
+	if opts.Event != "" {
+		pgn.Tags["Event"] = opts.Event
+	}
+	pgn.Tags["Date"] = date.Format("2006.01.02")
+	pgn.Tags["White"] = white.Name()
+	pgn.Tags["Black"] = black.Name()
+	pgn.Tags["Termination"] = termination
+	pgn.Tags["TimeControl"] = "-"
+	if timed {
+		pgn.Tags["TimeControl"] = opts.TimeControl.String()
+	}
+
+	return MatchGame{Result: result, Reason: reason, PGN: pgn}
+}
@@ -0,0 +1,145 @@
+package chess_test
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	tc, err := chess.ParseTimeControl("60+0.5")
+	require.NoError(t, err)
+	assert.Equal(t, chess.TimeControl{Base: time.Minute, Increment: 500 * time.Millisecond}, tc)
+	assert.Equal(t, "60+0.5", tc.String())
+
+	tc, err = chess.ParseTimeControl("300")
+	require.NoError(t, err)
+	assert.Equal(t, chess.TimeControl{Base: 5 * time.Minute}, tc)
+	assert.Equal(t, "300", tc.String())
+
+	for _, s := range []string{"", "0+1", "a+1", "10+-1"} {
+		_, err = chess.ParseTimeControl(s)
+		assert.ErrorIs(t, err, chess.ErrInvalidTimeControl, s)
+	}
+}
+
+func TestMatchElo(t *testing.T) {
+	r := chess.MatchResult{Wins: 30, Draws: 40, Losses: 30}
+	elo, margin := r.Elo()
+	assert.InDelta(t, 0, elo, 1e-9)
+	assert.InDelta(t, 53.2, margin, 0.1)
+
+	r = chess.MatchResult{Wins: 60, Draws: 20, Losses: 20}
+	elo, margin = r.Elo()
+	assert.InDelta(t, 147.2, elo, 0.1)
+	assert.Greater(t, margin, 0.0)
+
+	// More games, smaller margin
+	r = chess.MatchResult{Wins: 600, Draws: 200, Losses: 200}
+	_, smaller := r.Elo()
+	assert.Less(t, smaller, margin)
+
+	r = chess.MatchResult{Wins: 9, Losses: 1}
+	elo, margin = r.Elo()
+	assert.InDelta(t, 381.7, elo, 0.1)
+	assert.True(t, math.IsInf(margin, 1))
+
+	r = chess.MatchResult{Wins: 2}
+	elo, margin = r.Elo()
+	assert.True(t, math.IsInf(elo, 1))
+	assert.True(t, math.IsInf(margin, 1))
+}
+
+func newEnginePlayer(name string, depth int) *chess.EnginePlayer {
+	return &chess.EnginePlayer{
+		Label:  name,
+		Engine: &chess.Engine{TT: *chess.NewTranspositionTable(12), EP: chess.DefaultParams},
+		Limits: chess.SearchLimits{Depth: depth},
+	}
+}
+
+func TestRunMatch(t *testing.T) {
+	fake := startFakeUCIEngine(t, &fakeUCIEngine{})
+	defer fake.Close()
+
+	opening := chess.NewBoard()
+	playMoves(t, &opening, "e4 e5")
+
+	var pgn bytes.Buffer
+	progress := 0
+	r, err := chess.RunMatch([2]chess.Player{newEnginePlayer("engine", 1), fake}, chess.MatchOptions{
+		Games:           3,
+		Openings:        []chess.Board{chess.NewBoard(), opening},
+		ResignMoveCount: 3,
+		ResignScore:     500,
+		Event:           "Test",
+		PGN:             &pgn,
+		Progress:        func(*chess.MatchResult) { progress++ },
+	})
+	require.NoError(t, err)
+
+	// Rounded up to 4 games, and the fake resigns every one
+	assert.Equal(t, 4, progress)
+	assert.Equal(t, [2]string{"engine", "Fake 1.0"}, r.Players)
+	assert.Equal(t, 4, r.Wins)
+	require.Len(t, r.Games, 4)
+	for i, g := range r.Games {
+		assert.Equal(t, i/2, g.Opening)
+		assert.Equal(t, i%2 == 0, g.FirstIsWhite)
+		assert.Equal(t, "adjudication", g.PGN.Tags["Termination"])
+	}
+	assert.Equal(t, "black resigns", r.Games[0].Reason)
+	assert.Equal(t, "white resigns", r.Games[1].Reason)
+
+	pr := chess.NewPGNReader(&pgn)
+	for i := range 4 {
+		g, err := pr.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "Test", g.Tags["Event"])
+		assert.Equal(t, r.Games[i].Result, g.Result)
+		if i >= 2 {
+			assert.Equal(t, []string{"e4", "e5"}, g.Moves[:2])
+		}
+	}
+	_, err = pr.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestRunMatchAdjudication(t *testing.T) {
+	players := [2]chess.Player{newEnginePlayer("a", 1), newEnginePlayer("b", 1)}
+
+	r, err := chess.RunMatch(players, chess.MatchOptions{Games: 2, MaxMoves: 5})
+	require.NoError(t, err)
+	for _, g := range r.Games {
+		assert.Equal(t, "move limit", g.Reason)
+		assert.Len(t, g.PGN.Moves, 10)
+	}
+
+	// Both evaluate the starting position the same, so it's adjudicated straight away
+	r, err = chess.RunMatch(players, chess.MatchOptions{Games: 2, DrawMoveCount: 1, DrawScore: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.Draws)
+	assert.Len(t, r.Games[0].PGN.Moves, 2)
+}
+
+func TestRunMatchTimeForfeit(t *testing.T) {
+	fake := startFakeUCIEngine(t, &fakeUCIEngine{silent: true})
+	fake.Timeout = 10 * time.Millisecond
+
+	r, err := chess.RunMatch([2]chess.Player{newEnginePlayer("engine", 1), fake}, chess.MatchOptions{
+		Games:       1,
+		TimeControl: chess.TimeControl{Base: 50 * time.Millisecond},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "black loses on time", r.Games[0].Reason)
+	assert.Equal(t, "time forfeit", r.Games[0].PGN.Tags["Termination"])
+	assert.Equal(t, "0.05", r.Games[0].PGN.Tags["TimeControl"])
+	assert.Equal(t, "white loses on time", r.Games[1].Reason)
+	assert.Equal(t, 2, r.Wins)
+}
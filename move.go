@@ -7,11 +7,12 @@ import (
 
 var (
 	ErrInvalidAlgebraicNotation = errors.New("Invalid algebraic notation")
+	ErrInvalidPromotion         = errors.New("Invalid promotion symbol")
 )
 
 func ParseAlgebraicMove(s string) (from int, to int, p Promotion, err error) {
 	if len(s) != 4 && len(s) != 5 {
-		panic("Invalid algebraic move")
+		return 0, 0, NoPromotion, ErrInvalidAlgebraicNotation
 	}
 
 	from, err = IndexFromAlgebraic(s[0:2])
@@ -26,7 +27,10 @@ func ParseAlgebraicMove(s string) (from int, to int, p Promotion, err error) {
 
 	p = NoPromotion
 	if len(s) == 5 {
-		p = PromotionFromSymbol(rune(s[4]))
+		p, err = PromotionFromSymbol(rune(s[4]))
+		if err != nil || p == NoPromotion {
+			return 0, 0, NoPromotion, ErrInvalidAlgebraicNotation
+		}
 	}
 
 	return from, to, p, nil
@@ -81,6 +85,11 @@ func IndexFromAlgebraic(a string) (int, error) {
 	return i, nil
 }
 
+// Inverse of IndexFromAlgebraic, e.g. 3 -> "e1"
+func AlgebraicFromIndex(i int) string {
+	return string([]byte{"hgfedcba"[i%8], "12345678"[i/8]})
+}
+
 // --- Move Representation ---
 // Bits Overview (inclusive)
 // 0-2   - Piece type
@@ -347,20 +356,20 @@ const (
 	QueenPromotion  Promotion = 0b00000000000000011100000000000000
 )
 
-func PromotionFromSymbol(r rune) Promotion {
+func PromotionFromSymbol(r rune) (Promotion, error) {
 	switch r {
 	case ' ':
-		return NoPromotion
+		return NoPromotion, nil
 	case 'r', 'R':
-		return RookPromotion
+		return RookPromotion, nil
 	case 'n', 'N':
-		return KnightPromotion
+		return KnightPromotion, nil
 	case 'b', 'B':
-		return BishopPromotion
+		return BishopPromotion, nil
 	case 'q', 'Q':
-		return QueenPromotion
+		return QueenPromotion, nil
 	default:
-		panic("invalid promotion symbol")
+		return NoPromotion, ErrInvalidPromotion
 	}
 }
 
@@ -483,6 +492,25 @@ func CastleRightsFromString(s string) CastleRights {
 	return cr
 }
 
+// Returns the rights in FEN form, e.g. KQkq, or - if there are none
+func (cr CastleRights) String() string {
+	var s strings.Builder
+	for _, r := range []struct {
+		can    bool
+		symbol byte
+	}{
+		{cr.CanWhiteKing(), 'K'}, {cr.CanWhiteQueen(), 'Q'}, {cr.CanBlackKing(), 'k'}, {cr.CanBlackQueen(), 'q'},
+	} {
+		if r.can {
+			s.WriteByte(r.symbol)
+		}
+	}
+	if s.Len() == 0 {
+		return "-"
+	}
+	return s.String()
+}
+
 func (cr CastleRights) Uint64() uint64 {
 	return uint64(cr >> 6)
 }
@@ -0,0 +1,264 @@
+package chess
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+var ErrInvalidNetwork = errors.New("Invalid network")
+
+// Network file format, everything is little endian
+//
+//	magic          4 bytes  "CNNU"
+//	version        uint32   1
+//	hidden         uint32   N, the size of each accumulator
+//	featureWeights int16    768*N, all N weights of feature 0, then feature 1...
+//	featureBiases  int16    N
+//	outputWeights  int16    2*N, the side to move's accumulator first
+//	outputBias     int16
+//
+// Feature weights and biases are quantised by nnueQA, output weights by nnueQB, and the output bias by nnueQA*nnueQB
+// Features are indexed colour*384 + piece*64 + square, from the point of view of each side
+// colour is 0 for the side's own pieces, piece is pawn, knight, bishop, rook, queen, king, and square is a1=0 ... h8=63,
+// flipped vertically for black so both perspectives see their own pieces from the bottom of the board
+// The output is (sum of clamp(accumulator, 0, nnueQA) * outputWeights + outputBias) * nnueScale / (nnueQA * nnueQB)
+const (
+	nnueMagic    = "CNNU"
+	nnueVersion  = 1
+	nnueFeatures = 768
+	nnueQA       = 255
+	nnueQB       = 64
+	nnueScale    = 400
+)
+
+// A (768 -> N)x2 -> 1 network
+type Network struct {
+	Hidden         int
+	FeatureWeights []int16
+	FeatureBiases  []int16
+	OutputWeights  []int16
+	OutputBias     int16
+}
+
+// Reads a network in the format described above
+func LoadNetwork(r io.Reader) (*Network, error) {
+	var header struct {
+		Magic   [4]byte
+		Version uint32
+		Hidden  uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidNetwork, err)
+	}
+	if string(header.Magic[:]) != nnueMagic {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrInvalidNetwork, header.Magic[:])
+	}
+	if header.Version != nnueVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidNetwork, header.Version)
+	}
+	if header.Hidden == 0 || header.Hidden > 1<<16 {
+		return nil, fmt.Errorf("%w: hidden layer size %d", ErrInvalidNetwork, header.Hidden)
+	}
+
+	hidden := int(header.Hidden)
+	n := &Network{
+		Hidden:         hidden,
+		FeatureWeights: make([]int16, nnueFeatures*hidden),
+		FeatureBiases:  make([]int16, hidden),
+		OutputWeights:  make([]int16, 2*hidden),
+	}
+	for _, data := range []any{n.FeatureWeights, n.FeatureBiases, n.OutputWeights, &n.OutputBias} {
+		if err := binary.Read(r, binary.LittleEndian, data); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidNetwork, err)
+		}
+	}
+
+	// Trailing data probably means the file is for a different architecture
+	if _, err := io.ReadFull(r, make([]byte, 1)); err != io.EOF {
+		return nil, fmt.Errorf("%w: unexpected data after the output bias", ErrInvalidNetwork)
+	}
+
+	return n, nil
+}
+
+// Writes the network in the format LoadNetwork reads
+func (n *Network) Write(w io.Writer) error {
+	if len(n.FeatureWeights) != nnueFeatures*n.Hidden || len(n.FeatureBiases) != n.Hidden || len(n.OutputWeights) != 2*n.Hidden {
+		return fmt.Errorf("%w: layer sizes don't match hidden size %d", ErrInvalidNetwork, n.Hidden)
+	}
+
+	header := struct {
+		Magic   [4]byte
+		Version uint32
+		Hidden  uint32
+	}{[4]byte([]byte(nnueMagic)), nnueVersion, uint32(n.Hidden)}
+
+	for _, data := range []any{header, n.FeatureWeights, n.FeatureBiases, n.OutputWeights, n.OutputBias} {
+		if err := binary.Write(w, binary.LittleEndian, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Indexes of the accumulator values
+const (
+	whitePerspective = 0
+	blackPerspective = 1
+)
+
+// Accumulated hidden layer values from each side's perspective, along with the position they were calculated for
+type accumulator struct {
+	pieces [12]uint64
+	values [2][]int16 // white's perspective, then black's
+}
+
+// Evaluates positions with a Network, keeping accumulators up to date as moves are made and unmade
+// The accumulators store the position they were calculated for, and are brought up to date by only adding and removing
+// the features which changed, so they are never wrong, just slower if the board changes without NNUE being told
+type NNUE struct {
+	Net   *Network
+	board *Board
+	stack []accumulator
+}
+
+func NewNNUE(net *Network) *NNUE {
+	return &NNUE{Net: net}
+}
+
+// Tracks moves made on b, so evaluating it only needs the features which changed since the last move
+// An NNUE can only track one board at a time
+func (n *NNUE) Attach(b *Board) {
+	if n.board != nil && n.board.observer == n {
+		n.board.SetObserver(nil)
+	}
+	n.board = b
+	b.SetObserver(n)
+	n.stack = n.stack[:0]
+}
+
+func (b *Board) pieceBoards() [12]uint64 {
+	return [12]uint64{
+		b.whitePawns, b.whiteKnights, b.whiteBishops, b.whiteRooks, b.whiteQueens, b.whiteKings,
+		b.blackPawns, b.blackKnights, b.blackBishops, b.blackRooks, b.blackQueens, b.blackKings,
+	}
+}
+
+// Returns the feature indexes of piece (as ordered by pieceBoards) on square i, from white's and black's perspective
+func nnueFeature(piece, i int) (white, black int) {
+	colour, pieceType := piece/6, piece%6
+	sq := i ^ 7 // a1 = 0
+	white = colour*384 + pieceType*64 + sq
+	black = (1-colour)*384 + pieceType*64 + (sq ^ 56)
+	return white, black
+}
+
+// Adds (or subtracts, if sign is -1) the weights of every piece in changed
+func (n *Network) applyFeatures(acc *accumulator, changed [12]uint64, sign int16) {
+	h := n.Hidden
+	for piece, bb := range changed {
+		for ; bb != 0; bb &= bb - 1 {
+			white, black := nnueFeature(piece, bits.TrailingZeros64(bb))
+			ww, bw := n.FeatureWeights[white*h:(white+1)*h], n.FeatureWeights[black*h:(black+1)*h]
+			wv, bv := acc.values[whitePerspective], acc.values[blackPerspective]
+			for j := range h {
+				wv[j] += sign * ww[j]
+				bv[j] += sign * bw[j]
+			}
+		}
+	}
+}
+
+// Brings acc in line with the given pieces
+func (n *Network) update(acc *accumulator, pieces [12]uint64) {
+	var added, removed [12]uint64
+	for p := range pieces {
+		added[p] = pieces[p] &^ acc.pieces[p]
+		removed[p] = acc.pieces[p] &^ pieces[p]
+	}
+	n.applyFeatures(acc, removed, -1)
+	n.applyFeatures(acc, added, 1)
+	acc.pieces = pieces
+}
+
+// Makes acc an empty board, ready for update
+func (n *Network) reset(acc *accumulator) {
+	for p := range acc.values {
+		if len(acc.values[p]) != n.Hidden {
+			acc.values[p] = make([]int16, n.Hidden)
+		}
+		copy(acc.values[p], n.FeatureBiases)
+	}
+	acc.pieces = [12]uint64{}
+}
+
+// Pushes a copy of the top accumulator, reusing old storage where possible
+func (n *NNUE) push() *accumulator {
+	if len(n.stack) == cap(n.stack) {
+		n.stack = append(n.stack, accumulator{})
+	} else {
+		n.stack = n.stack[:len(n.stack)+1]
+	}
+
+	top := &n.stack[len(n.stack)-1]
+	if len(n.stack) == 1 {
+		n.Net.reset(top)
+		return top
+	}
+
+	prev := &n.stack[len(n.stack)-2]
+	for p := range top.values {
+		if len(top.values[p]) != n.Net.Hidden {
+			top.values[p] = make([]int16, n.Net.Hidden)
+		}
+		copy(top.values[p], prev.values[p])
+	}
+	top.pieces = prev.pieces
+	return top
+}
+
+func (n *NNUE) Moved(b *Board, m Move) {
+	if b != n.board || len(n.stack) == 0 {
+		return
+	}
+	n.Net.update(n.push(), b.pieceBoards())
+}
+
+func (n *NNUE) Unmoved(b *Board, m Move) {
+	if b != n.board || len(n.stack) == 0 {
+		return
+	}
+	n.stack = n.stack[:len(n.stack)-1]
+}
+
+// Returns the network's score from the side to move's point of view
+func (n *NNUE) Evaluate(b *Board) int {
+	var acc *accumulator
+	if b == n.board {
+		if len(n.stack) == 0 {
+			n.push()
+		}
+		acc = &n.stack[len(n.stack)-1]
+	} else {
+		acc = &accumulator{}
+		n.Net.reset(acc)
+	}
+	n.Net.update(acc, b.pieceBoards())
+
+	us, them := acc.values[whitePerspective], acc.values[blackPerspective]
+	if b.Turn == BlackTurn {
+		us, them = them, us
+	}
+
+	h := n.Net.Hidden
+	sum := 0
+	for j := range h {
+		sum += int(min(max(us[j], 0), nnueQA)) * int(n.Net.OutputWeights[j])
+		sum += int(min(max(them[j], 0), nnueQA)) * int(n.Net.OutputWeights[h+j])
+	}
+
+	return (sum + int(n.Net.OutputBias)) * nnueScale / (nnueQA * nnueQB)
+}
@@ -0,0 +1,130 @@
+package chess_test
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func randomNetwork(hidden int, seed uint64) *chess.Network {
+	r := rand.New(rand.NewPCG(seed, seed))
+	n := &chess.Network{
+		Hidden:         hidden,
+		FeatureWeights: make([]int16, 768*hidden),
+		FeatureBiases:  make([]int16, hidden),
+		OutputWeights:  make([]int16, 2*hidden),
+		OutputBias:     int16(r.IntN(2000) - 1000),
+	}
+	for i := range n.FeatureWeights {
+		n.FeatureWeights[i] = int16(r.IntN(41) - 20)
+	}
+	for i := range n.FeatureBiases {
+		n.FeatureBiases[i] = int16(r.IntN(101) - 50)
+	}
+	for i := range n.OutputWeights {
+		n.OutputWeights[i] = int16(r.IntN(129) - 64)
+	}
+	return n
+}
+
+// A network with a single neuron counting each side's own pawns
+func TestNNUEPawnCounter(t *testing.T) {
+	n := &chess.Network{
+		Hidden:         1,
+		FeatureWeights: make([]int16, 768),
+		FeatureBiases:  []int16{0},
+		OutputWeights:  []int16{64, -64},
+	}
+	for sq := 8; sq < 56; sq++ {
+		n.FeatureWeights[sq] = 10 // own pawns
+	}
+
+	b, err := chess.BoardFromFEN("4k3/ppp5/8/8/8/8/PPPP4/4K3 w - - 0 1")
+	require.NoError(t, err)
+	assert.Equal(t, 10*64*400/(255*64), chess.NewNNUE(n).Evaluate(&b))
+
+	b, err = chess.BoardFromFEN("4k3/ppp5/8/8/8/8/PPPP4/4K3 b - - 0 1")
+	require.NoError(t, err)
+	assert.Equal(t, -10*64*400/(255*64), chess.NewNNUE(n).Evaluate(&b))
+}
+
+// Incrementally updated accumulators must always match evaluating from scratch
+func TestNNUEIncremental(t *testing.T) {
+	net := randomNetwork(16, 1)
+	r := rand.New(rand.NewPCG(2, 2))
+
+	for _, fen := range evalFENs {
+		b, err := chess.BoardFromFEN(fen)
+		require.NoError(t, err)
+
+		n := chess.NewNNUE(net)
+		n.Attach(&b)
+
+		played := 0
+		for range 200 {
+			ms, _ := b.LegalMoves()
+			if len(ms) == 0 || (played > 0 && r.IntN(3) == 0) {
+				if played == 0 {
+					break
+				}
+				b.Unmove()
+				played--
+			} else {
+				b.Move(ms[r.IntN(len(ms))])
+				played++
+			}
+
+			fresh := b.Copy()
+			require.Equal(t, chess.NewNNUE(net).Evaluate(&fresh), n.Evaluate(&b), fen)
+		}
+
+		// A copy made with plain assignment shares the observer, but mustn't disturb the original
+		c := b
+		c.Moves = append([]chess.Move{}, b.Moves...)
+		if ms, _ := c.LegalMoves(); len(ms) != 0 {
+			c.Move(ms[0])
+		}
+		fresh := b.Copy()
+		assert.Equal(t, chess.NewNNUE(net).Evaluate(&fresh), n.Evaluate(&b), fen)
+	}
+}
+
+func TestLoadNetwork(t *testing.T) {
+	net := randomNetwork(8, 3)
+
+	var buf bytes.Buffer
+	require.NoError(t, net.Write(&buf))
+	data := buf.Bytes()
+	assert.Len(t, data, 12+2*(768*8+8+16+1))
+
+	got, err := chess.LoadNetwork(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, net, got)
+
+	bad := map[string][]byte{
+		"empty":     {},
+		"magic":     append([]byte("XNNU"), data[4:]...),
+		"version":   append(append([]byte("CNNU"), 2, 0, 0, 0), data[8:]...),
+		"truncated": data[:len(data)-1],
+		"trailing":  append(append([]byte{}, data...), 0),
+	}
+	for name, data := range bad {
+		_, err := chess.LoadNetwork(bytes.NewReader(data))
+		assert.ErrorIs(t, err, chess.ErrInvalidNetwork, name)
+	}
+}
+
+func TestEngineEvaluator(t *testing.T) {
+	net := randomNetwork(8, 4)
+	e := chess.Engine{B: chess.NewBoard(), EP: chess.DefaultParams}
+	classic := e.Evaluate()
+
+	n := chess.NewNNUE(net)
+	e.SetEvaluator(n)
+	assert.Equal(t, n.Evaluate(&e.B), e.Evaluate())
+	assert.NotEqual(t, classic, e.Evaluate())
+}
@@ -0,0 +1,220 @@
+package chess
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidParams = errors.New("Invalid EvalParams")
+
+// Reads parameters from a .json, .yaml or .yml file, keyed by the Go field names
+// Fields missing from the file keep their DefaultParams value, so a file only needs the weights it changes
+func LoadParams(path string) (EvalParams, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return EvalParams{}, err
+	}
+
+	ep := DefaultParams
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &ep)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &ep)
+	default:
+		return EvalParams{}, fmt.Errorf("%w: unknown file extension %q", ErrInvalidParams, filepath.Ext(path))
+	}
+	if err != nil {
+		return EvalParams{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return ep, nil
+}
+
+// Writes the parameters to a .json, .yaml or .yml file
+func (ep EvalParams) Save(path string) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err = ep.MarshalJSON() // json.Marshal would compact it
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(ep)
+	default:
+		return fmt.Errorf("%w: unknown file extension %q", ErrInvalidParams, filepath.Ext(path))
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Writes one field per line, with each row of a table on a single line so the files stay readable
+func (ep EvalParams) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	v := reflect.ValueOf(ep)
+
+	var writeValue func(v reflect.Value, indent string)
+	writeValue = func(v reflect.Value, indent string) {
+		if v.Kind() == reflect.Int {
+			buf.WriteString(strconv.FormatInt(v.Int(), 10))
+			return
+		}
+
+		nested := v.Type().Elem().Kind() == reflect.Array
+		buf.WriteString("[")
+		for i := range v.Len() {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if nested {
+				buf.WriteString("\n" + indent + "  ")
+			}
+			writeValue(v.Index(i), indent+"  ")
+		}
+		if nested {
+			buf.WriteString("\n" + indent)
+		}
+		buf.WriteString("]")
+	}
+
+	buf.WriteString("{")
+	for i := range v.NumField() {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  " + strconv.Quote(v.Type().Field(i).Name) + ": ")
+		writeValue(v.Field(i), "  ")
+	}
+	buf.WriteString("\n}\n")
+
+	return buf.Bytes(), nil
+}
+
+func (ep *EvalParams) UnmarshalJSON(data []byte) error {
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidParams, err)
+	}
+	return ep.setFields(fields)
+}
+
+// Same layout as the JSON, rows of tables are written in flow style
+func (ep EvalParams) MarshalYAML() (any, error) {
+	var toNode func(v reflect.Value) *yaml.Node
+	toNode = func(v reflect.Value) *yaml.Node {
+		if v.Kind() == reflect.Int {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(v.Int(), 10)}
+		}
+
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		if v.Type().Elem().Kind() == reflect.Int {
+			n.Style = yaml.FlowStyle
+		}
+		for i := range v.Len() {
+			n.Content = append(n.Content, toNode(v.Index(i)))
+		}
+		return n
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	v := reflect.ValueOf(ep)
+	for i := range v.NumField() {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: v.Type().Field(i).Name}
+		root.Content = append(root.Content, key, toNode(v.Field(i)))
+	}
+	return root, nil
+}
+
+func (ep *EvalParams) UnmarshalYAML(n *yaml.Node) error {
+	var fields map[string]any
+	if err := n.Decode(&fields); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidParams, err)
+	}
+	return ep.setFields(fields)
+}
+
+// Assigns decoded fields, checking every key exists and every table has exactly the right shape
+// ep is left untouched if anything is wrong
+func (ep *EvalParams) setFields(fields map[string]any) error {
+	updated := *ep
+	v := reflect.ValueOf(&updated).Elem()
+
+	for name, value := range fields {
+		f, ok := v.Type().FieldByName(name)
+		if !ok || !f.IsExported() {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidParams, name)
+		}
+		if err := setValue(v.FieldByIndex(f.Index), value, name); err != nil {
+			return err
+		}
+	}
+
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+	*ep = updated
+	return nil
+}
+
+// Checks the values evaluation uses as indexes, which would make it crash if they were negative
+func (ep *EvalParams) Validate() error {
+	for i, w := range ep.KingAttackWeights {
+		if w < 0 {
+			return fmt.Errorf("%w: KingAttackWeights[%d]: expected 0 or more, got %d", ErrInvalidParams, i, w)
+		}
+	}
+	return nil
+}
+
+func setValue(dst reflect.Value, value any, path string) error {
+	if dst.Kind() == reflect.Int {
+		var (
+			n   int64
+			err error
+		)
+		switch value := value.(type) {
+		case int:
+			n = int64(value)
+		case int64:
+			n = value
+		case json.Number:
+			n, err = value.Int64()
+		default:
+			err = errors.New("not an integer")
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s: expected an integer, got %v", ErrInvalidParams, path, value)
+		}
+		dst.SetInt(n)
+		return nil
+	}
+
+	values, ok := value.([]any)
+	if !ok {
+		return fmt.Errorf("%w: %s: expected a list of %d values", ErrInvalidParams, path, dst.Len())
+	}
+	if len(values) != dst.Len() {
+		return fmt.Errorf("%w: %s: expected %d values, got %d", ErrInvalidParams, path, dst.Len(), len(values))
+	}
+	for i, value := range values {
+		if err := setValue(dst.Index(i), value, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package chess_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestParamsRoundTrip(t *testing.T) {
+	ep := chess.DefaultParams
+	ep.PawnWt = [2]int{123, -45}
+	ep.KingVals[chess.EG][63] = 7
+	ep.KingAttackWeights[3] = 9
+
+	for _, name := range []string{"params.json", "params.yaml", "params.yml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, ep.Save(path))
+
+			got, err := chess.LoadParams(path)
+			require.NoError(t, err)
+			assert.Equal(t, ep, got)
+		})
+	}
+}
+
+func TestLoadParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+		check   func(t *testing.T, ep chess.EvalParams)
+	}{
+		{
+			name:    "missing fields keep defaults",
+			file:    "p.yaml",
+			content: "PawnWt: [90, 120]\n",
+			check: func(t *testing.T, ep chess.EvalParams) {
+				want := chess.DefaultParams
+				want.PawnWt = [2]int{90, 120}
+				assert.Equal(t, want, ep)
+			},
+		},
+		{
+			name:    "json",
+			file:    "p.json",
+			content: `{"BishopPair": [1, 2], "PassedPawn": [[0,1,2,3,4,5,6,7],[0,0,0,0,0,0,0,0]]}`,
+			check: func(t *testing.T, ep chess.EvalParams) {
+				assert.Equal(t, [2]int{1, 2}, ep.BishopPair)
+				assert.Equal(t, [8]int{0, 1, 2, 3, 4, 5, 6, 7}, ep.PassedPawn[chess.MG])
+			},
+		},
+		{name: "unknown field", file: "p.yaml", content: "PawnWeight: [1, 2]\n", wantErr: true},
+		{name: "too few values", file: "p.yaml", content: "PawnWt: [1]\n", wantErr: true},
+		{name: "too many values", file: "p.json", content: `{"PawnWt": [1, 2, 3]}`, wantErr: true},
+		{name: "table too short", file: "p.yaml", content: "PawnVals: [[1, 2], [3, 4]]\n", wantErr: true},
+		{name: "not a list", file: "p.yaml", content: "PawnWt: 100\n", wantErr: true},
+		{name: "not an integer", file: "p.json", content: `{"PawnWt": [1.5, 2]}`, wantErr: true},
+		{name: "string", file: "p.yaml", content: "PawnWt: [a, b]\n", wantErr: true},
+		{name: "negative index", file: "p.json", content: `{"KingAttackWeights": [2, -1, 3, 5]}`, wantErr: true},
+		{name: "bad extension", file: "p.txt", content: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			ep, err := chess.LoadParams(path)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, chess.ErrInvalidParams)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, ep)
+		})
+	}
+}
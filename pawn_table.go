@@ -0,0 +1,48 @@
+package chess
+
+// Cached pawn structure evaluation, which only depends on the pawns so is hit far more often than the transposition table
+type PawnEntry struct {
+	Key         uint64
+	Score       [2]int // middlegame and endgame score from white's point of view
+	WhitePassed uint64
+	BlackPassed uint64
+}
+
+type PawnTable struct {
+	entries []PawnEntry
+	mask    uint64
+}
+
+// Creates a pawn table with 2^exp entries
+func NewPawnTable(exp int) *PawnTable {
+	length := 1 << exp
+	mask := uint64(length - 1)
+	return &PawnTable{
+		entries: make([]PawnEntry, length),
+		mask:    mask,
+	}
+}
+
+// Always misses on a nil or zero value PawnTable
+func (pt *PawnTable) Get(key uint64) (PawnEntry, bool) {
+	if pt == nil || len(pt.entries) == 0 {
+		return PawnEntry{}, false
+	}
+	i := (key & pt.mask)
+	e := pt.entries[i]
+	return e, e.Key == key
+}
+
+// Always overwrites existing entry
+func (pt *PawnTable) Save(e PawnEntry) {
+	if pt == nil || len(pt.entries) == 0 {
+		return
+	}
+	i := (e.Key & pt.mask)
+	pt.entries[i] = e
+}
+
+// Entries must be cleared whenever the EvalParams they were calculated with change
+func (pt *PawnTable) Clear() {
+	clear(pt.entries)
+}
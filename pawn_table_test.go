@@ -0,0 +1,66 @@
+package chess_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestPawnTable(t *testing.T) {
+	e1 := chess.PawnEntry{Key: 0, Score: [2]int{1, 2}}
+	e2 := chess.PawnEntry{Key: 2, Score: [2]int{3, 4}, WhitePassed: 1 << 40}
+
+	pt := chess.NewPawnTable(1) // 2 entries
+
+	pt.Save(e1)
+	got, ok := pt.Get(0)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, e1, got)
+
+	pt.Save(e2)
+	_, ok = pt.Get(0)
+	assert.Equal(t, false, ok)
+
+	got, ok = pt.Get(2)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, e2, got)
+
+	pt.Clear()
+	_, ok = pt.Get(2)
+	assert.Equal(t, false, ok)
+
+	// The zero value is usable, it just never hits
+	var zero chess.PawnTable
+	zero.Save(e2)
+	_, ok = zero.Get(2)
+	assert.Equal(t, false, ok)
+}
+
+// Cached pawn structure must give the same evaluation as recalculating it
+func TestPawnTableEvaluate(t *testing.T) {
+	for _, fen := range evalFENs {
+		b, err := chess.BoardFromFEN(fen)
+		require.NoError(t, err)
+
+		uncached := chess.Engine{B: b, EP: chess.DefaultParams}
+		cached := chess.Engine{B: b, PT: *chess.NewPawnTable(4), EP: chess.DefaultParams}
+
+		want := uncached.Evaluate()
+		assert.Equal(t, want, cached.Evaluate(), fen)
+		assert.Equal(t, want, cached.Evaluate(), fen) // hits this time
+	}
+}
+
+func TestPawnZobrist(t *testing.T) {
+	a, err := chess.BoardFromFEN("4k3/pp6/8/8/8/8/PP6/4K3 w - - 0 1")
+	require.NoError(t, err)
+	b, err := chess.BoardFromFEN("3qk3/pp6/8/8/8/8/PP6/1N2K3 b - - 0 1")
+	require.NoError(t, err)
+	c, err := chess.BoardFromFEN("4k3/pp6/8/8/8/1P6/P7/4K3 w - - 0 1")
+	require.NoError(t, err)
+
+	assert.Equal(t, a.PawnZobrist(), b.PawnZobrist(), "only pawns should affect the key")
+	assert.NotEqual(t, a.PawnZobrist(), c.PawnZobrist())
+}
@@ -0,0 +1,210 @@
+package chess
+
+import (
+	"sync"
+)
+
+// Counts of leaf nodes, broken down like the Chess Programming Wiki perft tables
+// Only Nodes is populated unless PerftOptions.Detailed is set
+type PerftStats struct {
+	Nodes      uint64
+	Captures   uint64 // includes en passant
+	EnPassants uint64
+	Castles    uint64
+	Promotions uint64
+	Checks     uint64
+	Checkmates uint64
+}
+
+func (s *PerftStats) add(o PerftStats) {
+	s.Nodes += o.Nodes
+	s.Captures += o.Captures
+	s.EnPassants += o.EnPassants
+	s.Castles += o.Castles
+	s.Promotions += o.Promotions
+	s.Checks += o.Checks
+	s.Checkmates += o.Checkmates
+}
+
+type PerftOptions struct {
+	Workers  int  // number of goroutines the root moves are split across, values below 2 run single-threaded
+	HashExp  int  // subtree counts are cached in a table with 2^HashExp entries, 0 disables the cache
+	Detailed bool // populate every field of PerftStats, rather than just Nodes (much slower)
+}
+
+// Subtree result for a single root move
+type PerftDivide struct {
+	Move  Move
+	Stats PerftStats
+}
+
+type perftEntry struct {
+	key   uint64
+	depth int
+	stats PerftStats
+}
+
+const perftLockStripes = 256
+
+type perftTable struct {
+	entries []perftEntry
+	mask    uint64
+	locks   [perftLockStripes]sync.Mutex
+}
+
+func newPerftTable(exp int) *perftTable {
+	length := 1 << exp
+	return &perftTable{
+		entries: make([]perftEntry, length),
+		mask:    uint64(length - 1),
+	}
+}
+
+func (t *perftTable) get(key uint64, depth int) (PerftStats, bool) {
+	i := key & t.mask
+	l := &t.locks[i%perftLockStripes]
+	l.Lock()
+	e := t.entries[i]
+	l.Unlock()
+	return e.stats, e.key == key && e.depth == depth
+}
+
+// Always overwrites existing entry
+func (t *perftTable) save(key uint64, depth int, stats PerftStats) {
+	i := key & t.mask
+	l := &t.locks[i%perftLockStripes]
+	l.Lock()
+	t.entries[i] = perftEntry{key: key, depth: depth, stats: stats}
+	l.Unlock()
+}
+
+type perfter struct {
+	detailed bool
+	table    *perftTable
+}
+
+// Counts the leaf nodes depth plies below b, and returns the results for each root move along with their total
+// b is left unchanged
+func Perft(b *Board, depth int, opts PerftOptions) ([]PerftDivide, PerftStats) {
+	p := perfter{detailed: opts.Detailed}
+	if opts.HashExp > 0 {
+		p.table = newPerftTable(opts.HashExp)
+	}
+
+	if depth == 0 {
+		return []PerftDivide{}, PerftStats{Nodes: 1}
+	}
+
+	ms, _ := b.LegalMoves()
+	divide := make([]PerftDivide, len(ms))
+	for i, m := range ms {
+		divide[i].Move = m
+	}
+
+	if opts.Workers < 2 {
+		for i, m := range ms {
+			divide[i].Stats = p.moveStats(b, m, depth)
+		}
+	} else {
+		var wg sync.WaitGroup
+		jobs := make(chan int)
+
+		for range opts.Workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					c := b.Copy()
+					divide[i].Stats = p.moveStats(&c, ms[i], depth)
+				}
+			}()
+		}
+
+		for i := range ms {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	var total PerftStats
+	for _, d := range divide {
+		total.add(d.Stats)
+	}
+
+	return divide, total
+}
+
+func (p *perfter) perft(b *Board, depth int) PerftStats {
+	if depth == 0 {
+		return PerftStats{Nodes: 1}
+	}
+
+	var z uint64
+	if p.table != nil && depth > 1 {
+		z = b.Zobrist()
+		if s, ok := p.table.get(z, depth); ok {
+			return s
+		}
+	}
+
+	ms, _ := b.LegalMoves()
+
+	if depth == 1 && !p.detailed {
+		return PerftStats{Nodes: uint64(len(ms))}
+	}
+
+	var s PerftStats
+	for _, m := range ms {
+		s.add(p.moveStats(b, m, depth))
+	}
+
+	if p.table != nil && depth > 1 {
+		p.table.save(z, depth, s)
+	}
+
+	return s
+}
+
+// Returns the stats for the subtree reached by playing m, where depth includes m itself
+func (p *perfter) moveStats(b *Board, m Move, depth int) PerftStats {
+	if depth == 1 {
+		return p.leafStats(b, m)
+	}
+
+	b.Move(m)
+	s := p.perft(b, depth-1)
+	b.Unmove()
+	return s
+}
+
+func (p *perfter) leafStats(b *Board, m Move) PerftStats {
+	s := PerftStats{Nodes: 1}
+	if !p.detailed {
+		return s
+	}
+
+	if m.Capture() != NoCapture || m.EnPassant() {
+		s.Captures++
+	}
+	if m.EnPassant() {
+		s.EnPassants++
+	}
+	if m.Castle() != NoCastle {
+		s.Castles++
+	}
+	if m.Promotion() != NoPromotion {
+		s.Promotions++
+	}
+
+	b.Move(m)
+	if b.InCheck() {
+		s.Checks++
+		if _, status := b.LegalMoves(); status == Checkmate {
+			s.Checkmates++
+		}
+	}
+	b.Unmove()
+
+	return s
+}
@@ -0,0 +1,170 @@
+package chess
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPerftEPD = errors.New("Invalid perft EPD")
+)
+
+// A position from a perft suite, along with its known node counts
+type PerftPosition struct {
+	FEN    string
+	Depths []uint64 // Depths[0] is the node count at depth 1, 0 if unknown
+}
+
+// Parses a perft suite in the standard EPD format, one position per line
+//
+//	rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;D1 20 ;D2 400 ;D3 8902
+//
+// Blank lines and lines starting with # are ignored
+func ParsePerftEPD(r io.Reader) ([]PerftPosition, error) {
+	var ps []PerftPosition
+
+	s := bufio.NewScanner(r)
+	line := 0
+	for s.Scan() {
+		line++
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, ";")
+		p := PerftPosition{FEN: strings.TrimSpace(fields[0])}
+
+		if _, err := BoardFromFEN(p.FEN); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidPerftEPD, line, err)
+		}
+
+		for _, f := range fields[1:] {
+			parts := strings.Fields(f)
+			if len(parts) != 2 || !strings.HasPrefix(parts[0], "D") {
+				return nil, fmt.Errorf("%w: line %d: cannot parse %q", ErrInvalidPerftEPD, line, f)
+			}
+
+			depth, err := strconv.Atoi(parts[0][1:])
+			if err != nil || depth < 1 {
+				return nil, fmt.Errorf("%w: line %d: cannot parse depth %q", ErrInvalidPerftEPD, line, parts[0])
+			}
+			nodes, err := strconv.ParseUint(parts[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: cannot parse node count %q", ErrInvalidPerftEPD, line, parts[1])
+			}
+
+			for len(p.Depths) < depth {
+				p.Depths = append(p.Depths, 0)
+			}
+			p.Depths[depth-1] = nodes
+		}
+
+		ps = append(ps, p)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+// Returns the divide (node count for each root move, keyed by Move.String()) of a trusted perft implementation,
+// for the position reached by playing moves from fen
+type PerftReference func(fen string, moves []string, depth int) (map[string]uint64, error)
+
+// Where our move generation first disagrees with a reference implementation
+type PerftMismatch struct {
+	Moves   []string // moves from the root to the position with the incorrect move list
+	Missing []string // legal moves we failed to generate
+	Extra   []string // moves we generated which are not legal
+}
+
+func (m PerftMismatch) String() string {
+	var s strings.Builder
+	s.WriteString("after [")
+	s.WriteString(strings.Join(m.Moves, " "))
+	s.WriteString("]")
+	if len(m.Missing) != 0 {
+		s.WriteString(" missing ")
+		s.WriteString(strings.Join(m.Missing, " "))
+	}
+	if len(m.Extra) != 0 {
+		s.WriteString(" extra ")
+		s.WriteString(strings.Join(m.Extra, " "))
+	}
+	return s.String()
+}
+
+// Walks down the divide tree, following the first move whose node count differs from the reference,
+// until it reaches a position where the move lists themselves differ
+// Returns false if the counts agree at the given depth
+func FindPerftMismatch(fen string, depth int, ref PerftReference, opts PerftOptions) (PerftMismatch, bool, error) {
+	b, err := BoardFromFEN(fen)
+	if err != nil {
+		return PerftMismatch{}, false, err
+	}
+
+	path := []string{}
+
+	for d := depth; d >= 1; d-- {
+		divide, _ := Perft(&b, d, opts)
+
+		ours := make(map[string]uint64, len(divide))
+		moves := make(map[string]Move, len(divide))
+		for _, dv := range divide {
+			ours[dv.Move.String()] = dv.Stats.Nodes
+			moves[dv.Move.String()] = dv.Move
+		}
+
+		theirs, err := ref(fen, path, d)
+		if err != nil {
+			return PerftMismatch{}, false, err
+		}
+
+		mismatch := PerftMismatch{Moves: path}
+		for m := range theirs {
+			if _, ok := ours[m]; !ok {
+				mismatch.Missing = append(mismatch.Missing, m)
+			}
+		}
+		for m := range ours {
+			if _, ok := theirs[m]; !ok {
+				mismatch.Extra = append(mismatch.Extra, m)
+			}
+		}
+		if len(mismatch.Missing) != 0 || len(mismatch.Extra) != 0 {
+			slices.Sort(mismatch.Missing)
+			slices.Sort(mismatch.Extra)
+			return mismatch, true, nil
+		}
+
+		names := make([]string, 0, len(ours))
+		for m := range ours {
+			names = append(names, m)
+		}
+		slices.Sort(names)
+
+		next := ""
+		for _, m := range names {
+			if ours[m] != theirs[m] {
+				next = m
+				break
+			}
+		}
+		if next == "" {
+			return PerftMismatch{}, false, nil
+		}
+
+		path = append(path, next)
+		b.Move(moves[next])
+	}
+
+	return PerftMismatch{}, false, nil
+}
@@ -0,0 +1,115 @@
+package chess_test
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestParsePerftEPD(t *testing.T) {
+	epd := `# comment
+rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;D1 20 ;D2 400
+
+8/8/1k6/2b5/2pP4/8/5K2/8 b - d3 0 1 ;D3 1000
+`
+	ps, err := chess.ParsePerftEPD(strings.NewReader(epd))
+	require.NoError(t, err)
+
+	assert.Equal(t, []chess.PerftPosition{
+		{FEN: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", Depths: []uint64{20, 400}},
+		{FEN: "8/8/1k6/2b5/2pP4/8/5K2/8 b - d3 0 1", Depths: []uint64{0, 0, 1000}},
+	}, ps)
+
+	for _, bad := range []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;D1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;X1 20",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;D0 1",
+		"not a fen ;D1 20",
+	} {
+		_, err := chess.ParsePerftEPD(strings.NewReader(bad))
+		assert.ErrorIs(t, err, chess.ErrInvalidPerftEPD, bad)
+	}
+}
+
+// Runs the shipped suite, skipping depths which would take too long
+func TestPerftSuite(t *testing.T) {
+	f, err := os.Open("testing/perftsuite.epd")
+	require.NoError(t, err)
+	defer f.Close()
+
+	ps, err := chess.ParsePerftEPD(f)
+	require.NoError(t, err)
+
+	for _, p := range ps {
+		b, err := chess.BoardFromFEN(p.FEN)
+		require.NoError(t, err)
+
+		for d, expected := range p.Depths {
+			if expected == 0 || expected > 2_000_000 {
+				continue
+			}
+			_, got := chess.Perft(&b, d+1, chess.PerftOptions{Workers: 4, HashExp: 16})
+			assert.Equal(t, expected, got.Nodes, "%s at depth %d", p.FEN, d+1)
+		}
+	}
+}
+
+func TestFindPerftMismatch(t *testing.T) {
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq -"
+
+	// A reference which knows about an extra move after e1g1 a6e2 that we don't generate
+	ref := func(fen string, moves []string, depth int) (map[string]uint64, error) {
+		b, err := chess.BoardFromFEN(fen)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range moves {
+			require.NoError(t, b.DoAlgebraicMove(m))
+		}
+
+		divide, _ := chess.Perft(&b, depth, chess.PerftOptions{})
+		res := map[string]uint64{}
+		for _, d := range divide {
+			res[d.Move.String()] = d.Stats.Nodes
+		}
+
+		switch {
+		case len(moves) == 0:
+			res["e1g1"]++
+		case len(moves) == 1 && moves[0] == "e1g1":
+			res["a6e2"]++
+		case slices.Equal(moves, []string{"e1g1", "a6e2"}):
+			res["a1a2"] = 1
+		}
+		return res, nil
+	}
+
+	mismatch, found, err := chess.FindPerftMismatch(fen, 3, ref, chess.PerftOptions{})
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, []string{"e1g1", "a6e2"}, mismatch.Moves)
+	assert.Equal(t, []string{"a1a2"}, mismatch.Missing)
+	assert.Empty(t, mismatch.Extra)
+
+	// Our own counts always agree with themselves
+	self := func(fen string, moves []string, depth int) (map[string]uint64, error) {
+		b, _ := chess.BoardFromFEN(fen)
+		for _, m := range moves {
+			b.DoAlgebraicMove(m)
+		}
+		divide, _ := chess.Perft(&b, depth, chess.PerftOptions{})
+		res := map[string]uint64{}
+		for _, d := range divide {
+			res[d.Move.String()] = d.Stats.Nodes
+		}
+		return res, nil
+	}
+	_, found, err = chess.FindPerftMismatch(fen, 3, self, chess.PerftOptions{})
+	require.NoError(t, err)
+	assert.False(t, found)
+}
@@ -0,0 +1,95 @@
+package chess_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestPerftStats(t *testing.T) {
+	tests := []struct {
+		Name     string
+		FEN      string
+		Depth    int
+		Expected chess.PerftStats
+	}{
+		{
+			Name:  "Initial Position",
+			FEN:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			Depth: 4,
+			Expected: chess.PerftStats{
+				Nodes:      197281,
+				Captures:   1576,
+				Checks:     469,
+				Checkmates: 8,
+			},
+		},
+		{
+			Name:  "Kiwipete",
+			FEN:   "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq -",
+			Depth: 3,
+			Expected: chess.PerftStats{
+				Nodes:      97862,
+				Captures:   17102,
+				EnPassants: 45,
+				Castles:    3162,
+				Checks:     993,
+				Checkmates: 1,
+			},
+		},
+		{
+			Name:  "Position 3",
+			FEN:   "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+			Depth: 4,
+			Expected: chess.PerftStats{
+				Nodes:      43238,
+				Captures:   3348,
+				EnPassants: 123,
+				Checks:     1680,
+				Checkmates: 17,
+			},
+		},
+		{
+			Name:  "Position 4",
+			FEN:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+			Depth: 3,
+			Expected: chess.PerftStats{
+				Nodes:      9467,
+				Captures:   1021,
+				EnPassants: 4,
+				Castles:    0,
+				Promotions: 120,
+				Checks:     38,
+				Checkmates: 22,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			b, err := chess.BoardFromFEN(tt.FEN)
+			require.NoError(t, err)
+
+			_, got := chess.Perft(&b, tt.Depth, chess.PerftOptions{Detailed: true})
+			assert.Equal(t, tt.Expected, got)
+		})
+	}
+}
+
+func TestPerftParallelHashed(t *testing.T) {
+	b, err := chess.BoardFromFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq -")
+	require.NoError(t, err)
+
+	wantDivide, want := chess.Perft(&b, 4, chess.PerftOptions{})
+	require.Equal(t, uint64(4085603), want.Nodes)
+
+	gotDivide, got := chess.Perft(&b, 4, chess.PerftOptions{Workers: 4, HashExp: 16})
+	assert.Equal(t, want, got)
+	assert.Equal(t, wantDivide, gotDivide)
+
+	_, detailed := chess.Perft(&b, 2, chess.PerftOptions{Workers: 4, HashExp: 16, Detailed: true})
+	_, serial := chess.Perft(&b, 2, chess.PerftOptions{Detailed: true})
+	assert.Equal(t, serial, detailed)
+}
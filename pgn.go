@@ -0,0 +1,442 @@
+package chess
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPGN = errors.New("Invalid PGN")
+
+// A game read from a PGN file
+type PGNGame struct {
+	Tags map[string]string
+	PGNLine
+	Result string // 1-0, 0-1, 1/2-1/2 or *
+}
+
+// A line of PGN movetext, either a game's main line or a variation
+type PGNLine struct {
+	Moves       []string               // SAN, without move numbers or annotations
+	Annotations map[int]*PGNAnnotation // keyed by index into Moves, nil if none of the moves have any
+	Comment     string                 // before the first move
+}
+
+// Everything written after a move in PGN movetext
+type PGNAnnotation struct {
+	NAGs       []int     // numeric annotation glyphs, suffixes such as ! and ?! are read as $1 and $6
+	Comment    string    // several comments after the same move are joined with a space
+	Variations []PGNLine // alternatives to the move, played from the position before it
+}
+
+// Returns the annotation of the line's last move, adding an empty one if it has none, or nil before the first move
+func (l *PGNLine) lastAnnotation() *PGNAnnotation {
+	if len(l.Moves) == 0 {
+		return nil
+	}
+	if l.Annotations == nil {
+		l.Annotations = map[int]*PGNAnnotation{}
+	}
+	i := len(l.Moves) - 1
+	if l.Annotations[i] == nil {
+		l.Annotations[i] = &PGNAnnotation{}
+	}
+	return l.Annotations[i]
+}
+
+// Move suffix annotations and the NAGs they stand for
+var pgnSuffixNAGs = map[string]int{"!": 1, "?": 2, "!!": 3, "??": 4, "!?": 5, "?!": 6}
+
+func joinComments(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return a + " " + b
+}
+
+// Returns the game's starting position, which is the standard one unless there's a FEN tag
+func (g *PGNGame) Board() (Board, error) {
+	fen, ok := g.Tags["FEN"]
+	if !ok {
+		return NewBoard(), nil
+	}
+	b, err := BoardFromFEN(fen)
+	if err != nil {
+		return Board{}, fmt.Errorf("%w: FEN tag %q", ErrInvalidPGN, fen)
+	}
+	return b, nil
+}
+
+// Returns a game made up of b's moves, from the position b started in
+func NewPGNGame(b *Board) *PGNGame {
+	start := b.Copy()
+	for len(start.Moves) > 0 {
+		start.Unmove()
+	}
+
+	g := &PGNGame{Tags: map[string]string{}, Result: "*"}
+	if fen := start.FEN(); fen != startFEN {
+		g.Tags["SetUp"] = "1"
+		g.Tags["FEN"] = fen
+	}
+	for _, m := range b.Moves {
+		g.Moves = append(g.Moves, start.SAN(m))
+		start.Move(m)
+	}
+	return g
+}
+
+const startFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// The seven tag roster, which is always written first and in this order
+var pgnRosterTags = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// Writes the game in PGN export format, followed by a blank line
+// Missing roster tags are written as ?, and the movetext is wrapped at 80 columns
+func (g *PGNGame) Write(w io.Writer) error {
+	var s strings.Builder
+	writeTag := func(name, value string) {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+		value = strings.ReplaceAll(value, `"`, `\"`)
+		fmt.Fprintf(&s, "[%s \"%s\"]\n", name, value)
+	}
+
+	for _, name := range pgnRosterTags {
+		value, ok := g.Tags[name]
+		switch {
+		case name == "Result":
+			value = g.Result
+		case !ok:
+			value = "?"
+		}
+		writeTag(name, value)
+	}
+	var others []string
+	for name := range g.Tags {
+		if !slices.Contains(pgnRosterTags, name) {
+			others = append(others, name)
+		}
+	}
+	slices.Sort(others)
+	for _, name := range others {
+		writeTag(name, g.Tags[name])
+	}
+	s.WriteByte('\n')
+
+	b, err := g.Board()
+	if err != nil {
+		return err
+	}
+	tokens := append(g.PGNLine.tokens(b.HalfMoves), g.Result)
+
+	width := 0
+	for i, t := range tokens {
+		if i > 0 && width+1+len(t) > 80 {
+			s.WriteByte('\n')
+			width = 0
+		} else if i > 0 {
+			s.WriteByte(' ')
+			width++
+		}
+		s.WriteString(t)
+		width += len(t)
+	}
+	s.WriteString("\n\n")
+
+	_, err = io.WriteString(w, s.String())
+	return err
+}
+
+// Returns the movetext of the line, starting at ply, split into tokens which can be wrapped between
+// Black's move number is repeated after comments and variations, as well as before the first move
+func (l *PGNLine) tokens(ply int) []string {
+	var tokens []string
+	comment := func(c string) {
+		if c != "" {
+			tokens = append(tokens, strings.Fields("{"+c+"}")...)
+		}
+	}
+
+	comment(l.Comment)
+	number := true
+	for i, san := range l.Moves {
+		switch {
+		case ply%2 == 0:
+			tokens = append(tokens, strconv.Itoa(ply/2+1)+".")
+		case number:
+			tokens = append(tokens, strconv.Itoa(ply/2+1)+"...")
+		}
+		tokens = append(tokens, san)
+		number = false
+
+		if a := l.Annotations[i]; a != nil {
+			for _, nag := range a.NAGs {
+				tokens = append(tokens, "$"+strconv.Itoa(nag))
+			}
+			comment(a.Comment)
+			for _, v := range a.Variations {
+				vt := v.tokens(ply)
+				if len(vt) == 0 {
+					continue
+				}
+				vt[0] = "(" + vt[0]
+				vt[len(vt)-1] += ")"
+				tokens = append(tokens, vt...)
+			}
+			number = a.Comment != "" || len(a.Variations) > 0
+		}
+		ply++
+	}
+	return tokens
+}
+
+func isPGNResult(s string) bool {
+	return s == "1-0" || s == "0-1" || s == "1/2-1/2" || s == "*"
+}
+
+// Reads games one at a time from a PGN file
+// Comments, NAGs and variations are kept as annotations, % escaped lines are skipped over
+type PGNReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func NewPGNReader(r io.Reader) *PGNReader {
+	return &PGNReader{r: bufio.NewReader(r), line: 1}
+}
+
+func (pr *PGNReader) readByte() (byte, error) {
+	c, err := pr.r.ReadByte()
+	if c == '\n' {
+		pr.line++
+	}
+	return c, err
+}
+
+func (pr *PGNReader) unreadByte(c byte) {
+	pr.r.UnreadByte()
+	if c == '\n' {
+		pr.line--
+	}
+}
+
+// Reads everything up to and including end, returning it without end
+func (pr *PGNReader) readPast(end byte) (string, error) {
+	var s strings.Builder
+	for {
+		c, err := pr.readByte()
+		if err != nil {
+			return s.String(), err
+		}
+		if c == end {
+			return s.String(), nil
+		}
+		s.WriteByte(c)
+	}
+}
+
+// Reads a token up to, but not including, whitespace or a special character
+func (pr *PGNReader) readToken() (string, error) {
+	var s strings.Builder
+	for {
+		c, err := pr.readByte()
+		if err == io.EOF {
+			return s.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if strings.IndexByte(" \t\r\n{}();[]", c) != -1 {
+			pr.unreadByte(c)
+			return s.String(), nil
+		}
+		s.WriteByte(c)
+	}
+}
+
+// Reads the rest of a [Name "value"] tag pair, after the opening bracket
+func (pr *PGNReader) readTag(g *PGNGame) error {
+	line := pr.line
+	var name, value strings.Builder
+	inValue := false
+	for {
+		c, err := pr.readByte()
+		if err == io.EOF {
+			return fmt.Errorf("%w: line %d: unterminated tag", ErrInvalidPGN, line)
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case inValue && c == '\\':
+			if c, err = pr.readByte(); err != nil {
+				return fmt.Errorf("%w: line %d: unterminated tag", ErrInvalidPGN, line)
+			}
+			value.WriteByte(c)
+		case inValue && c == '"':
+			inValue = false
+		case inValue:
+			value.WriteByte(c)
+		case c == '"':
+			inValue = true
+		case c == ']':
+			if name.Len() == 0 {
+				return fmt.Errorf("%w: line %d: tag without a name", ErrInvalidPGN, line)
+			}
+			g.Tags[name.String()] = value.String()
+			return nil
+		case c != ' ' && c != '\t':
+			name.WriteByte(c)
+		}
+	}
+}
+
+// Returns the next game, or io.EOF once there are none left
+// A game without a result is ended by the tags of the next one, or the end of the file
+func (pr *PGNReader) Next() (*PGNGame, error) {
+	g := &PGNGame{Tags: map[string]string{}}
+	started := false                // seen any movetext
+	lines := []*PGNLine{&g.PGNLine} // the main line, then any variations being read inside it
+	lineStart := pr.line == 1
+
+	comment := func(text string) {
+		text = strings.Join(strings.Fields(text), " ")
+		line := lines[len(lines)-1]
+		if a := line.lastAnnotation(); a != nil {
+			a.Comment = joinComments(a.Comment, text)
+		} else {
+			line.Comment = joinComments(line.Comment, text)
+		}
+	}
+
+	for {
+		c, err := pr.readByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		atLineStart := lineStart
+		lineStart = c == '\n'
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		case c == '%' && atLineStart:
+			if _, err := pr.readPast('\n'); err != nil && err != io.EOF {
+				return nil, err
+			}
+			lineStart = true
+		case c == '[' && len(lines) == 1:
+			if started {
+				pr.unreadByte(c)
+				return finishPGNGame(g), nil
+			}
+			if err := pr.readTag(g); err != nil {
+				return nil, err
+			}
+		case c == '{':
+			line := pr.line
+			text, err := pr.readPast('}')
+			if err == io.EOF {
+				return nil, fmt.Errorf("%w: line %d: unterminated comment", ErrInvalidPGN, line)
+			} else if err != nil {
+				return nil, err
+			}
+			comment(text)
+		case c == ';':
+			text, err := pr.readPast('\n')
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			comment(text)
+			lineStart = true
+		case c == '(':
+			started = true
+			if len(lines[len(lines)-1].Moves) == 0 {
+				return nil, fmt.Errorf("%w: line %d: variation before any move", ErrInvalidPGN, pr.line)
+			}
+			lines = append(lines, &PGNLine{})
+		case c == ')':
+			if len(lines) == 1 {
+				return nil, fmt.Errorf("%w: line %d: unmatched ')'", ErrInvalidPGN, pr.line)
+			}
+			v := lines[len(lines)-1]
+			lines = lines[:len(lines)-1]
+			a := lines[len(lines)-1].lastAnnotation()
+			a.Variations = append(a.Variations, *v)
+		default:
+			started = true
+			pr.unreadByte(c)
+			line := pr.line
+			token, err := pr.readToken()
+			if err != nil {
+				return nil, err
+			}
+			if token == "" { // a stray ] or }
+				pr.readByte()
+				return nil, fmt.Errorf("%w: line %d: unexpected %q", ErrInvalidPGN, line, c)
+			}
+
+			current := lines[len(lines)-1]
+			if nag, ok := strings.CutPrefix(token, "$"); ok {
+				n, err := strconv.Atoi(nag)
+				if err != nil {
+					return nil, fmt.Errorf("%w: line %d: bad NAG %q", ErrInvalidPGN, line, token)
+				}
+				if a := current.lastAnnotation(); a != nil {
+					a.NAGs = append(a.NAGs, n)
+				}
+				continue
+			}
+			if isPGNResult(token) {
+				if len(lines) > 1 {
+					continue
+				}
+				g.Result = token
+				return finishPGNGame(g), nil
+			}
+
+			// Move numbers can be attached to the move, as in 1.e4 or 3...Nf6, but 0-0 is castling
+			if digits := strings.TrimLeft(token, "0123456789"); strings.HasPrefix(digits, ".") {
+				token = strings.TrimLeft(digits, ".")
+			}
+			san := strings.TrimRight(token, "!?")
+			if san != "" {
+				current.Moves = append(current.Moves, san)
+			}
+			if nag, ok := pgnSuffixNAGs[token[len(san):]]; ok {
+				if a := current.lastAnnotation(); a != nil {
+					a.NAGs = append(a.NAGs, nag)
+				}
+			}
+		}
+	}
+
+	if len(lines) > 1 {
+		return nil, fmt.Errorf("%w: line %d: unterminated variation", ErrInvalidPGN, pr.line)
+	}
+	if !started && len(g.Tags) == 0 {
+		return nil, io.EOF
+	}
+	return finishPGNGame(g), nil
+}
+
+// Falls back on the Result tag when the movetext didn't end with one
+func finishPGNGame(g *PGNGame) *PGNGame {
+	if g.Result == "" {
+		g.Result = g.Tags["Result"]
+	}
+	if g.Result == "" {
+		g.Result = "*"
+	}
+	return g
+}
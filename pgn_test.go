@@ -0,0 +1,147 @@
+package chess_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+const testPGN = `% exported by hand
+[Event "Casual \"blitz\""]
+[White "A"]
+[Black "B"]
+[Result "1-0"]
+
+1. e4 {best by test} e5 2.Nf3 Nc6 $1 (2... d6 3. d4 (3. Bc4) exd4) 3. Bb5 a6
+; a rest of line comment
+4. Ba4 Nf6 5. O-O 1-0
+
+[Event "No result in the movetext"]
+[Result "1/2-1/2"]
+
+1. d4 d5 2. c4 c6
+
+[Event "From a position"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+
+1. e4 Kd7 2. Kf2 1/2-1/2
+`
+
+func TestPGNReader(t *testing.T) {
+	pr := chess.NewPGNReader(strings.NewReader(testPGN))
+
+	g, err := pr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, `Casual "blitz"`, g.Tags["Event"])
+	assert.Equal(t, "A", g.Tags["White"])
+	assert.Equal(t, "1-0", g.Result)
+	assert.Equal(t, []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "O-O"}, g.Moves)
+	assert.Equal(t, "best by test", g.Annotations[0].Comment)
+	assert.Equal(t, []int{1}, g.Annotations[3].NAGs)
+	require.Len(t, g.Annotations[3].Variations, 1)
+	v := g.Annotations[3].Variations[0]
+	assert.Equal(t, []string{"d6", "d4", "exd4"}, v.Moves)
+	assert.Equal(t, []chess.PGNLine{{Moves: []string{"Bc4"}}}, v.Annotations[1].Variations)
+	assert.Equal(t, "a rest of line comment", g.Annotations[5].Comment)
+	assert.Len(t, g.Annotations, 3)
+
+	g, err = pr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "1/2-1/2", g.Result)
+	assert.Equal(t, []string{"d4", "d5", "c4", "c6"}, g.Moves)
+
+	g, err = pr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e4", "Kd7", "Kf2"}, g.Moves)
+	b, err := g.Board()
+	require.NoError(t, err)
+	assert.Equal(t, "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", b.FEN())
+
+	_, err = pr.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestPGNReaderErrors(t *testing.T) {
+	for _, pgn := range []string{
+		`[Event "unterminated`,
+		`1. e4 {unterminated`,
+		`1. e4 e5 ) 2. Nf3`,
+		`[ "no name"]`,
+		`1. e4 (1. d4`,
+		`({comment} 1. e4)`,
+		`1. e4 $x`,
+	} {
+		_, err := chess.NewPGNReader(strings.NewReader(pgn)).Next()
+		assert.ErrorIs(t, err, chess.ErrInvalidPGN, pgn)
+	}
+}
+
+func TestPGNWrite(t *testing.T) {
+	b := chess.NewBoard()
+	for range 12 {
+		playMoves(t, &b, "Nf3 Nf6 Ng1 Ng8")
+	}
+	playMoves(t, &b, "e4")
+	g := chess.NewPGNGame(&b)
+	g.Tags["White"] = `Quoted "engine"`
+	g.Tags["Termination"] = "adjudication"
+	g.Result = "1/2-1/2"
+
+	var buf bytes.Buffer
+	require.NoError(t, g.Write(&buf))
+	assert.Equal(t, `[Event "?"]
+[Site "?"]
+[Date "?"]
+[Round "?"]
+[White "Quoted \"engine\""]
+[Black "?"]
+[Result "1/2-1/2"]
+[Termination "adjudication"]
+
+1. Nf3 Nf6 2. Ng1 Ng8 3. Nf3 Nf6 4. Ng1 Ng8 5. Nf3 Nf6 6. Ng1 Ng8 7. Nf3 Nf6 8.
+Ng1 Ng8 9. Nf3 Nf6 10. Ng1 Ng8 11. Nf3 Nf6 12. Ng1 Ng8 13. Nf3 Nf6 14. Ng1 Ng8
+15. Nf3 Nf6 16. Ng1 Ng8 17. Nf3 Nf6 18. Ng1 Ng8 19. Nf3 Nf6 20. Ng1 Ng8 21. Nf3
+Nf6 22. Ng1 Ng8 23. Nf3 Nf6 24. Ng1 Ng8 25. e4 1/2-1/2
+
+`, buf.String())
+
+	read, err := chess.NewPGNReader(&buf).Next()
+	require.NoError(t, err)
+	assert.Equal(t, g.Moves, read.Moves)
+	assert.Equal(t, g.Result, read.Result)
+	assert.Equal(t, g.Tags["White"], read.Tags["White"])
+}
+
+func TestPGNWriteFromPosition(t *testing.T) {
+	b, err := chess.BoardFromFEN("4k3/8/8/8/8/8/4P3/4K3 b - - 0 30")
+	require.NoError(t, err)
+	playMoves(t, &b, "Kd7 e4 Ke6")
+
+	var buf bytes.Buffer
+	require.NoError(t, chess.NewPGNGame(&b).Write(&buf))
+	assert.Contains(t, buf.String(), "[FEN \"4k3/8/8/8/8/8/4P3/4K3 b - - 0 30\"]\n[SetUp \"1\"]\n\n30... Kd7 31. e4 Ke6 *\n")
+}
+
+func TestPGNAnnotations(t *testing.T) {
+	g, err := chess.NewPGNReader(strings.NewReader(`{Start} 1. e4! e5?! $18 {Solid} (1... c5 {Sicilian} 2. Nf3) 2. Nf3 *`)).Next()
+	require.NoError(t, err)
+	assert.Equal(t, "Start", g.Comment)
+	assert.Equal(t, []string{"e4", "e5", "Nf3"}, g.Moves)
+	assert.Equal(t, []int{1}, g.Annotations[0].NAGs)
+	assert.Equal(t, []int{6, 18}, g.Annotations[1].NAGs)
+
+	var buf bytes.Buffer
+	require.NoError(t, g.Write(&buf))
+	movetext := "{Start} 1. e4 $1 e5 $6 $18 {Solid} (1... c5 {Sicilian} 2. Nf3) 2. Nf3 *\n\n"
+	assert.True(t, strings.HasSuffix(buf.String(), movetext), buf.String())
+
+	read, err := chess.NewPGNReader(&buf).Next()
+	require.NoError(t, err)
+	assert.Equal(t, g.PGNLine, read.PGNLine)
+}
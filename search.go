@@ -1,14 +1,85 @@
 package chess
 
 import (
-	"fmt"
+	"math"
+	"slices"
 	"sort"
 	"time"
 )
 
 var inf = 9223372036854775807
 
+// Searches for the best move, deepening until a depth finishes after the given number of seconds
 func (e *Engine) Search(seconds int) Move {
+	return e.search(searchBounds{soft: time.Duration(seconds) * time.Second, hard: noTimeLimit}).Move
+}
+
+// Bounds on a search, zero values mean no limit
+// Without any limits only depth 1 is searched
+type SearchLimits struct {
+	Depth    int
+	Nodes    int
+	MoveTime time.Duration
+
+	// The clock, used to decide how long to spend when MoveTime isn't given
+	WhiteTime, BlackTime time.Duration
+	WhiteInc, BlackInc   time.Duration
+	MovesToGo            int // until the next time control, 0 if the rest of the game must be played in the time left
+}
+
+type SearchResult struct {
+	Move  Move
+	Score int // from the side to move's point of view, as returned by negamax
+	Depth int // of the last completed iteration, 0 for book and tablebase moves
+	Nodes int
+	Time  time.Duration
+	PV    []Move // the line the search expects, starting with Move
+}
+
+// Searches within the limits, stopping part way through an iteration if a hard limit is reached
+// Depth 1 is always completed, however tight the limits are
+func (e *Engine) SearchWith(l SearchLimits) SearchResult {
+	return e.search(l.bounds(e.B.Turn))
+}
+
+// Works out how long to spend on a move for turn
+func (l SearchLimits) bounds(turn Turn) searchBounds {
+	sb := searchBounds{depth: l.Depth, nodes: l.Nodes, soft: noTimeLimit, hard: noTimeLimit}
+
+	remaining, inc := l.WhiteTime, l.WhiteInc
+	if turn == BlackTurn {
+		remaining, inc = l.BlackTime, l.BlackInc
+	}
+
+	switch {
+	case l.MoveTime > 0:
+		// Another iteration is unlikely to finish in the time left once half of it has gone
+		sb.soft, sb.hard = l.MoveTime/2, l.MoveTime
+	case remaining > 0:
+		movesToGo := l.MovesToGo
+		if movesToGo == 0 {
+			movesToGo = 30
+		}
+		// An iteration running long past the soft limit is cut short, rather than using most of the clock
+		soft := remaining/time.Duration(movesToGo) + inc*3/4
+		sb.hard = min(remaining*3/4, soft*4)
+		sb.soft = min(soft, sb.hard)
+	case l.Depth == 0 && l.Nodes == 0:
+		sb.depth = 1
+	}
+
+	return sb
+}
+
+const noTimeLimit = time.Duration(math.MaxInt64)
+
+type searchBounds struct {
+	depth, nodes int           // 0 for no limit
+	soft         time.Duration // no new iteration is started after this
+	hard         time.Duration // the search stops as soon as it notices this has passed
+}
+
+func (e *Engine) search(sb searchBounds) SearchResult {
 	start := time.Now()
 
 	ms, status := e.B.LegalMoves()
@@ -17,6 +88,12 @@ func (e *Engine) Search(seconds int) Move {
 		panic("aghhh, the game is over")
 	}
 
+	if e.Book != nil {
+		if m, ok := e.Book.Move(&e.B); ok {
+			return SearchResult{Move: m, Time: time.Since(start), PV: []Move{m}}
+		}
+	}
+
 	searched := make([]MoveSearch, 0, len(ms))
 
 	for _, m := range ms {
@@ -27,15 +104,72 @@ func (e *Engine) Search(seconds int) Move {
 		})
 	}
 
-	for d := 1; ; d += 1 {
+	e.nodes = 0
+	e.stopped = false
+	e.maxNodes = 0
+	e.deadline = time.Time{}
+
+	depth := 0
+	for d := 1; sb.depth == 0 || d <= sb.depth; d += 1 {
 		searched = e.orderMoves(d, searched)
-		fmt.Println(d)
-		if time.Since(start).Seconds() > float64(seconds) {
+		if e.stopped {
+			break
+		}
+		depth = d
+
+		// The limits only apply from depth 2, so there's always a move to play
+		e.maxNodes = sb.nodes
+		if sb.hard != noTimeLimit {
+			e.deadline = start.Add(sb.hard)
+		}
+		if time.Since(start) > sb.soft || (sb.nodes > 0 && e.nodes >= sb.nodes) {
+			break
+		}
+	}
+
+	return SearchResult{
+		Move:  searched[0].Move,
+		Score: searched[0].Eval,
+		Depth: depth,
+		Nodes: e.nodes,
+		Time:  time.Since(start),
+		PV:    e.pv(searched[0].Move, depth),
+	}
+}
+
+// Follows the transposition table's best moves from after the root move, for up to length moves in all
+// Entries can be overwritten by other positions, so the line stops at the first one which is missing or illegal
+func (e *Engine) pv(first Move, length int) []Move {
+	pv := []Move{first}
+	e.B.Move(first)
+	for len(pv) < length {
+		t, ok := e.TT.Get(e.B.Zobrist())
+		if !ok || t.BestMove == 0 {
 			break
 		}
+		ms, _ := e.B.LegalMoves()
+		if !slices.Contains(ms, t.BestMove) {
+			break
+		}
+		pv = append(pv, t.BestMove)
+		e.B.Move(t.BestMove)
+	}
+	for range pv {
+		e.B.Unmove()
 	}
+	return pv
+}
 
-	return searched[0].Move
+// Checks the node and time limits, remembering once either has been reached
+func (e *Engine) shouldStop() bool {
+	if !e.stopped && e.maxNodes > 0 && e.nodes >= e.maxNodes {
+		e.stopped = true
+	}
+	// The clock is only read every so often, since it's comparatively slow
+	if !e.stopped && !e.deadline.IsZero() && e.nodes&1023 == 0 && time.Now().After(e.deadline) {
+		e.stopped = true
+	}
+	return e.stopped
 }
 
 type MoveSearch struct {
@@ -44,23 +178,56 @@ type MoveSearch struct {
 	Depth int
 }
 
+// Searches every root move to depth, returning them best first
+// If the search is stopped part way through, the moves are returned as they were
 func (e *Engine) orderMoves(depth int, searched []MoveSearch) []MoveSearch {
+	next := make([]MoveSearch, len(searched))
 	for i, m := range searched {
 		e.B.Move(m.Move)
 		val := -e.negamax(depth-1, -inf, inf, 1)
-		searched[i].Depth = depth
-		searched[i].Eval = val
 		e.B.Unmove()
+		if e.stopped {
+			return searched
+		}
+		next[i] = MoveSearch{Move: m.Move, Eval: val, Depth: depth}
 	}
 
-	sort.Slice(searched, func(i, j int) bool { return searched[i].Eval > searched[j].Eval })
+	sort.Slice(next, func(i, j int) bool { return next[i].Eval > next[j].Eval })
 
-	return searched
+	return next
 }
 
 const checkmateEval = -1000000
 
+// Scores beyond this are mates, leaving room below them for tablebase wins
+const tbWinEval = -checkmateEval - 1000
+
+// Returns how many moves away mate is for a score found by the search, negative if the side to move is being mated
+func MateIn(score int) (int, bool) {
+	if abs(score) <= tbWinEval {
+		return 0, false
+	}
+	moves := (-checkmateEval - abs(score) + 1) / 2
+	if score < 0 {
+		return -moves, true
+	}
+	return moves, true
+}
+
+// The inverse of MateIn
+func MateScore(moves int) int {
+	if moves > 0 {
+		return -checkmateEval - (2*moves - 1)
+	}
+	return checkmateEval - 2*moves
+}
+
 func (e *Engine) negamax(depth int, alpha, beta, ply int) int {
+	e.nodes++
+	if e.shouldStop() {
+		return 0
+	}
+
 	z := e.B.Zobrist()
 	if t, ok := e.TT.Get(z); ok && t.Depth >= depth {
 		if t.Type == ExactEntry ||
@@ -86,14 +253,22 @@ func (e *Engine) negamax(depth int, alpha, beta, ply int) int {
 	if len(ms) == 0 {
 		return value
 	}
+
 	if depth == 0 {
 		return e.Evaluate()
 	}
 
+	var best Move
 	for _, m := range ms {
 		e.B.Move(m)
-		value = max(value, -e.negamax(depth-1, -beta, -alpha, ply+1))
+		v := -e.negamax(depth-1, -beta, -alpha, ply+1)
 		e.B.Unmove()
+		if e.stopped {
+			return 0 // the value is meaningless, so mustn't be stored
+		}
+		if v > value {
+			value, best = v, m
+		}
 		alpha = max(alpha, value)
 		if alpha >= beta {
 			break
@@ -101,10 +276,11 @@ func (e *Engine) negamax(depth int, alpha, beta, ply int) int {
 	}
 
 	t := Transposition{
-		Key:   z,
-		Depth: depth,
-		Score: value,
-		Type:  ExactEntry,
+		Key:      z,
+		BestMove: best,
+		Depth:    depth,
+		Score:    value,
+		Type:     ExactEntry,
 	}
 
 	if value <= originalA {
@@ -2,6 +2,7 @@ package chess
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -40,3 +41,82 @@ func TestSearch(t *testing.T) {
 	m := e.Search(1)
 	t.Log(m.String())
 }
+
+func TestSearchWith(t *testing.T) {
+	newEngine := func() Engine {
+		return Engine{B: NewBoard(), TT: *NewTranspositionTable(16), EP: DefaultParams}
+	}
+
+	e := newEngine()
+	r := e.SearchWith(SearchLimits{})
+	assert.Equal(t, 1, r.Depth)
+	assert.Equal(t, 20, r.Nodes)
+
+	e = newEngine()
+	r = e.SearchWith(SearchLimits{Depth: 3})
+	assert.Equal(t, 3, r.Depth)
+	assert.NotZero(t, r.Move)
+	assert.Equal(t, r.Move, r.PV[0])
+	assert.LessOrEqual(t, len(r.PV), 3)
+	for _, m := range r.PV {
+		ms, _ := e.B.LegalMoves()
+		assert.Contains(t, ms, m)
+		e.B.Move(m)
+	}
+
+	// Stops part way through depth 3, going back to the depth 2 result
+	e = newEngine()
+	depth2 := e.SearchWith(SearchLimits{Depth: 2})
+	e = newEngine()
+	r = e.SearchWith(SearchLimits{Nodes: depth2.Nodes + 10})
+	assert.Equal(t, 2, r.Depth)
+	assert.Equal(t, depth2.Move, r.Move)
+	assert.Equal(t, depth2.Nodes+10, r.Nodes)
+
+	e = newEngine()
+	r = e.SearchWith(SearchLimits{MoveTime: 50 * time.Millisecond})
+	assert.Less(t, r.Time, 500*time.Millisecond)
+
+	e = newEngine()
+	r = e.SearchWith(SearchLimits{WhiteTime: 3 * time.Second, BlackTime: time.Millisecond})
+	assert.Less(t, r.Time, time.Second)
+}
+
+func TestSearchLimitsBounds(t *testing.T) {
+	// 5+2, so 10s plus most of the increment per move, but no more than four times that
+	l := SearchLimits{WhiteTime: 300 * time.Second, BlackTime: 300 * time.Second, WhiteInc: 2 * time.Second, BlackInc: 2 * time.Second}
+	sb := l.bounds(WhiteTurn)
+	assert.Equal(t, 11500*time.Millisecond, sb.soft)
+	assert.Equal(t, 46*time.Second, sb.hard)
+
+	// Short of time, the hard limit still keeps a quarter of the clock
+	l = SearchLimits{WhiteTime: time.Second, BlackTime: time.Second, MovesToGo: 1}
+	sb = l.bounds(BlackTurn)
+	assert.Equal(t, 750*time.Millisecond, sb.soft)
+	assert.Equal(t, 750*time.Millisecond, sb.hard)
+
+	sb = SearchLimits{MoveTime: time.Second}.bounds(WhiteTurn)
+	assert.Equal(t, 500*time.Millisecond, sb.soft)
+	assert.Equal(t, time.Second, sb.hard)
+}
+
+func TestSearchMate(t *testing.T) {
+	b, err := BoardFromFEN("6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	assert.NoError(t, err)
+	e := Engine{B: b, TT: *NewTranspositionTable(16), EP: DefaultParams}
+
+	r := e.SearchWith(SearchLimits{Depth: 2})
+	assert.Equal(t, "a1a8", r.Move.String())
+	assert.Equal(t, []Move{r.Move}, r.PV)
+	moves, ok := MateIn(r.Score)
+	assert.True(t, ok)
+	assert.Equal(t, 1, moves)
+	assert.Equal(t, r.Score, MateScore(1))
+
+	moves, ok = MateIn(MateScore(-3))
+	assert.True(t, ok)
+	assert.Equal(t, -3, moves)
+
+	_, ok = MateIn(tbWinEval - 5)
+	assert.False(t, ok)
+}
@@ -0,0 +1,160 @@
+package chess
+
+func (p PieceType) value() int {
+	switch p {
+	case PawnType:
+		return pawnValue
+	case RookType:
+		return rookValue
+	case KnightType:
+		return knightValue
+	case BishopType:
+		return bishopValue
+	case QueenType:
+		return queenValue
+	case KingType:
+		return kingValue
+	default:
+		return 0
+	}
+}
+
+func (c Capture) value() int {
+	switch c {
+	case PawnCapture:
+		return pawnValue
+	case RookCapture:
+		return rookValue
+	case KnightCapture:
+		return knightValue
+	case BishopCapture:
+		return bishopValue
+	case QueenCapture:
+		return queenValue
+	default:
+		return 0
+	}
+}
+
+func (p Promotion) value() int {
+	switch p {
+	case RookPromotion:
+		return rookValue
+	case KnightPromotion:
+		return knightValue
+	case BishopPromotion:
+		return bishopValue
+	case QueenPromotion:
+		return queenValue
+	default:
+		return 0
+	}
+}
+
+// Returns the least valuable piece in attackers, or NoType if there are none
+func (b *Board) leastValuableAttacker(attackers uint64, turn Turn) (uint64, PieceType) {
+	boards := [6]uint64{b.whitePawns, b.whiteKnights, b.whiteBishops, b.whiteRooks, b.whiteQueens, b.whiteKings}
+	if turn == BlackTurn {
+		boards = [6]uint64{b.blackPawns, b.blackKnights, b.blackBishops, b.blackRooks, b.blackQueens, b.blackKings}
+	}
+	types := [6]PieceType{PawnType, KnightType, BishopType, RookType, QueenType, KingType}
+
+	for i, bb := range boards {
+		if bb&attackers != 0 {
+			bb &= attackers
+			return bb & -bb, types[i]
+		}
+	}
+	return 0, NoType
+}
+
+// Static Exchange Evaluation
+// Returns the material balance (from the perspective of the side making the move) after every
+// profitable recapture on the target square has been played out, including x-ray attackers
+// Pins are ignored, and the move is assumed to be pseudo-legal
+func (b *Board) SEE(m Move) int {
+	var (
+		gain [32]int
+		d    int
+
+		to       = int(m.To())
+		fromMask = uint64(1) << m.From()
+		toMask   = uint64(1) << to
+		occupied = b.occupied()
+
+		own     = b.whitePieces()
+		enemies = b.blackPieces()
+	)
+
+	if b.Turn == BlackTurn {
+		own, enemies = enemies, own
+	}
+
+	promotionRanks := uint64(0xff000000000000ff)
+
+	gain[0] = m.Capture().value()
+	attackerValue := m.PieceType().value()
+
+	if m.Promotion() != NoPromotion {
+		gain[0] += m.Promotion().value() - pawnValue
+		attackerValue = m.Promotion().value()
+	}
+
+	if m.EnPassant() {
+		gain[0] = pawnValue
+		if b.Turn == WhiteTurn {
+			occupied ^= toMask >> 8
+		} else {
+			occupied ^= toMask << 8
+		}
+	}
+
+	occupied ^= fromMask
+	turn := !b.Turn
+
+	for {
+		attackers := b.attackersTo(to, occupied) & occupied
+
+		side, opponents := enemies, own
+		if turn == b.Turn {
+			side, opponents = own, enemies
+		}
+		sideAttackers := attackers & side
+
+		attacker, pieceType := b.leastValuableAttacker(sideAttackers, turn)
+		if pieceType == NoType {
+			break
+		}
+
+		// The king may only recapture if the square is no longer defended
+		if pieceType == KingType {
+			remaining := occupied ^ attacker
+			if b.attackersTo(to, remaining)&remaining&opponents != 0 {
+				break
+			}
+		}
+
+		d++
+		gain[d] = attackerValue - gain[d-1]
+		attackerValue = pieceType.value()
+
+		if pieceType == PawnType && toMask&promotionRanks != 0 {
+			gain[d] += queenValue - pawnValue
+			attackerValue = queenValue
+		}
+
+		occupied ^= attacker
+		turn = !turn
+	}
+
+	for ; d > 0; d-- {
+		gain[d-1] = -max(-gain[d-1], gain[d])
+	}
+
+	return gain[0]
+}
+
+// Returns whether the static exchange evaluation of m is at least threshold
+func (b *Board) SEEGe(m Move, threshold int) bool {
+	return b.SEE(m) >= threshold
+}
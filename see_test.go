@@ -0,0 +1,90 @@
+package chess_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func findMove(t *testing.T, b *chess.Board, s string) chess.Move {
+	ms, _ := b.LegalMoves()
+	for _, m := range ms {
+		if m.String() == s {
+			return m
+		}
+	}
+	t.Fatalf("%s is not a legal move", s)
+	return 0
+}
+
+func TestSEE(t *testing.T) {
+	tests := []struct {
+		Name     string
+		FEN      string
+		Move     string
+		Expected int
+	}{
+		{
+			Name:     "Undefended pawn",
+			FEN:      "1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1",
+			Move:     "e1e5",
+			Expected: 100,
+		},
+		{
+			Name:     "X-ray attackers on both sides",
+			FEN:      "1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1",
+			Move:     "d3e5",
+			Expected: -200,
+		},
+		{
+			Name:     "Defended pawn",
+			FEN:      "3k4/3p4/8/8/8/8/8/3RK3 w - - 0 1",
+			Move:     "d1d7",
+			Expected: -400,
+		},
+		{
+			Name:     "King cannot recapture a defended piece",
+			FEN:      "3k4/3p4/8/1B6/8/8/8/3RK3 w - - 0 1",
+			Move:     "d1d7",
+			Expected: 100,
+		},
+		{
+			Name:     "En passant",
+			FEN:      "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1",
+			Move:     "e5d6",
+			Expected: 100,
+		},
+		{
+			Name:     "Quiet move to a safe square",
+			FEN:      "4k3/8/3p4/8/8/3N4/8/4K3 w - - 0 1",
+			Move:     "d3f4",
+			Expected: 0,
+		},
+		{
+			Name:     "Knight hangs to pawn",
+			FEN:      "4k3/8/3p4/8/8/3N4/8/4K3 w - - 0 1",
+			Move:     "d3e5",
+			Expected: -300,
+		},
+		{
+			Name:     "Promotion",
+			FEN:      "4k3/P7/8/8/8/8/8/4K3 w - - 0 1",
+			Move:     "a7a8q",
+			Expected: 800,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			b, err := chess.BoardFromFEN(tt.FEN)
+			require.NoError(t, err)
+
+			m := findMove(t, &b, tt.Move)
+			assert.Equal(t, tt.Expected, b.SEE(m))
+			assert.True(t, b.SEEGe(m, tt.Expected))
+			assert.False(t, b.SEEGe(m, tt.Expected+1))
+		})
+	}
+}
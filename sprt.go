@@ -0,0 +1,113 @@
+package chess
+
+import "math"
+
+// Counts of game pairs, played from the same opening with colours swapped, by how many points the first player scored
+// in them: 0, 1/2, 1, 3/2 and 2
+type Pentanomial [5]int
+
+func (p Pentanomial) Pairs() int {
+	return p[0] + p[1] + p[2] + p[3] + p[4]
+}
+
+// Returns the mean and variance of the score per game, from the pairs
+// Empty counts are treated as half a pair, otherwise a run of identical pairs would have no variance at all and the
+// test would finish after the first one
+func (p Pentanomial) meanVariance() (mean, variance float64) {
+	var counts [5]float64
+	var n float64
+	for i, c := range p {
+		counts[i] = float64(c)
+		if c == 0 {
+			counts[i] = 0.5
+		}
+		n += counts[i]
+	}
+
+	for i, c := range counts {
+		mean += c / n * float64(i) / 4
+	}
+	for i, c := range counts {
+		variance += c / n * math.Pow(float64(i)/4-mean, 2)
+	}
+	return mean, variance
+}
+
+// Returns the pentanomial statistics of the pairs played so far, a trailing unpaired game is left out
+func (r *MatchResult) Pentanomial() Pentanomial {
+	var p Pentanomial
+	for i := 0; i+1 < len(r.Games); i += 2 {
+		points := 0
+		for _, g := range r.Games[i : i+2] {
+			switch {
+			case g.Result == "1/2-1/2":
+				points++
+			case (g.Result == "1-0") == g.FirstIsWhite:
+				points += 2
+			}
+		}
+		p[points]++
+	}
+	return p
+}
+
+type SPRTDecision int
+
+const (
+	SPRTContinue SPRTDecision = iota
+	SPRTAcceptH0              // the first player is no more than Elo0 stronger, so a change being tested fails
+	SPRTAcceptH1              // the first player is at least Elo1 stronger, so a change being tested passes
+)
+
+func (d SPRTDecision) String() string {
+	switch d {
+	case SPRTAcceptH0:
+		return "H0 accepted"
+	case SPRTAcceptH1:
+		return "H1 accepted"
+	default:
+		return "continue"
+	}
+}
+
+// A sequential probability ratio test between two hypotheses about the first player's Elo advantage, H0: elo = Elo0
+// and H1: elo = Elo1, with false positive rate Alpha and false negative rate Beta
+type SPRT struct {
+	Elo0, Elo1  float64
+	Alpha, Beta float64
+}
+
+// Returns the log likelihood ratio bounds, H0 is accepted below lower and H1 above upper
+func (s SPRT) Bounds() (lower, upper float64) {
+	return math.Log(s.Beta / (1 - s.Alpha)), math.Log((1 - s.Beta) / s.Alpha)
+}
+
+// Returns the log likelihood ratio of H1 against H0 for the pairs
+// This is the generalised SPRT approximation, which treats the pair scores as normally distributed
+func (s SPRT) LLR(p Pentanomial) float64 {
+	if p.Pairs() == 0 {
+		return 0
+	}
+
+	mean, variance := p.meanVariance()
+	s0, s1 := scoreFromElo(s.Elo0), scoreFromElo(s.Elo1)
+	return float64(p.Pairs()) * (s1 - s0) * (2*mean - s0 - s1) / (2 * variance)
+}
+
+// Returns whether the test has finished, and which hypothesis it accepted if it has
+func (s SPRT) Decide(p Pentanomial) SPRTDecision {
+	llr := s.LLR(p)
+	lower, upper := s.Bounds()
+	switch {
+	case llr >= upper:
+		return SPRTAcceptH1
+	case llr <= lower:
+		return SPRTAcceptH0
+	}
+	return SPRTContinue
+}
+
+// The inverse of EloFromScore
+func scoreFromElo(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
@@ -0,0 +1,65 @@
+package chess_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestSPRT(t *testing.T) {
+	s := chess.SPRT{Elo0: 0, Elo1: 5, Alpha: 0.05, Beta: 0.05}
+	lower, upper := s.Bounds()
+	assert.InDelta(t, -2.944, lower, 0.001)
+	assert.InDelta(t, 2.944, upper, 0.001)
+
+	assert.Zero(t, s.LLR(chess.Pentanomial{}))
+	assert.InDelta(t, 0.8251, s.LLR(chess.Pentanomial{10, 40, 100, 50, 20}), 0.0001)
+
+	assert.Equal(t, chess.SPRTContinue, s.Decide(chess.Pentanomial{10, 40, 100, 50, 20}))
+	assert.Equal(t, chess.SPRTAcceptH1, s.Decide(chess.Pentanomial{10, 40, 100, 90, 50}))
+	assert.Equal(t, chess.SPRTAcceptH0, s.Decide(chess.Pentanomial{30, 100, 200, 60, 10}))
+}
+
+func TestMatchPentanomial(t *testing.T) {
+	games := func(results ...string) []chess.MatchGame {
+		var gs []chess.MatchGame
+		for i, r := range results {
+			gs = append(gs, chess.MatchGame{Result: r, FirstIsWhite: i%2 == 0})
+		}
+		return gs
+	}
+
+	r := chess.MatchResult{Games: games(
+		"1-0", "0-1", // won both
+		"1-0", "1-0", // won one, lost one
+		"1/2-1/2", "1-0", // drew one, lost one
+		"0-1", "1/2-1/2", // lost one, drew one
+		"1-0", // unpaired
+	)}
+	assert.Equal(t, chess.Pentanomial{0, 2, 1, 0, 1}, r.Pentanomial())
+	assert.Equal(t, 4, r.Pentanomial().Pairs())
+}
+
+func TestRunMatchSPRT(t *testing.T) {
+	fake := startFakeUCIEngine(t, &fakeUCIEngine{})
+	defer fake.Close()
+
+	decisions := 0
+	r, err := chess.RunMatch([2]chess.Player{newEnginePlayer("engine", 1), fake}, chess.MatchOptions{
+		SPRT:            &chess.SPRT{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05},
+		ResignMoveCount: 1,
+		ResignScore:     500,
+		Progress: func(r *chess.MatchResult) {
+			if r.SPRT != chess.SPRTContinue {
+				decisions++
+			}
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, chess.SPRTAcceptH1, r.SPRT)
+	assert.Equal(t, 1, decisions)
+	assert.Zero(t, len(r.Games)%2)
+	assert.Equal(t, len(r.Games)/2, r.Pentanomial()[4])
+}
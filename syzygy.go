@@ -0,0 +1,1194 @@
+package chess
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInvalidTablebase = errors.New("Invalid tablebase")
+	ErrNotInTablebase   = errors.New("Position not in tablebase")
+)
+
+// Result of a position with perfect play, from the side to move's point of view
+// Cursed wins and blessed losses are drawn by the 50 move rule
+type WDL int
+
+const (
+	WDLLoss        WDL = -2
+	WDLBlessedLoss WDL = -1
+	WDLDraw        WDL = 0
+	WDLCursedWin   WDL = 1
+	WDLWin         WDL = 2
+)
+
+func (w WDL) String() string {
+	switch w {
+	case WDLLoss:
+		return "loss"
+	case WDLBlessedLoss:
+		return "blessed loss"
+	case WDLDraw:
+		return "draw"
+	case WDLCursedWin:
+		return "cursed win"
+	case WDLWin:
+		return "win"
+	default:
+		return fmt.Sprintf("WDL(%d)", int(w))
+	}
+}
+
+// This is a port of the probing code shared by most engines which use Syzygy tables (Ronald de Man's original,
+// Fathom and Stockfish), the file format is only documented by that code
+// Squares here are numbered a1 = 0 ... h8 = 63, i.e. Board indexes ^ 7, and pieces are numbered pawn = 1 ... king = 6,
+// plus 8 for black
+
+const (
+	tbMaxPieces = 7
+
+	// Most positions are wins or losses within this many plies of the root, see rootRank
+	tbMaxDTZ = 1 << 18
+)
+
+var (
+	wdlMagic = [4]byte{0x71, 0xE8, 0x23, 0x5D}
+	dtzMagic = [4]byte{0xD7, 0x66, 0x0C, 0xA5}
+)
+
+// Flags stored for each table
+const (
+	tbFlagSTM         = 1 // DTZ tables only store one side to move, this is set when it's black
+	tbFlagMapped      = 2
+	tbFlagWinPlies    = 4
+	tbFlagLossPlies   = 8
+	tbFlagWide        = 16
+	tbFlagSingleValue = 128
+)
+
+var (
+	tbBinomial      [6][64]uint64 // ways to choose k squares from n
+	tbMapPawns      [64]int       // encodes a2-h7, the leading pawn is the one with the highest value
+	tbLeadPawnIdx   [6][64]uint64
+	tbLeadPawnsSize [6][4]uint64
+	tbMapB1H1H7     [64]int // squares below the a1-h8 diagonal
+	tbMapA1D1D4     [64]int // the a1-d1-d4 triangle, diagonal last
+	tbMapKK         [10][64]int
+)
+
+func tbFile(s int) int {
+	return s & 7
+}
+
+func tbRank(s int) int {
+	return s >> 3
+}
+
+// Positive above the a1-h8 diagonal, negative below
+func offA1H8(s int) int {
+	return tbRank(s) - tbFile(s)
+}
+
+func init() {
+	code := 0
+	for s := range 64 {
+		if offA1H8(s) < 0 {
+			tbMapB1H1H7[s] = code
+			code++
+		}
+	}
+
+	var diagonal []int
+	code = 0
+	for s := range 28 { // a1 ... d4
+		if offA1H8(s) < 0 && tbFile(s) <= 3 {
+			tbMapA1D1D4[s] = code
+			code++
+		} else if offA1H8(s) == 0 && tbFile(s) <= 3 {
+			diagonal = append(diagonal, s)
+		}
+	}
+	for _, s := range diagonal {
+		tbMapA1D1D4[s] = code
+		code++
+	}
+
+	// Every legal placement of two kings with the first in the a1-d1-d4 triangle, when the first is on the diagonal the
+	// second can't be above it. Placements with both on the diagonal come last
+	var bothOnDiagonal [][2]int
+	code = 0
+	for idx := range 10 {
+		for s1 := range 28 {
+			if tbMapA1D1D4[s1] != idx || (idx == 0 && s1 != 1) { // b1 is mapped to 0
+				continue
+			}
+			for s2 := range 64 {
+				switch {
+				case abs(tbRank(s1)-tbRank(s2)) <= 1 && abs(tbFile(s1)-tbFile(s2)) <= 1:
+					continue // touching, or the same square
+				case offA1H8(s1) == 0 && offA1H8(s2) > 0:
+					continue
+				case offA1H8(s1) == 0 && offA1H8(s2) == 0:
+					bothOnDiagonal = append(bothOnDiagonal, [2]int{idx, s2})
+				default:
+					tbMapKK[idx][s2] = code
+					code++
+				}
+			}
+		}
+	}
+	for _, p := range bothOnDiagonal {
+		tbMapKK[p[0]][p[1]] = code
+		code++
+	}
+
+	tbBinomial[0][0] = 1
+	for n := 1; n < 64; n++ {
+		for k := 0; k < 6 && k <= n; k++ {
+			if k > 0 {
+				tbBinomial[k][n] += tbBinomial[k-1][n-1]
+			}
+			if k < n {
+				tbBinomial[k][n] += tbBinomial[k][n-1]
+			}
+		}
+	}
+
+	// a2 = 47, h2 = 46, a3 = 45 ... h7 = 36, b2 = 35 ... e7 = 0
+	available := 47
+	for leadPawns := 1; leadPawns <= 5; leadPawns++ {
+		for f := range 4 {
+			var idx uint64
+			for r := 1; r <= 6; r++ {
+				s := r*8 + f
+				if leadPawns == 1 {
+					tbMapPawns[s] = available
+					tbMapPawns[s^7] = available - 1
+					available -= 2
+				}
+				tbLeadPawnIdx[leadPawns][s] = idx
+				idx += tbBinomial[leadPawns-1][tbMapPawns[s]]
+			}
+			tbLeadPawnsSize[leadPawns][f] = idx
+		}
+	}
+}
+
+// Decompression data for one side to move and leading pawn file of a table
+type tbPairs struct {
+	data  []byte
+	flags byte
+
+	pieces   [tbMaxPieces]int
+	groupLen [tbMaxPieces + 1]int // zero terminated
+	groupIdx [tbMaxPieces + 1]uint64
+
+	blockSize       uint64
+	span            uint64
+	sparseIndexSize uint64
+	blocksNum       uint64
+	blockLengthSize uint64
+	minSymLen       int // the value itself for single value tables
+	maxSymLen       int
+	base64          []uint64
+	symLen          []uint8
+
+	// Offsets into data
+	lowestSym   int
+	btree       int
+	sparseIndex int
+	blockLength int
+	blocks      int
+
+	mapIdx [4]int // DTZ only
+}
+
+type tbTable struct {
+	path string
+	dtz  bool
+
+	// Material codes with the stronger side (the first in the file name) as white, and as black
+	key, key2 string
+
+	pieceCount      int
+	hasPawns        bool
+	hasUniquePieces bool
+	pawnCount       [2]int // the leading colour first
+
+	once   sync.Once
+	err    error
+	data   []byte
+	dtzMap int
+	pairs  [2][4]*tbPairs // by side to move, then leading pawn file
+}
+
+// A directory of Syzygy tables, which are only read the first time they're probed
+type Tablebase struct {
+	wdl, dtz    map[string]*tbTable // keyed by material code with white's pieces first, in both orientations
+	cardinality int
+}
+
+// Finds the WDL (.rtbw) and DTZ (.rtbz) tables in dir
+// WDL tables are needed for any probing, DTZ tables are only used to choose moves at the root
+func LoadTablebase(dir string) (*Tablebase, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tb := &Tablebase{wdl: map[string]*tbTable{}, dtz: map[string]*tbTable{}}
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if entry.IsDir() || (ext != ".rtbw" && ext != ".rtbz") {
+			continue
+		}
+
+		t, ok := newTBTable(strings.TrimSuffix(name, filepath.Ext(name)))
+		if !ok {
+			continue // not a table, e.g. something.rtbw.part
+		}
+		t.path = filepath.Join(dir, name)
+		t.dtz = ext == ".rtbz"
+
+		tables := tb.wdl
+		if t.dtz {
+			tables = tb.dtz
+		}
+		tables[t.key] = t
+		tables[t.key2] = t
+
+		if !t.dtz {
+			tb.cardinality = max(tb.cardinality, t.pieceCount)
+		}
+	}
+
+	if tb.cardinality == 0 {
+		return nil, fmt.Errorf("%w: no .rtbw files in %s", ErrInvalidTablebase, dir)
+	}
+	return tb, nil
+}
+
+// Largest number of pieces, kings included, covered by a WDL table
+func (tb *Tablebase) Cardinality() int {
+	return tb.cardinality
+}
+
+const tbPieceSymbols = "PNBRQK"
+
+// Parses a table name like KRPvKR
+func newTBTable(name string) (*tbTable, bool) {
+	white, black, ok := strings.Cut(name, "v")
+	if !ok || !validTBSide(white) || !validTBSide(black) {
+		return nil, false
+	}
+
+	var counts [2][6]int
+	for c, side := range []string{white, black} {
+		for _, r := range side {
+			counts[c][strings.IndexRune(tbPieceSymbols, r)]++
+		}
+	}
+
+	t := &tbTable{
+		key:        materialCode(counts[0], counts[1]),
+		key2:       materialCode(counts[1], counts[0]),
+		pieceCount: len(white) + len(black),
+		hasPawns:   counts[0][0]+counts[1][0] > 0,
+	}
+	if t.pieceCount > tbMaxPieces {
+		return nil, false
+	}
+
+	for c := range 2 {
+		for p := range 5 {
+			if counts[c][p] == 1 {
+				t.hasUniquePieces = true
+			}
+		}
+	}
+
+	// When both sides have pawns, the side with fewer leads since that compresses better
+	if counts[1][0] == 0 || (counts[0][0] > 0 && counts[1][0] >= counts[0][0]) {
+		t.pawnCount = [2]int{counts[0][0], counts[1][0]}
+	} else {
+		t.pawnCount = [2]int{counts[1][0], counts[0][0]}
+	}
+
+	return t, true
+}
+
+func validTBSide(s string) bool {
+	return strings.Count(s, "K") == 1 && strings.HasPrefix(s, "K") && strings.Trim(s, tbPieceSymbols) == ""
+}
+
+// Writes a material signature with kings, queens, rooks, bishops, knights then pawns, as table files are named
+func materialCode(white, black [6]int) string {
+	var sb strings.Builder
+	for c, counts := range [2][6]int{white, black} {
+		if c == 1 {
+			sb.WriteByte('v')
+		}
+		sb.WriteByte('K')
+		for p := 4; p >= 0; p-- {
+			sb.WriteString(strings.Repeat(string(tbPieceSymbols[p]), counts[p]))
+		}
+	}
+	return sb.String()
+}
+
+func (b *Board) materialCode() string {
+	counts := b.materialCounts()
+	return materialCode(counts[0], counts[1])
+}
+
+// Maps and parses the file, only once however many times it's called
+func (t *tbTable) load() error {
+	t.once.Do(func() {
+		data, err := mapTableFile(t.path)
+		if err != nil {
+			t.err = err
+			return
+		}
+		if err := t.parse(data); err != nil {
+			t.err = fmt.Errorf("%s: %w", t.path, err)
+		}
+	})
+	return t.err
+}
+
+func (t *tbTable) parse(data []byte) (err error) {
+	magic := wdlMagic
+	if t.dtz {
+		magic = dtzMagic
+	}
+	if len(data) < 5 || [4]byte(data[:4]) != magic {
+		return fmt.Errorf("%w: bad magic", ErrInvalidTablebase)
+	}
+	if len(data)%64 != 16 {
+		return fmt.Errorf("%w: unexpected file size %d", ErrInvalidTablebase, len(data))
+	}
+
+	// A corrupt file can point anywhere, bounds are checked here rather than at every read
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidTablebase, r)
+		}
+	}()
+
+	flags := data[4]
+	if (flags&2 != 0) != t.hasPawns || (flags&1 != 0) != (t.key != t.key2) {
+		return fmt.Errorf("%w: header doesn't match the file name", ErrInvalidTablebase)
+	}
+	pos := 5
+
+	sides := 1
+	if !t.dtz && t.key != t.key2 {
+		sides = 2
+	}
+	files := 1
+	if t.hasPawns {
+		files = 4
+	}
+	pp := t.hasPawns && t.pawnCount[1] > 0 // pawns on both sides
+
+	for f := range files {
+		order := [2][2]int{{int(data[pos] & 0xF), 0xF}, {int(data[pos] >> 4), 0xF}}
+		if pp {
+			order[0][1], order[1][1] = int(data[pos+1]&0xF), int(data[pos+1]>>4)
+			pos++
+		}
+		pos++
+
+		for i := range sides {
+			t.pairs[i][f] = &tbPairs{data: data}
+		}
+		for k := range t.pieceCount {
+			for i := range sides {
+				if i == 0 {
+					t.pairs[i][f].pieces[k] = int(data[pos] & 0xF)
+				} else {
+					t.pairs[i][f].pieces[k] = int(data[pos] >> 4)
+				}
+			}
+			pos++
+		}
+		for i := range sides {
+			t.setGroups(t.pairs[i][f], order[i], f)
+		}
+	}
+	pos += pos & 1
+
+	for f := range files {
+		for i := range sides {
+			pos = t.pairs[i][f].setSizes(pos)
+		}
+	}
+
+	if t.dtz {
+		pos = t.setDTZMap(data, pos, files)
+	}
+
+	for f := range files {
+		for i := range sides {
+			p := t.pairs[i][f]
+			p.sparseIndex = pos
+			pos += int(p.sparseIndexSize) * 6
+		}
+	}
+	for f := range files {
+		for i := range sides {
+			p := t.pairs[i][f]
+			p.blockLength = pos
+			pos += int(p.blockLengthSize) * 2
+		}
+	}
+	for f := range files {
+		for i := range sides {
+			p := t.pairs[i][f]
+			pos = (pos + 63) &^ 63
+			p.blocks = pos
+			pos += int(p.blocksNum * p.blockSize)
+		}
+	}
+
+	if pos > len(data) {
+		return fmt.Errorf("%w: truncated", ErrInvalidTablebase)
+	}
+	t.data = data
+	return nil
+}
+
+// Works out how pieces are grouped when calculating indexes, and how much each group's index is scaled by
+func (t *tbTable) setGroups(p *tbPairs, order [2]int, f int) {
+	n := 0
+	firstLen := 2
+	if t.hasPawns {
+		firstLen = 0
+	} else if t.hasUniquePieces {
+		firstLen = 3
+	}
+
+	// e.g. KRKN has groups (3, 1), the first three pieces are encoded together
+	p.groupLen[0] = 1
+	for i := 1; i < t.pieceCount; i++ {
+		firstLen--
+		if firstLen > 0 || p.pieces[i] == p.pieces[i-1] {
+			p.groupLen[n]++
+		} else {
+			n++
+			p.groupLen[n] = 1
+		}
+	}
+	n++
+	p.groupLen[n] = 0
+
+	// The leading group is at position order[0] and the remaining pawns (if both sides have any) are at order[1],
+	// the rest of the groups follow in order
+	pp := t.hasPawns && t.pawnCount[1] > 0
+	next := 1
+	if pp {
+		next = 2
+	}
+	freeSquares := 64 - p.groupLen[0]
+	if pp {
+		freeSquares -= p.groupLen[1]
+	}
+
+	idx := uint64(1)
+	for k := 0; next < n || k == order[0] || k == order[1]; k++ {
+		switch {
+		case k == order[0]:
+			p.groupIdx[0] = idx
+			switch {
+			case t.hasPawns:
+				idx *= tbLeadPawnsSize[p.groupLen[0]][f]
+			case t.hasUniquePieces:
+				idx *= 31332
+			default:
+				idx *= 462
+			}
+		case k == order[1]:
+			p.groupIdx[1] = idx
+			idx *= tbBinomial[p.groupLen[1]][48-p.groupLen[0]]
+		default:
+			p.groupIdx[next] = idx
+			idx *= tbBinomial[p.groupLen[next]][freeSquares]
+			freeSquares -= p.groupLen[next]
+			next++
+		}
+	}
+	p.groupIdx[n] = idx
+}
+
+// Reads the compression parameters, returning the position after them
+func (p *tbPairs) setSizes(pos int) int {
+	data := p.data
+	p.flags = data[pos]
+	pos++
+
+	if p.flags&tbFlagSingleValue != 0 {
+		p.minSymLen = int(data[pos])
+		return pos + 1
+	}
+
+	n := 0
+	for p.groupLen[n] != 0 {
+		n++
+	}
+	size := p.groupIdx[n]
+
+	p.blockSize = 1 << data[pos]
+	p.span = 1 << data[pos+1]
+	p.sparseIndexSize = (size + p.span - 1) / p.span
+	padding := uint64(data[pos+2])
+	p.blocksNum = uint64(binary.LittleEndian.Uint32(data[pos+3:]))
+	p.blockLengthSize = p.blocksNum + padding // padded so the sparse index can't point past the end
+	p.maxSymLen = int(data[pos+7])
+	p.minSymLen = int(data[pos+8])
+	pos += 9
+	p.lowestSym = pos
+
+	// Canonical Huffman codes, base64[i] is the smallest code of length i + minSymLen, padded to 64 bits
+	p.base64 = make([]uint64, p.maxSymLen-p.minSymLen+1)
+	for i := len(p.base64) - 2; i >= 0; i-- {
+		p.base64[i] = (p.base64[i+1] + uint64(p.lowest(i)) - uint64(p.lowest(i+1))) / 2
+	}
+	for i := range p.base64 {
+		p.base64[i] <<= 64 - i - p.minSymLen
+	}
+	pos += len(p.base64) * 2
+
+	symbols := int(binary.LittleEndian.Uint16(data[pos:]))
+	pos += 2
+	p.btree = pos
+
+	// Symbols are pairs of other symbols (Recursive Pairing), symLen is one less than the number of values each expands to
+	p.symLen = make([]uint8, symbols)
+	visited := make([]bool, symbols)
+	for s := range symbols {
+		if !visited[s] {
+			p.symLen[s] = p.setSymLen(s, visited)
+		}
+	}
+
+	return pos + symbols*3 + symbols&1
+}
+
+func (p *tbPairs) setSymLen(s int, visited []bool) uint8 {
+	visited[s] = true
+	right := p.right(s)
+	if right == 0xFFF {
+		return 0
+	}
+	left := p.left(s)
+	if !visited[left] {
+		p.symLen[left] = p.setSymLen(left, visited)
+	}
+	if !visited[right] {
+		p.symLen[right] = p.setSymLen(right, visited)
+	}
+	return p.symLen[left] + p.symLen[right] + 1
+}
+
+// Lowest symbol with a code of length i + minSymLen
+func (p *tbPairs) lowest(i int) int {
+	return int(binary.LittleEndian.Uint16(p.data[p.lowestSym+2*i:]))
+}
+
+// Symbols are stored as two 12 bit halves, for leaves the left half is the value
+func (p *tbPairs) left(s int) int {
+	lr := p.data[p.btree+3*s:]
+	return int(lr[1]&0xF)<<8 | int(lr[0])
+}
+
+func (p *tbPairs) right(s int) int {
+	lr := p.data[p.btree+3*s:]
+	return int(lr[2])<<4 | int(lr[1]>>4)
+}
+
+// Records where each file's value maps start, returning the position after them
+func (t *tbTable) setDTZMap(data []byte, pos, files int) int {
+	t.dtzMap = pos
+	for f := range files {
+		p := t.pairs[0][f]
+		if p.flags&tbFlagMapped == 0 {
+			continue
+		}
+		if p.flags&tbFlagWide != 0 {
+			pos += pos & 1
+			for i := range 4 {
+				p.mapIdx[i] = (pos-t.dtzMap)/2 + 1
+				pos += 2*int(binary.LittleEndian.Uint16(data[pos:])) + 2
+			}
+		} else {
+			for i := range 4 {
+				p.mapIdx[i] = pos - t.dtzMap + 1
+				pos += int(data[pos]) + 1
+			}
+		}
+	}
+	return pos + pos&1
+}
+
+// Returns the value stored at idx
+func (p *tbPairs) decompress(idx uint64) int {
+	if p.flags&tbFlagSingleValue != 0 {
+		return p.minSymLen
+	}
+
+	// The sparse index stores the block and offset of every span'th value, starting half a span in
+	k := idx / p.span
+	entry := p.data[p.sparseIndex+6*int(k):]
+	block := int(binary.LittleEndian.Uint32(entry))
+	offset := int(binary.LittleEndian.Uint16(entry[4:]))
+	offset += int(idx%p.span) - int(p.span/2)
+
+	// Each block stores blockLength + 1 values
+	blockLength := func(i int) int {
+		return int(binary.LittleEndian.Uint16(p.data[p.blockLength+2*i:]))
+	}
+	for offset < 0 {
+		block--
+		offset += blockLength(block) + 1
+	}
+	for offset > blockLength(block) {
+		offset -= blockLength(block) + 1
+		block++
+	}
+
+	ptr := p.blocks + block*int(p.blockSize)
+	buf := binary.BigEndian.Uint64(p.data[ptr:])
+	ptr += 8
+	bufSize := 64
+
+	var sym int
+	for {
+		l := 0
+		for buf < p.base64[l] {
+			l++
+		}
+		sym = int((buf-p.base64[l])>>(64-l-p.minSymLen)) + p.lowest(l)
+
+		if offset < int(p.symLen[sym])+1 {
+			break
+		}
+		offset -= int(p.symLen[sym]) + 1
+
+		l += p.minSymLen
+		buf <<= l
+		bufSize -= l
+		if bufSize <= 32 {
+			bufSize += 32
+			buf |= uint64(binary.BigEndian.Uint32(p.data[ptr:])) << (64 - bufSize)
+			ptr += 4
+		}
+	}
+
+	// Expand sym until we reach the leaf at offset
+	for p.symLen[sym] != 0 {
+		left := p.left(sym)
+		if offset < int(p.symLen[left])+1 {
+			sym = left
+		} else {
+			offset -= int(p.symLen[left]) + 1
+			sym = p.right(sym)
+		}
+	}
+
+	return p.left(sym)
+}
+
+func (t *tbTable) sideToMoveStored(stm, f int) bool {
+	return int(t.pairs[0][f].flags&tbFlagSTM) == stm || (t.key == t.key2 && !t.hasPawns)
+}
+
+// Looks up the position, code is b's material code
+// changeSTM is returned when the position is in a DTZ table which only stores the other side to move
+func (t *tbTable) probe(b *Board, code string, wdl WDL) (value int, changeSTM bool) {
+	d, f, idx, changeSTM := t.index(b, code)
+	if changeSTM {
+		return 0, true
+	}
+	return t.mapScore(f, d.decompress(idx), wdl), false
+}
+
+// Returns the position's index into d, the data for its side to move and leading pawn file f
+func (t *tbTable) index(b *Board, code string) (d *tbPairs, f int, idx uint64, changeSTM bool) {
+	var (
+		squares [tbMaxPieces]int
+		pieces  [tbMaxPieces]int
+		size    int
+	)
+
+	// Tables are stored with the stronger side as white, and symmetric tables only with white to move,
+	// otherwise the colours are switched and the board flipped vertically
+	flip := (t.key == t.key2 && b.Turn == BlackTurn) || code != t.key
+	flipColour, flipSquares := 0, 0
+	if flip {
+		flipColour, flipSquares = 8, 56
+	}
+	stm := 0
+	if flip != (b.Turn == BlackTurn) {
+		stm = 1
+	}
+
+	boards := b.pieceBoards()
+
+	// Pawn tables are split by the file of the leading pawn, the one nearest the edge and then the lowest rank,
+	// which has to be on files a-d
+	var leadPawns uint64
+	leadPawnsCnt := 0
+	if t.hasPawns {
+		colour := (t.pairs[0][0].pieces[0] ^ flipColour) >> 3
+		leadPawns = boards[6*colour]
+		for bb := leadPawns; bb != 0; bb &= bb - 1 {
+			squares[size] = (bits.TrailingZeros64(bb) ^ 7) ^ flipSquares
+			size++
+		}
+		leadPawnsCnt = size
+
+		lead := 0
+		for i := 1; i < leadPawnsCnt; i++ {
+			if tbMapPawns[squares[i]] > tbMapPawns[squares[lead]] {
+				lead = i
+			}
+		}
+		squares[0], squares[lead] = squares[lead], squares[0]
+		f = min(tbFile(squares[0]), 7-tbFile(squares[0]))
+	}
+
+	if t.dtz && !t.sideToMoveStored(stm, f) {
+		return nil, f, 0, true
+	}
+
+	for p, bb := range boards {
+		for bb &^= leadPawns; bb != 0; bb &= bb - 1 {
+			squares[size] = (bits.TrailingZeros64(bb) ^ 7) ^ flipSquares
+			pieces[size] = (p%6 + 1 + 8*(p/6)) ^ flipColour
+			size++
+		}
+	}
+
+	d = t.pairs[0][f]
+	if !t.dtz {
+		d = t.pairs[stm][f]
+	}
+
+	// Put the pieces in the order the table expects
+	for i := leadPawnsCnt; i < size-1; i++ {
+		for j := i + 1; j < size; j++ {
+			if d.pieces[i] == pieces[j] {
+				pieces[i], pieces[j] = pieces[j], pieces[i]
+				squares[i], squares[j] = squares[j], squares[i]
+				break
+			}
+		}
+	}
+
+	// Flip horizontally so the leading piece is on files a-d
+	if tbFile(squares[0]) > 3 {
+		for i := range size {
+			squares[i] ^= 7
+		}
+	}
+
+	if t.hasPawns {
+		idx = tbLeadPawnIdx[leadPawnsCnt][squares[0]]
+		lead := squares[1:leadPawnsCnt]
+		sort.SliceStable(lead, func(i, j int) bool { return tbMapPawns[lead[i]] < tbMapPawns[lead[j]] })
+		for i := 1; i < leadPawnsCnt; i++ {
+			idx += tbBinomial[i][tbMapPawns[squares[i]]]
+		}
+	} else {
+		// Without pawns, flip vertically so the leading piece is on ranks 1-4, then along the a1-h8 diagonal so the
+		// first piece of the leading group which isn't on it is below it
+		if tbRank(squares[0]) > 3 {
+			for i := range size {
+				squares[i] ^= 56
+			}
+		}
+		for i := range d.groupLen[0] {
+			if offA1H8(squares[i]) == 0 {
+				continue
+			}
+			if offA1H8(squares[i]) > 0 {
+				for j := i; j < size; j++ {
+					squares[j] = ((squares[j] >> 3) | (squares[j] << 3)) & 63
+				}
+			}
+			break
+		}
+
+		if t.hasUniquePieces {
+			// The first three pieces are encoded together
+			s0, s1, s2 := squares[0], squares[1], squares[2]
+			adjust1 := 0
+			if s1 > s0 {
+				adjust1 = 1
+			}
+			adjust2 := 0
+			if s2 > s0 {
+				adjust2++
+			}
+			if s2 > s1 {
+				adjust2++
+			}
+
+			switch {
+			case offA1H8(s0) != 0:
+				idx = uint64((tbMapA1D1D4[s0]*63+s1-adjust1)*62 + s2 - adjust2)
+			case offA1H8(s1) != 0:
+				idx = uint64((6*63+tbRank(s0)*28+tbMapB1H1H7[s1])*62 + s2 - adjust2)
+			case offA1H8(s2) != 0:
+				idx = uint64(6*63*62 + 4*28*62 + tbRank(s0)*7*28 + (tbRank(s1)-adjust1)*28 + tbMapB1H1H7[s2])
+			default:
+				idx = uint64(6*63*62 + 4*28*62 + 4*7*28 + tbRank(s0)*7*6 + (tbRank(s1)-adjust1)*6 + tbRank(s2) - adjust2)
+			}
+		} else {
+			idx = uint64(tbMapKK[tbMapA1D1D4[squares[0]]][squares[1]])
+		}
+	}
+	idx *= d.groupIdx[0]
+
+	// The remaining groups, each in ascending square order, skipping squares taken by earlier groups
+	start := d.groupLen[0]
+	remainingPawns := t.hasPawns && t.pawnCount[1] > 0
+	for next := 1; d.groupLen[next] != 0; next++ {
+		group := squares[start : start+d.groupLen[next]]
+		sort.Ints(group)
+
+		var n uint64
+		for i, s := range group {
+			adjust := 0
+			for _, prev := range squares[:start] {
+				if s > prev {
+					adjust++
+				}
+			}
+			if remainingPawns {
+				adjust += 8
+			}
+			n += tbBinomial[i+1][s-adjust]
+		}
+
+		remainingPawns = false
+		idx += n * d.groupIdx[next]
+		start += len(group)
+	}
+
+	return d, f, idx, false
+}
+
+// Converts a stored value, for DTZ tables the result is in plies
+func (t *tbTable) mapScore(f, value int, wdl WDL) int {
+	if !t.dtz {
+		return value - 2
+	}
+
+	p := t.pairs[0][f]
+	if p.flags&tbFlagMapped != 0 {
+		i := p.mapIdx[[5]int{1, 3, 0, 2, 0}[wdl+2]] + value
+		if p.flags&tbFlagWide != 0 {
+			value = int(binary.LittleEndian.Uint16(t.data[t.dtzMap+2*i:]))
+		} else {
+			value = int(t.data[t.dtzMap+i])
+		}
+	}
+
+	if (wdl == WDLWin && p.flags&tbFlagWinPlies == 0) ||
+		(wdl == WDLLoss && p.flags&tbFlagLossPlies == 0) ||
+		wdl == WDLCursedWin || wdl == WDLBlessedLoss {
+		value *= 2
+	}
+	return value + 1
+}
+
+// Probes the WDL or DTZ table for b's material
+func (tb *Tablebase) probeTable(b *Board, dtz bool, wdl WDL) (int, bool, error) {
+	if bits.OnesCount64(b.occupied()) == 2 {
+		return 0, false, nil // kings only
+	}
+
+	code := b.materialCode()
+	tables := tb.wdl
+	if dtz {
+		tables = tb.dtz
+	}
+	t, ok := tables[code]
+	if !ok {
+		return 0, false, fmt.Errorf("%w: no table for %s", ErrNotInTablebase, code)
+	}
+	if err := t.load(); err != nil {
+		return 0, false, err
+	}
+
+	value, changeSTM := t.probe(b, code, wdl)
+	return value, changeSTM, nil
+}
+
+// Returns an error if b can't be probed, without reading any tables
+func (tb *Tablebase) checkProbe(b *Board) error {
+	if b.CastleRights != NoCastleRights {
+		return fmt.Errorf("%w: castling rights", ErrNotInTablebase)
+	}
+	if n := bits.OnesCount64(b.occupied()); n > tb.cardinality {
+		return fmt.Errorf("%w: %d pieces", ErrNotInTablebase, n)
+	}
+	return nil
+}
+
+// Tables store "don't care" values wherever a capture (or pawn move, for DTZ) is the best move, since it compresses
+// better, so the position's value is the best of those moves and the stored value
+// zeroingBest reports that a move which resets the 50 move counter is at least as good as anything else, in which case
+// the stored DTZ is meaningless
+func (tb *Tablebase) search(b *Board, pawnMoves bool) (wdl WDL, zeroingBest bool, err error) {
+	ms, _ := b.LegalMoves()
+
+	best, searched := WDLLoss, 0
+	for _, m := range ms {
+		if m.Capture() == NoCapture && !m.EnPassant() && (!pawnMoves || m.PieceType() != PawnType) {
+			continue
+		}
+		searched++
+
+		b.Move(m)
+		v, _, err := tb.search(b, false)
+		b.Unmove()
+		if err != nil {
+			return WDLDraw, false, err
+		}
+
+		if v = -v; v > best {
+			best = v
+			if v >= WDLWin {
+				return v, true, nil
+			}
+		}
+	}
+
+	// The stored value can be wrong when every move was searched, e.g. if en passant was possible
+	allSearched := searched > 0 && searched == len(ms)
+	value := best
+	if !allSearched {
+		stored, _, err := tb.probeTable(b, false, WDLDraw)
+		if err != nil {
+			return WDLDraw, false, err
+		}
+		value = WDL(stored)
+	}
+
+	if best >= value {
+		return best, best > WDLDraw || allSearched, nil
+	}
+	return value, false, nil
+}
+
+// Returns the result of b with perfect play, ignoring repetitions
+func (tb *Tablebase) ProbeWDL(b *Board) (WDL, error) {
+	if err := tb.checkProbe(b); err != nil {
+		return WDLDraw, err
+	}
+	wdl, _, err := tb.search(b, false)
+	return wdl, err
+}
+
+// DTZ of a position where the best move resets the 50 move counter
+func dtzBeforeZeroing(wdl WDL) int {
+	switch wdl {
+	case WDLWin:
+		return 1
+	case WDLCursedWin:
+		return 101
+	case WDLBlessedLoss:
+		return -101
+	case WDLLoss:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Returns the number of plies until the 50 move counter is reset by a capture or pawn move with perfect play,
+// positive when the side to move is winning, negative when losing and 0 for draws
+// Cursed wins and blessed losses are offset by 100
+// The result might be one more than the true distance, since some tables are stored in moves rather than plies
+func (tb *Tablebase) ProbeDTZ(b *Board) (int, error) {
+	if err := tb.checkProbe(b); err != nil {
+		return 0, err
+	}
+	return tb.probeDTZ(b)
+}
+
+func (tb *Tablebase) probeDTZ(b *Board) (int, error) {
+	wdl, zeroingBest, err := tb.search(b, true)
+	if err != nil || wdl == WDLDraw {
+		return 0, err
+	}
+	if zeroingBest {
+		return dtzBeforeZeroing(wdl), nil
+	}
+
+	dtz, changeSTM, err := tb.probeTable(b, true, wdl)
+	if err != nil {
+		return 0, err
+	}
+	if !changeSTM {
+		if wdl == WDLBlessedLoss || wdl == WDLCursedWin {
+			dtz += 100
+		}
+		return dtz * sign(int(wdl)), nil
+	}
+
+	// Only the other side to move is stored, so find the best DTZ one ply on
+	minDTZ := 0xFFFF
+	ms, _ := b.LegalMoves()
+	for _, m := range ms {
+		zeroing := m.IsNoisy()
+
+		b.Move(m)
+		var d int
+		if zeroing {
+			// The DTZ before the move is wanted, but the result after it has to be checked
+			var v WDL
+			v, _, err = tb.search(b, false)
+			d = -dtzBeforeZeroing(v)
+		} else {
+			d, err = tb.probeDTZ(b)
+			d = -d
+		}
+		if d == 1 && b.InCheck() {
+			if replies, _ := b.LegalMoves(); len(replies) == 0 {
+				minDTZ = 1 // mate
+			}
+		}
+		b.Unmove()
+		if err != nil {
+			return 0, err
+		}
+
+		if !zeroing {
+			d += sign(d)
+		}
+		if d < minDTZ && sign(d) == sign(int(wdl)) {
+			minDTZ = d
+		}
+	}
+
+	if minDTZ == 0xFFFF {
+		return -1, nil // mated
+	}
+	return minDTZ, nil
+}
+
+// A legal move at the root, ranked by the tablebases
+type TBMove struct {
+	Move Move
+	WDL  WDL // result after the move, from the moving side's point of view, taking the 50 move counter into account
+	DTZ  int // from the root position, as ProbeDTZ
+	Rank int // higher is better, every move which wins comfortably within the 50 move rule ranks equally
+}
+
+// Ranks every legal move, best first, using DTZ tables
+// Among moves of equal rank, the fastest wins and slowest losses come first
+func (tb *Tablebase) ProbeRoot(b *Board) ([]TBMove, error) {
+	if err := tb.checkProbe(b); err != nil {
+		return nil, err
+	}
+
+	quiet := b.QuietMoveCounter()
+	ms, _ := b.LegalMoves()
+	moves := make([]TBMove, 0, len(ms))
+
+	for _, m := range ms {
+		b.Move(m)
+
+		var (
+			dtz int
+			err error
+		)
+		switch {
+		case m.IsNoisy():
+			var wdl WDL
+			wdl, _, err = tb.search(b, false)
+			dtz = dtzBeforeZeroing(-wdl)
+		case quiet+1 >= 100:
+			dtz = 0 // drawn by the 50 move rule
+		default:
+			dtz, err = tb.probeDTZ(b)
+			dtz = -dtz
+			dtz += sign(dtz)
+		}
+
+		// Mate counts as a zeroing move
+		if dtz == 2 && b.InCheck() {
+			if replies, _ := b.LegalMoves(); len(replies) == 0 {
+				dtz = 1
+			}
+		}
+		b.Unmove()
+		if err != nil {
+			return nil, err
+		}
+
+		moves = append(moves, TBMove{Move: m, DTZ: dtz, Rank: rootRank(dtz, quiet)})
+	}
+
+	for i := range moves {
+		switch r := moves[i].Rank; {
+		case r >= tbMaxDTZ-100:
+			moves[i].WDL = WDLWin
+		case r > 0:
+			moves[i].WDL = WDLCursedWin
+		case r == 0:
+			moves[i].WDL = WDLDraw
+		case r > -tbMaxDTZ+100:
+			moves[i].WDL = WDLBlessedLoss
+		default:
+			moves[i].WDL = WDLLoss
+		}
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		if moves[i].Rank != moves[j].Rank {
+			return moves[i].Rank > moves[j].Rank
+		}
+		return moves[i].DTZ < moves[j].DTZ
+	})
+	return moves, nil
+}
+
+// Wins which can be completed before the 50 move rule rank equally, as do losses the opponent can complete
+// Otherwise wins are better the sooner they zero, and losses are better the later they do
+func rootRank(dtz, quiet int) int {
+	switch {
+	case dtz > 0 && dtz+quiet <= 99:
+		return tbMaxDTZ
+	case dtz > 0:
+		return tbMaxDTZ - (dtz + quiet)
+	case dtz < 0 && -dtz*2+quiet < 100:
+		return -tbMaxDTZ
+	case dtz < 0:
+		return -tbMaxDTZ + (-dtz + quiet)
+	default:
+		return 0
+	}
+}
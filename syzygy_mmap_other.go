@@ -0,0 +1,10 @@
+//go:build !unix
+
+package chess
+
+import "os"
+
+// Without mmap the whole file is read, which is fine for small tables but uses a lot of memory for big DTZ ones
+func mapTableFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package chess
+
+import (
+	"os"
+	"syscall"
+)
+
+// Maps a table file into memory read only, so just the blocks which are probed get read from disk
+// Tables stay mapped for as long as the process runs, as they're shared by every search
+func mapTableFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		return nil, nil // can't be mapped, parse reports it as too short
+	}
+	return syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+}
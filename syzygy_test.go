@@ -0,0 +1,635 @@
+package chess
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Most of these tests write KQvK tables in the Syzygy format from a small retrograde solver, which checks everything but
+// the order positions are indexed in against an independent source
+// TestTablebaseRealTables checks that too, against the KQvK and KPvK tables in testing/syzygy
+
+const kqkPositions = 64 * 64 * 64
+
+const (
+	kqkUnknown = -3
+	kqkIllegal = -2
+	kqkDraw    = -1
+)
+
+// Plies to mate for every KQvK position, by side to move then (whiteKing*64 + whiteQueen)*64 + blackKing
+type kqkSolution [2][kqkPositions]int
+
+func kqkBoard(p int, turn Turn) Board {
+	return Board{whiteKings: 1 << (p / 4096), whiteQueens: 1 << (p / 64 % 64), blackKings: 1 << (p % 64), Turn: turn}
+}
+
+func solveKQK() *kqkSolution {
+	s := &kqkSolution{}
+	var moves [2][][]int32
+
+	for p := range kqkPositions {
+		wk, wq, bk := p/4096, p/64%64, p%64
+		s[0][p], s[1][p] = kqkIllegal, kqkIllegal
+		if wk == wq || wq == bk || wk == bk || kingAttacks[wk]&(1<<bk) != 0 {
+			continue
+		}
+
+		for stm, turn := range []Turn{WhiteTurn, BlackTurn} {
+			b := kqkBoard(p, !turn)
+			if b.InCheck() {
+				continue // the side which just moved is in check
+			}
+			b.Turn = turn
+
+			ms, _ := b.LegalMoves()
+			s[stm][p] = kqkUnknown
+			if len(ms) == 0 {
+				s[stm][p] = kqkDraw
+				if b.InCheck() {
+					s[stm][p] = 0
+				}
+				continue
+			}
+
+			children := make([]int32, 0, len(ms))
+			for _, m := range ms {
+				if m.Capture() != NoCapture {
+					s[stm][p] = kqkDraw
+					break
+				}
+				to := int(m.To())
+				switch {
+				case turn == BlackTurn:
+					children = append(children, int32(wk*4096+wq*64+to))
+				case m.PieceType() == KingType:
+					children = append(children, int32(to*4096+wq*64+bk))
+				default:
+					children = append(children, int32(wk*4096+to*64+bk))
+				}
+			}
+			if moves[stm] == nil {
+				moves[stm] = make([][]int32, kqkPositions)
+			}
+			moves[stm][p] = children
+		}
+	}
+
+	unchanged := 0
+	for n := 1; unchanged < 2; n++ {
+		stm := 1 - n%2
+		changed := false
+		for p := range kqkPositions {
+			if s[stm][p] != kqkUnknown {
+				continue
+			}
+			if stm == 0 {
+				for _, c := range moves[0][p] {
+					if s[1][c] == n-1 {
+						s[0][p] = n
+						changed = true
+						break
+					}
+				}
+				continue
+			}
+
+			longest := -1
+			for _, c := range moves[1][p] {
+				if s[0][c] < 0 {
+					longest = -1
+					break
+				}
+				longest = max(longest, s[0][c])
+			}
+			if longest == n-1 {
+				s[1][p] = n
+				changed = true
+			}
+		}
+		if changed {
+			unchanged = 0
+		} else {
+			unchanged++
+		}
+	}
+
+	for stm := range 2 {
+		for p := range kqkPositions {
+			if s[stm][p] == kqkUnknown {
+				s[stm][p] = kqkDraw
+			}
+		}
+	}
+	return s
+}
+
+type testTBSide struct {
+	flags  byte
+	values []int // by index, nil stores single for every position
+	single int
+}
+
+// A leaf holding value left when right is 0xFFF, otherwise a pair of symbols
+type testTBSymbol struct {
+	left, right int
+}
+
+// Leaves for every value from least to most common, then pairs of the most common value, since shorter codes have to
+// be higher symbols
+// Symbol s has code 1 in n-s bits, except the first two which have codes 0 and 1 in n-1 bits
+func testTBSymbols(values []int) []testTBSymbol {
+	counts := map[int]int{}
+	for _, v := range values {
+		counts[v]++
+	}
+	var leaves []int
+	for v := range counts {
+		leaves = append(leaves, v)
+	}
+	slices.SortFunc(leaves, func(a, b int) int {
+		if counts[a] != counts[b] {
+			return counts[a] - counts[b]
+		}
+		return a - b
+	})
+
+	var syms []testTBSymbol
+	for _, v := range leaves {
+		syms = append(syms, testTBSymbol{v, 0xFFF})
+	}
+	top := len(syms) - 1
+	syms = append(syms, testTBSymbol{top, top}, testTBSymbol{top + 1, top + 1})
+	return syms
+}
+
+func expandTestSymbol(syms []testTBSymbol, s int) []int {
+	if syms[s].right == 0xFFF {
+		return []int{syms[s].left}
+	}
+	return append(expandTestSymbol(syms, syms[s].left), expandTestSymbol(syms, syms[s].right)...)
+}
+
+// Lays out a table for pawnless material with a unique piece, pieces are codes in the order they're indexed
+func buildTestTable(magic [4]byte, split, dtz bool, pieces []int, sides []testTBSide) []byte {
+	const (
+		blockBits = 6
+		spanBits  = 6
+		blockSize = 1 << blockBits
+		span      = 1 << spanBits
+	)
+
+	buf := magic[:]
+	if split {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	buf = append(buf, 0) // the leading group comes first for both sides
+	for _, p := range pieces {
+		buf = append(buf, byte(p|p<<4))
+	}
+	if len(buf)%2 == 1 {
+		buf = append(buf, 0)
+	}
+
+	type compressed struct {
+		blocks  [][]byte
+		lengths []int
+		sparse  []byte
+	}
+	var cs []compressed
+
+	for _, side := range sides {
+		if side.values == nil {
+			buf = append(buf, side.flags|tbFlagSingleValue, byte(side.single))
+			cs = append(cs, compressed{})
+			continue
+		}
+
+		syms := testTBSymbols(side.values)
+		n := len(syms)
+		code := func(s int) (length, code int) {
+			if s < 2 {
+				return n - 1, s
+			}
+			return n - s, 1
+		}
+
+		var (
+			c      compressed
+			starts []int
+		)
+		for i := 0; i < len(side.values); {
+			block, bit, start := make([]byte, blockSize), 0, i
+			for i < len(side.values) {
+				s := len(syms) - 1
+				for ; s >= 0; s-- {
+					exp := expandTestSymbol(syms, s)
+					if i+len(exp) <= len(side.values) && slices.Equal(exp, side.values[i:i+len(exp)]) {
+						break
+					}
+				}
+				l, bits := code(s)
+				if bit+l > blockSize*8-64 {
+					break // leave room for the decoder reading ahead
+				}
+				for j := l - 1; j >= 0; j-- {
+					if bits>>j&1 == 1 {
+						block[bit/8] |= 0x80 >> (bit % 8)
+					}
+					bit++
+				}
+				i += len(expandTestSymbol(syms, s))
+			}
+			c.blocks = append(c.blocks, block)
+			c.lengths = append(c.lengths, i-start-1)
+			starts = append(starts, start)
+		}
+
+		for k := 0; k*span < len(side.values); k++ {
+			mid := k*span + span/2
+			b := len(starts) - 1
+			for starts[b] > mid {
+				b--
+			}
+			c.sparse = binary.LittleEndian.AppendUint32(c.sparse, uint32(b))
+			c.sparse = binary.LittleEndian.AppendUint16(c.sparse, uint16(mid-starts[b]))
+		}
+		cs = append(cs, c)
+
+		buf = append(buf, side.flags, blockBits, spanBits, 0)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(c.blocks)))
+		buf = append(buf, byte(n-1), 1)
+		for i := range n - 1 {
+			lowest := n - 1 - i
+			if i == n-2 {
+				lowest = 0
+			}
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(lowest))
+		}
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(n))
+		for _, sym := range syms {
+			buf = append(buf, byte(sym.left), byte(sym.left>>8&0xF|sym.right&0xF<<4), byte(sym.right>>4))
+		}
+		if n%2 == 1 {
+			buf = append(buf, 0)
+		}
+	}
+	if dtz && len(buf)%2 == 1 {
+		buf = append(buf, 0)
+	}
+
+	for _, c := range cs {
+		buf = append(buf, c.sparse...)
+	}
+	for _, c := range cs {
+		for _, l := range c.lengths {
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(l))
+		}
+	}
+	for _, c := range cs {
+		for len(buf)%64 != 0 {
+			buf = append(buf, 0)
+		}
+		for _, block := range c.blocks {
+			buf = append(buf, block...)
+		}
+	}
+
+	buf = append(buf, make([]byte, 64)...)
+	for len(buf)%64 != 16 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+// Writes KQvK.rtbw and KQvK.rtbz to dir, the DTZ table only stores white to move
+func writeKQKTables(t *testing.T, dir string, s *kqkSolution) {
+	pieces := []int{6, 5, 14} // white king, white queen, black king
+
+	// Tables with a single value work out the index size without needing any data
+	layout := func(dtz bool) *tbTable {
+		table, ok := newTBTable("KQvK")
+		require.True(t, ok)
+		table.dtz = dtz
+		sides := []testTBSide{{}, {}}
+		if dtz {
+			sides = sides[:1]
+		}
+		magic := wdlMagic
+		if dtz {
+			magic = dtzMagic
+		}
+		require.NoError(t, table.parse(buildTestTable(magic, true, dtz, pieces, sides)))
+		return table
+	}
+
+	fill := func(table *tbTable, side int, defaultValue int, value func(stm, p int) (int, bool)) []int {
+		d := table.pairs[side][0]
+		values := make([]int, d.groupIdx[1])
+		set := make([]bool, len(values))
+		for p := range kqkPositions {
+			v, ok := value(side, p)
+			if !ok {
+				continue
+			}
+			b := kqkBoard(p, side == 1)
+			_, _, idx, _ := table.index(&b, b.materialCode())
+			if set[idx] && values[idx] != v {
+				t.Fatalf("positions with different values share index %d", idx)
+			}
+			values[idx], set[idx] = v, true
+		}
+		for i := range values {
+			if !set[i] {
+				values[i] = defaultValue
+			}
+		}
+		return values
+	}
+
+	wdl := layout(false)
+	wdlValue := func(stm, p int) (int, bool) {
+		switch v := s[stm][p]; {
+		case v == kqkIllegal:
+			return 0, false
+		case v == kqkDraw:
+			return int(WDLDraw) + 2, true
+		case stm == 0:
+			return int(WDLWin) + 2, true
+		default:
+			return int(WDLLoss) + 2, true
+		}
+	}
+	data := buildTestTable(wdlMagic, true, false, pieces, []testTBSide{
+		{values: fill(wdl, 0, 4, wdlValue)},
+		{values: fill(wdl, 1, 0, wdlValue)},
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "KQvK.rtbw"), data, 0o644))
+
+	// Stored in moves, which is rounded down, white's mates always take an odd number of plies
+	dtz := layout(true)
+	dtzValue := func(stm, p int) (int, bool) {
+		if s[0][p] < 0 {
+			return 0, false
+		}
+		return (s[0][p] - 1) / 2, true
+	}
+	data = buildTestTable(dtzMagic, true, true, pieces, []testTBSide{{values: fill(dtz, 0, 0, dtzValue)}})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "KQvK.rtbz"), data, 0o644))
+}
+
+// Swaps the colours of a KQvK board, so black has the queen
+func flipKQK(b Board) Board {
+	flip := func(bb uint64) uint64 {
+		return 1 << (bits.TrailingZeros64(bb) ^ 56)
+	}
+	return Board{
+		whiteKings:  flip(b.blackKings),
+		blackKings:  flip(b.whiteKings),
+		blackQueens: flip(b.whiteQueens),
+		Turn:        !b.Turn,
+	}
+}
+
+// Checks every step'th KQvK position probes as the solver says, with the colours either way round
+func checkKQvK(t *testing.T, tb *Tablebase, s *kqkSolution, step int) {
+	for p := 0; p < kqkPositions; p += step {
+		for stm := range 2 {
+			dtm := s[stm][p]
+			if dtm == kqkIllegal {
+				continue
+			}
+			b := kqkBoard(p, stm == 1)
+
+			wantWDL, wantDTZ := WDLDraw, 0
+			switch {
+			case dtm == kqkDraw:
+			case stm == 0:
+				wantWDL, wantDTZ = WDLWin, dtm
+			default:
+				wantWDL, wantDTZ = WDLLoss, -max(dtm, 1)
+			}
+
+			for _, b := range []Board{b, flipKQK(b)} {
+				wdl, err := tb.ProbeWDL(&b)
+				require.NoError(t, err)
+				dtz, err := tb.ProbeDTZ(&b)
+				require.NoError(t, err)
+				if wdl != wantWDL || dtz != wantDTZ {
+					t.Fatalf("%s\ngot %v with DTZ %d, expected %v with DTZ %d", b.String(), wdl, dtz, wantWDL, wantDTZ)
+				}
+			}
+		}
+	}
+}
+
+func TestTablebaseKQvK(t *testing.T) {
+	s := solveKQK()
+	assert.Equal(t, 19, slices.Max(s[0][:]), "longest KQvK mate")
+
+	dir := t.TempDir()
+	writeKQKTables(t, dir, s)
+	tb, err := LoadTablebase(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, tb.Cardinality())
+
+	t.Run("WDL and DTZ", func(t *testing.T) {
+		checkKQvK(t, tb, s, 7)
+	})
+
+	t.Run("root", func(t *testing.T) {
+		b, err := BoardFromFEN("8/8/8/4k3/8/8/8/KQ6 w - - 0 1")
+		require.NoError(t, err)
+		moves, err := tb.ProbeRoot(&b)
+		require.NoError(t, err)
+		ms, _ := b.LegalMoves()
+		require.Len(t, moves, len(ms))
+
+		for i, m := range moves {
+			b.Move(m.Move)
+			dtz, err := tb.ProbeDTZ(&b)
+			b.Unmove()
+			require.NoError(t, err)
+
+			if dtz == 0 {
+				assert.Equal(t, WDLDraw, m.WDL, m.Move.String())
+				assert.Zero(t, m.DTZ, m.Move.String())
+			} else {
+				assert.Equal(t, WDLWin, m.WDL, m.Move.String())
+				assert.Equal(t, -dtz+1, m.DTZ, m.Move.String())
+			}
+			if i > 0 {
+				assert.True(t, moves[i-1].Rank > m.Rank || (moves[i-1].Rank == m.Rank && moves[i-1].DTZ <= m.DTZ))
+			}
+		}
+	})
+}
+
+func TestTablebaseErrors(t *testing.T) {
+	_, err := LoadTablebase(t.TempDir())
+	assert.ErrorIs(t, err, ErrInvalidTablebase)
+
+	_, err = LoadTablebase(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "KRvK.rtbw"), make([]byte, 80), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644))
+	tb, err := LoadTablebase(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, tb.Cardinality())
+
+	probe := func(fen string) error {
+		b, err := BoardFromFEN(fen)
+		require.NoError(t, err)
+		_, err = tb.ProbeWDL(&b)
+		return err
+	}
+	assert.ErrorIs(t, probe("8/8/8/4k3/8/8/8/KR6 w - - 0 1"), ErrInvalidTablebase, "corrupt table")
+	assert.ErrorIs(t, probe("8/8/8/4k3/8/8/8/KQ6 w - - 0 1"), ErrNotInTablebase, "missing table")
+	assert.ErrorIs(t, probe("8/8/8/4k3/8/8/8/KQ5R w - - 0 1"), ErrNotInTablebase, "too many pieces")
+	assert.ErrorIs(t, probe("4k3/8/8/8/8/8/8/4K2R w K - 0 1"), ErrNotInTablebase, "castling")
+
+	b, err := BoardFromFEN("8/8/8/4k3/8/8/8/K7 w - - 0 1")
+	require.NoError(t, err)
+	wdl, err := tb.ProbeWDL(&b)
+	require.NoError(t, err)
+	assert.Equal(t, WDLDraw, wdl)
+}
+
+func TestTablebaseNames(t *testing.T) {
+	table, ok := newTBTable("KRPvKR")
+	require.True(t, ok)
+	assert.Equal(t, "KRPvKR", table.key)
+	assert.Equal(t, "KRvKRP", table.key2)
+	assert.Equal(t, 5, table.pieceCount)
+	assert.True(t, table.hasPawns)
+	assert.Equal(t, [2]int{1, 0}, table.pawnCount)
+
+	table, ok = newTBTable("KPPvKP")
+	require.True(t, ok)
+	assert.Equal(t, [2]int{1, 2}, table.pawnCount, "the side with fewer pawns leads")
+
+	table, ok = newTBTable("KRRvK")
+	require.True(t, ok)
+	assert.False(t, table.hasUniquePieces)
+
+	for _, name := range []string{"KRvKR.part", "KRK", "RvK", "KKvK", "KXvK"} {
+		_, ok := newTBTable(name)
+		assert.False(t, ok, name)
+	}
+
+	b, err := BoardFromFEN("8/8/2n5/3k4/8/8/P3R3/K7 w - - 0 1")
+	require.NoError(t, err)
+	assert.Equal(t, "KRPvKN", b.materialCode())
+
+	// Every legal placement of the kings has its own code
+	codes := map[int]bool{}
+	for idx := range 10 {
+		for s1 := range 28 {
+			if tbMapA1D1D4[s1] != idx || (idx == 0 && s1 != 1) {
+				continue
+			}
+			for s2 := range 64 {
+				if abs(tbRank(s1)-tbRank(s2)) > 1 || abs(tbFile(s1)-tbFile(s2)) > 1 {
+					if offA1H8(s1) != 0 || offA1H8(s2) <= 0 {
+						codes[tbMapKK[idx][s2]] = true
+					}
+				}
+			}
+		}
+	}
+	assert.Len(t, codes, 462)
+}
+
+func TestRootRank(t *testing.T) {
+	assert.Equal(t, tbMaxDTZ, rootRank(5, 90))
+	assert.Equal(t, tbMaxDTZ-100, rootRank(5, 95), "can't be won before the 50 move rule")
+	assert.Less(t, rootRank(7, 95), rootRank(5, 95))
+	assert.Equal(t, 0, rootRank(0, 0))
+	assert.Equal(t, -tbMaxDTZ, rootRank(-5, 80))
+	assert.Greater(t, rootRank(-20, 80), rootRank(-5, 80), "the opponent runs out of moves")
+}
+
+// Probes the real KQvK and KPvK tables, which were made by the Syzygy generator, so unlike the tables written above
+// they check the index order as well, for pawnless and pawn tables
+func TestTablebaseRealTables(t *testing.T) {
+	const dir = "testing/syzygy"
+	for _, name := range []string{"KQvK.rtbw", "KQvK.rtbz", "KPvK.rtbw", "KPvK.rtbz"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Skipf("%s is missing, the 3 piece tables are available from https://tablebase.lichess.ovh/tables/standard/3-4-5/", name)
+		}
+	}
+	tb, err := LoadTablebase(dir)
+	require.NoError(t, err)
+
+	t.Run("KQvK", func(t *testing.T) {
+		checkKQvK(t, tb, solveKQK(), 1)
+	})
+
+	// The WDL result is checked against the KPK bitbase, and the DTZ against the WDL result and the rule that a win
+	// by a pawn move has a DTZ of 1
+	t.Run("KPvK", func(t *testing.T) {
+		for wk := range 64 {
+			for pawn := 8; pawn < 56; pawn++ {
+				for bk := range 64 {
+					if wk == pawn || pawn == bk || wk == bk || kingAttacks[wk]&(1<<bk) != 0 {
+						continue
+					}
+					for _, turn := range []Turn{WhiteTurn, BlackTurn} {
+						b := Board{whiteKings: 1 << wk, whitePawns: 1 << pawn, blackKings: 1 << bk, Turn: !turn}
+						if b.InCheck() {
+							continue // the side which just moved is in check
+						}
+						b.Turn = turn
+						ms, status := b.LegalMoves()
+						if status != InProgress {
+							continue
+						}
+
+						wantWDL := WDLDraw
+						if kpkProbe(turn == BlackTurn, wk, pawn, bk) {
+							wantWDL = WDLWin
+							if turn == BlackTurn {
+								wantWDL = WDLLoss
+							}
+						}
+						wdl, err := tb.ProbeWDL(&b)
+						require.NoError(t, err)
+						dtz, err := tb.ProbeDTZ(&b)
+						require.NoError(t, err)
+						if wdl != wantWDL || sign(dtz) != sign(int(wdl)) {
+							t.Fatalf("%s\ngot %v with DTZ %d, expected %v", b.String(), wdl, dtz, wantWDL)
+						}
+
+						if wdl == WDLWin {
+							pawnWins := slices.ContainsFunc(ms, func(m Move) bool {
+								// Underpromotions would need the KRvK, KBvK and KNvK tables, so only queen promotions are tried
+								if m.PieceType() != PawnType || m.Promotion() != NoPromotion && m.Promotion() != QueenPromotion {
+									return false
+								}
+								b.Move(m)
+								defer b.Unmove()
+								wdl, err := tb.ProbeWDL(&b)
+								require.NoError(t, err)
+								return wdl == WDLLoss
+							})
+							if pawnWins && dtz != 1 {
+								t.Fatalf("%s\nDTZ %d, but a winning pawn move is %v", b.String(), dtz, pawnWins)
+							}
+						}
+					}
+				}
+			}
+		}
+	})
+}
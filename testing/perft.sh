@@ -10,7 +10,7 @@ if [ "$#" -ne 2 ] && [ "$#" -ne 3 ]; then
 fi
 
 if [ "$#" -eq 2 ]; then
-  go run "$SCRIPT_DIR/../cmd/chess.go" perft "$1" "$2"
+  go run "$SCRIPT_DIR/../cmd" perft "$1" "$2"
 else
-  go run "$SCRIPT_DIR/../cmd/chess.go" perft "$1" "$2" "$3"
+  go run "$SCRIPT_DIR/../cmd" perft "$1" "$2" "$3"
 fi
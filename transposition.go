@@ -42,3 +42,8 @@ func (tt *TranspositionTable) Save(t Transposition) {
 	i := (t.Key & tt.mask)
 	tt.entries[i] = t
 }
+
+// Forgets every entry, e.g. between games so one can't affect the next
+func (tt *TranspositionTable) Clear() {
+	clear(tt.entries)
+}
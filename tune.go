@@ -0,0 +1,236 @@
+package chess
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInvalidTuningData = errors.New("Invalid tuning data")
+)
+
+// A quiet position labelled with the result of the game it came from
+type TuningPosition struct {
+	Board  Board
+	Result float64 // 1 for a white win, 0.5 for a draw, 0 for a black win
+}
+
+// Parses a tuning dataset, one position per line, in any of these formats
+//
+//	rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - c9 "1/2-1/2";
+//	rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1;0.5
+//	rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1 [0.5]
+//	rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1 | -25 | 0.5
+//
+// Results can be given as 1-0, 0-1 and 1/2-1/2, or as a number from white's point of view
+// Blank lines and lines starting with # are ignored
+func ParseTuningData(r io.Reader) ([]TuningPosition, error) {
+	var ps []TuningPosition
+
+	s := bufio.NewScanner(r)
+	line := 0
+	for s.Scan() {
+		line++
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var fen, result string
+		switch {
+		case strings.Contains(text, " c9 "):
+			fields := strings.Fields(text)
+			fen = strings.Join(fields[:min(4, len(fields))], " ")
+			_, result, _ = strings.Cut(text, " c9 ")
+			result, _, _ = strings.Cut(result, ";")
+			result = strings.Trim(strings.TrimSpace(result), `"`)
+		case strings.Contains(text, "|"): // fen | score | result, as written by TrainingPosition.WriteText
+			fields := strings.Split(text, "|")
+			fen, result = fields[0], fields[len(fields)-1]
+		case strings.Contains(text, ";"):
+			fen, result, _ = strings.Cut(text, ";")
+		case strings.HasSuffix(text, "]"):
+			i := strings.LastIndex(text, "[")
+			if i == -1 {
+				return nil, fmt.Errorf("%w: line %d: no result", ErrInvalidTuningData, line)
+			}
+			fen, result = text[:i], text[i+1:len(text)-1]
+		default:
+			return nil, fmt.Errorf("%w: line %d: no result", ErrInvalidTuningData, line)
+		}
+
+		b, err := BoardFromFEN(strings.TrimSpace(fen))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidTuningData, line, err)
+		}
+
+		p := TuningPosition{Board: b}
+		switch result = strings.TrimSpace(result); result {
+		case "1-0":
+			p.Result = 1
+		case "0-1":
+			p.Result = 0
+		case "1/2-1/2":
+			p.Result = 0.5
+		default:
+			p.Result, err = strconv.ParseFloat(result, 64)
+			if err != nil || p.Result < 0 || p.Result > 1 {
+				return nil, fmt.Errorf("%w: line %d: cannot parse result %q", ErrInvalidTuningData, line, result)
+			}
+		}
+
+		ps = append(ps, p)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+// Returns a pointer to every weight, in field order, so they can be adjusted generically
+func (ep *EvalParams) Weights() []*int {
+	var ws []*int
+
+	var collect func(v reflect.Value)
+	collect = func(v reflect.Value) {
+		if v.Kind() == reflect.Int {
+			ws = append(ws, v.Addr().Interface().(*int))
+			return
+		}
+		for i := range v.Len() {
+			collect(v.Index(i))
+		}
+	}
+
+	v := reflect.ValueOf(ep).Elem()
+	for i := range v.NumField() {
+		collect(v.Field(i))
+	}
+	return ws
+}
+
+// Maps a centipawn score from white's point of view to an expected result
+func sigmoid(score, k float64) float64 {
+	return 1 / (1 + math.Pow(10, -k*score/400))
+}
+
+// Mean squared error between the game results and the results predicted by evaluating each position with ep
+// Positions are split across workers goroutines
+func TuningError(ps []TuningPosition, ep *EvalParams, k float64, workers int) float64 {
+	if len(ps) == 0 {
+		return 0
+	}
+	workers = max(1, min(workers, len(ps)))
+
+	sums := make([]float64, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// The pawn table is left empty, since ep changes between calls
+			e := Engine{EP: *ep}
+			for i := w; i < len(ps); i += workers {
+				e.B = ps[i].Board
+				score := e.Evaluate()
+				if e.B.Turn == BlackTurn {
+					score = -score
+				}
+				diff := ps[i].Result - sigmoid(float64(score), k)
+				sums[w] += diff * diff
+			}
+		}()
+	}
+	wg.Wait()
+
+	var sum float64
+	for _, s := range sums {
+		sum += s
+	}
+	return sum / float64(len(ps))
+}
+
+// Finds the scaling constant K which minimises the error for the current parameters, to within 0.0001
+func FitK(ps []TuningPosition, ep *EvalParams, workers int) float64 {
+	lo, hi := 0.0, 4.0
+	for hi-lo > 0.0001 {
+		// Golden section search, the error is unimodal in K
+		a := hi - (hi-lo)/math.Phi
+		b := lo + (hi-lo)/math.Phi
+		if TuningError(ps, ep, a, workers) < TuningError(ps, ep, b, workers) {
+			hi = b
+		} else {
+			lo = a
+		}
+	}
+	return (lo + hi) / 2
+}
+
+type TuneOptions struct {
+	K          float64 // 0 fits it before tuning
+	Step       int     // amount each weight is nudged by, defaults to 1
+	Iterations int     // maximum passes over every weight, 0 means until nothing improves
+	Workers    int     // defaults to runtime.NumCPU()
+
+	// Called after every pass, with the parameters so far
+	Progress func(iteration int, err float64, ep EvalParams)
+}
+
+// Texel's tuning method, a local search which nudges each weight up and down, keeping any change that lowers the error
+// Returns the tuned parameters and the K they were tuned with
+func Tune(ps []TuningPosition, start EvalParams, opts TuneOptions) (EvalParams, float64) {
+	if opts.Step == 0 {
+		opts.Step = 1
+	}
+	if opts.Workers == 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+
+	ep := start
+	k := opts.K
+	if k == 0 {
+		k = FitK(ps, &ep, opts.Workers)
+	}
+
+	best := TuningError(ps, &ep, k, opts.Workers)
+	weights := ep.Weights()
+
+	for iteration := 1; opts.Iterations == 0 || iteration <= opts.Iterations; iteration++ {
+		improved := false
+
+		for _, w := range weights {
+			for _, delta := range []int{opts.Step, -opts.Step} {
+				*w += delta
+				// Some weights are indexes, which mustn't be stepped out of range
+				if ep.Validate() == nil {
+					if err := TuningError(ps, &ep, k, opts.Workers); err < best {
+						best = err
+						improved = true
+						break
+					}
+				}
+				*w -= delta
+			}
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(iteration, best, ep)
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return ep, k
+}
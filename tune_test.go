@@ -0,0 +1,111 @@
+package chess_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+func TestParseTuningData(t *testing.T) {
+	data := `# comment
+rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - c9 "1/2-1/2";
+rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - id "x"; c9 "1-0";
+
+4k3/8/8/8/8/8/8/3QK3 w - - 0 1;1-0
+4k3/8/8/8/8/8/8/3qK3 w - - 0 1;0
+4k3/8/8/8/8/8/8/3qK3 w - - 0 1 [0.5]
+4k3/8/8/8/8/8/8/3QK3 b - - 3 40 | 880 | 1.0
+`
+	ps, err := chess.ParseTuningData(strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, ps, 6)
+
+	results := []float64{}
+	for _, p := range ps {
+		results = append(results, p.Result)
+	}
+	assert.Equal(t, []float64{0.5, 1, 1, 0, 0.5, 1}, results)
+	assert.Equal(t, chess.BlackTurn, ps[0].Board.Turn)
+
+	for _, bad := range []string{
+		"4k3/8/8/8/8/8/8/3QK3 w - - 0 1",
+		"4k3/8/8/8/8/8/8/3QK3 w - - 0 1;2",
+		"4k3/8/8/8/8/8/8/3QK3 w - - 0 1;win",
+		"4k3/8/8/8/8/8/8/3QKQ3 w - - 0 1;1-0",
+	} {
+		_, err := chess.ParseTuningData(strings.NewReader(bad))
+		assert.ErrorIs(t, err, chess.ErrInvalidTuningData, bad)
+	}
+}
+
+func TestWeights(t *testing.T) {
+	ep := chess.DefaultParams
+	ws := ep.Weights()
+
+	*ws[0] = 42
+	assert.Equal(t, 42, ep.PawnWt[chess.MG])
+
+	*ws[len(ws)-1] = 43
+	assert.Equal(t, 43, ep.TrappedRook[chess.EG])
+}
+
+func tuningData(t *testing.T) []chess.TuningPosition {
+	t.Helper()
+	ps, err := chess.ParseTuningData(strings.NewReader(`
+4k3/pppp4/8/8/8/8/PPPPP3/4K3 w - - 0 1;1-0
+4k3/ppppp3/8/8/8/8/PPPP4/4K3 w - - 0 1;0-1
+4k3/pppp4/8/8/8/8/PPPP4/4K3 w - - 0 1;1/2-1/2
+4k3/ppp5/8/8/8/8/PPPPP3/4K3 b - - 0 1;1-0
+4k3/pppp4/8/8/8/8/PPPPP3/4K3 b - - 0 1;1/2-1/2
+4k3/8/8/8/8/8/3N4/4K3 w - - 0 1;1/2-1/2
+4k3/8/8/8/8/8/3Q4/4K3 w - - 0 1;1-0
+4k3/3r4/8/8/8/8/8/4K3 w - - 0 1;0-1
+`))
+	require.NoError(t, err)
+	return ps
+}
+
+func TestFitK(t *testing.T) {
+	ps := tuningData(t)
+	ep := chess.DefaultParams
+
+	k := chess.FitK(ps, &ep, 2)
+	assert.Greater(t, k, 0.0)
+
+	best := chess.TuningError(ps, &ep, k, 2)
+	assert.LessOrEqual(t, best, chess.TuningError(ps, &ep, k*0.9, 2))
+	assert.LessOrEqual(t, best, chess.TuningError(ps, &ep, k*1.1, 2))
+
+	// Splitting the work shouldn't change the answer
+	assert.InDelta(t, best, chess.TuningError(ps, &ep, k, 1), 1e-12)
+}
+
+func TestTune(t *testing.T) {
+	ps := tuningData(t)
+	start := chess.DefaultParams
+
+	passes := 0
+	tuned, k := chess.Tune(ps, start, chess.TuneOptions{
+		K:          1,
+		Step:       5,
+		Iterations: 2,
+		Workers:    2,
+		Progress:   func(int, float64, chess.EvalParams) { passes++ },
+	})
+
+	assert.Equal(t, 1.0, k)
+	assert.Equal(t, 2, passes)
+	assert.Less(t, chess.TuningError(ps, &tuned, k, 2), chess.TuningError(ps, &start, k, 2))
+	assert.Equal(t, chess.DefaultParams, start, "starting parameters shouldn't be modified")
+}
+
+// Stepping the king attack weights below zero would make evaluation index out of range
+func TestTuneIndexWeights(t *testing.T) {
+	ps, err := chess.ParseTuningData(strings.NewReader("r5k1/ppp2pp1/6N1/8/8/8/PPP2PP1/4K2R w - - 0 1;0-1\n"))
+	require.NoError(t, err)
+	tuned, _ := chess.Tune(ps, chess.DefaultParams, chess.TuneOptions{K: 1, Step: 5, Iterations: 1, Workers: 1})
+	assert.NoError(t, tuned.Validate())
+}
@@ -0,0 +1,289 @@
+package chess
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUCI        = errors.New("UCI engine error")
+	ErrUCITimeout = errors.New("UCI engine didn't respond in time")
+)
+
+// Talks to an external engine over the Universal Chess Interface
+type UCIEngine struct {
+	name  string
+	w     io.Writer
+	lines chan string // closed once the engine's output ends
+	cmd   *exec.Cmd   // nil unless started by StartUCIEngine
+
+	// How long to wait for a reply, on top of the time given for a search
+	Timeout time.Duration
+}
+
+// Starts the engine at path as a subprocess and waits until it's ready
+func StartUCIEngine(path string, args ...string) (*UCIEngine, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	u, err := NewUCIEngine(stdout, stdin)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	u.cmd = cmd
+	return u, nil
+}
+
+// Performs the UCI handshake with an engine which reads commands from w and replies on r
+func NewUCIEngine(r io.Reader, w io.Writer) (*UCIEngine, error) {
+	u := &UCIEngine{w: w, lines: make(chan string, 64), Timeout: 5 * time.Second}
+	go func() {
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			u.lines <- strings.TrimSpace(s.Text())
+		}
+		close(u.lines)
+	}()
+
+	if err := u.send("uci"); err != nil {
+		return nil, err
+	}
+	for {
+		line, err := u.readLine(u.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		if name, ok := strings.CutPrefix(line, "id name "); ok {
+			u.name = name
+		}
+		if line == "uciok" {
+			return u, nil
+		}
+	}
+}
+
+func (u *UCIEngine) send(command string) error {
+	if _, err := io.WriteString(u.w, command+"\n"); err != nil {
+		return fmt.Errorf("%w: %w", ErrUCI, err)
+	}
+	return nil
+}
+
+func (u *UCIEngine) readLine(timeout time.Duration) (string, error) {
+	select {
+	case line, ok := <-u.lines:
+		if !ok {
+			return "", fmt.Errorf("%w: engine exited", ErrUCI)
+		}
+		return line, nil
+	case <-time.After(timeout):
+		return "", ErrUCITimeout
+	}
+}
+
+// Waits until the engine has finished dealing with everything sent so far
+func (u *UCIEngine) sync() error {
+	if err := u.send("isready"); err != nil {
+		return err
+	}
+	for {
+		line, err := u.readLine(u.Timeout)
+		if err != nil {
+			return err
+		}
+		if line == "readyok" {
+			return nil
+		}
+	}
+}
+
+// Returns the name the engine gave itself
+func (u *UCIEngine) Name() string {
+	return u.name
+}
+
+func (u *UCIEngine) SetOption(name, value string) error {
+	if err := u.send("setoption name " + name + " value " + value); err != nil {
+		return err
+	}
+	return u.sync()
+}
+
+func (u *UCIEngine) NewGame() error {
+	if err := u.send("ucinewgame"); err != nil {
+		return err
+	}
+	return u.sync()
+}
+
+// Returns the UCI position command for b, which lists every move from the position it started in
+func uciPosition(b *Board) string {
+	start := b.Copy()
+	for len(start.Moves) > 0 {
+		start.Unmove()
+	}
+
+	var s strings.Builder
+	if fen := start.FEN(); fen == startFEN {
+		s.WriteString("position startpos")
+	} else {
+		s.WriteString("position fen " + fen)
+	}
+	if len(b.Moves) > 0 {
+		s.WriteString(" moves")
+		for _, m := range b.Moves {
+			s.WriteString(" " + m.String())
+		}
+	}
+	return s.String()
+}
+
+func uciGo(l SearchLimits) string {
+	s := "go"
+	for _, p := range []struct {
+		name  string
+		value int64
+	}{
+		{"wtime", l.WhiteTime.Milliseconds()},
+		{"btime", l.BlackTime.Milliseconds()},
+		{"winc", l.WhiteInc.Milliseconds()},
+		{"binc", l.BlackInc.Milliseconds()},
+		{"movestogo", int64(l.MovesToGo)},
+		{"movetime", l.MoveTime.Milliseconds()},
+		{"depth", int64(l.Depth)},
+		{"nodes", int64(l.Nodes)},
+	} {
+		if p.value > 0 {
+			s += " " + p.name + " " + strconv.FormatInt(p.value, 10)
+		}
+	}
+	if s == "go" {
+		s += " depth 1" // the same as Engine.SearchWith does without any limits
+	}
+	return s
+}
+
+// Reads a search's score, depth and node count from an info line into r
+func parseUCIInfo(line string, r *SearchResult) {
+	fields := strings.Fields(line)
+	for i := 1; i+1 < len(fields); i++ {
+		n, err := strconv.Atoi(fields[i+1])
+		switch fields[i] {
+		case "depth":
+			if err == nil {
+				r.Depth = n
+			}
+		case "nodes":
+			if err == nil {
+				r.Nodes = n
+			}
+		case "score":
+			if i+2 >= len(fields) {
+				continue
+			}
+			v, err := strconv.Atoi(fields[i+2])
+			if err != nil {
+				continue
+			}
+			switch fields[i+1] {
+			case "cp":
+				r.Score = v
+			case "mate":
+				r.Score = MateScore(v)
+			}
+		}
+	}
+}
+
+// Asks the engine for its move in b, which must be in progress
+// Gives up with ErrUCITimeout if the reply takes longer than the time available plus Timeout
+func (u *UCIEngine) Go(b *Board, l SearchLimits) (SearchResult, error) {
+	start := time.Now()
+	if err := u.send(uciPosition(b)); err != nil {
+		return SearchResult{}, err
+	}
+	if err := u.send(uciGo(l)); err != nil {
+		return SearchResult{}, err
+	}
+
+	wait := u.Timeout + l.MoveTime + l.WhiteTime
+	if b.Turn == BlackTurn {
+		wait = u.Timeout + l.MoveTime + l.BlackTime
+	}
+	deadline := start.Add(wait)
+	if l.MoveTime == 0 && l.WhiteTime == 0 && l.BlackTime == 0 {
+		deadline = time.Time{} // only limited by depth or nodes, which could take any amount of time
+	}
+
+	var r SearchResult
+	for {
+		timeout := time.Until(deadline)
+		if deadline.IsZero() {
+			timeout = time.Duration(1<<63 - 1)
+		}
+		line, err := u.readLine(timeout)
+		if errors.Is(err, ErrUCITimeout) {
+			u.send("stop") // its late reply is skipped over by the next sync
+		}
+		if err != nil {
+			return SearchResult{}, err
+		}
+
+		if strings.HasPrefix(line, "info ") {
+			parseUCIInfo(line, &r)
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "bestmove" {
+			continue
+		}
+
+		m, err := b.ParseMove(fields[1])
+		if err != nil {
+			return SearchResult{}, fmt.Errorf("%w: bestmove %s: %w", ErrUCI, fields[1], err)
+		}
+		r.Move = m
+		r.Time = time.Since(start)
+		return r, nil
+	}
+}
+
+// Asks the engine to quit, killing it if it was started by StartUCIEngine and doesn't
+func (u *UCIEngine) Close() error {
+	u.send("quit")
+	go func() {
+		for range u.lines { // lets the reader finish
+		}
+	}()
+	if u.cmd == nil {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- u.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(u.Timeout):
+		u.cmd.Process.Kill()
+		return <-done
+	}
+}
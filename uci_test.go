@@ -0,0 +1,113 @@
+package chess_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zakkbob/chess"
+)
+
+// Plays the first legal move in every position, recording the commands it was sent
+type fakeUCIEngine struct {
+	commands []string
+	silent   bool          // never replies to go
+	done     chan struct{} // closed once it quits
+}
+
+func (f *fakeUCIEngine) run(r io.Reader, w io.WriteCloser) {
+	defer close(f.done)
+	defer w.Close()
+	var b chess.Board
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		f.commands = append(f.commands, line)
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "uci":
+			fmt.Fprintln(w, "id name Fake 1.0\nid author nobody\nuciok")
+		case "isready":
+			fmt.Fprintln(w, "readyok")
+		case "position":
+			b = chess.NewBoard()
+			moves := 2
+			if fields[1] == "fen" {
+				b, _ = chess.BoardFromFEN(strings.Join(fields[2:8], " "))
+				moves = 8
+			}
+			for _, m := range fields[min(moves+1, len(fields)):] {
+				b.TryMove(m)
+			}
+		case "go":
+			if f.silent {
+				continue
+			}
+			ms, _ := b.LegalMoves()
+			fmt.Fprintln(w, "info depth 1 score cp 12 nodes 30\ninfo depth 2 score mate -3 nodes 300 pv", ms[0].String())
+			fmt.Fprintln(w, "bestmove", ms[0].String(), "ponder a1a1")
+		case "quit":
+			return
+		}
+	}
+}
+
+func startFakeUCIEngine(t *testing.T, f *fakeUCIEngine) *chess.UCIEngine {
+	t.Helper()
+	commandsR, commandsW := io.Pipe()
+	repliesR, repliesW := io.Pipe()
+	f.done = make(chan struct{})
+	go f.run(commandsR, repliesW)
+
+	u, err := chess.NewUCIEngine(repliesR, commandsW)
+	require.NoError(t, err)
+	return u
+}
+
+func TestUCIEngine(t *testing.T) {
+	f := &fakeUCIEngine{}
+	u := startFakeUCIEngine(t, f)
+	assert.Equal(t, "Fake 1.0", u.Name())
+	require.NoError(t, u.SetOption("Hash", "16"))
+	require.NoError(t, u.NewGame())
+
+	b := chess.NewBoard()
+	playMoves(t, &b, "e4 e5")
+	r, err := u.Go(&b, chess.SearchLimits{WhiteTime: 2 * time.Second, BlackTime: time.Second, WhiteInc: 100 * time.Millisecond})
+	require.NoError(t, err)
+	ms, _ := b.LegalMoves()
+	assert.Equal(t, ms[0], r.Move)
+	assert.Equal(t, 2, r.Depth)
+	assert.Equal(t, 300, r.Nodes)
+	assert.Equal(t, chess.MateScore(-3), r.Score)
+
+	b, err = chess.BoardFromFEN("4k3/8/8/8/8/8/4P3/4K3 b - - 0 30")
+	require.NoError(t, err)
+	_, err = u.Go(&b, chess.SearchLimits{Depth: 3})
+	require.NoError(t, err)
+	require.NoError(t, u.Close())
+	<-f.done
+
+	assert.Equal(t, []string{
+		"uci",
+		"setoption name Hash value 16", "isready",
+		"ucinewgame", "isready",
+		"position startpos moves e2e4 e7e5", "go wtime 2000 btime 1000 winc 100",
+		"position fen 4k3/8/8/8/8/8/4P3/4K3 b - - 0 30", "go depth 3",
+		"quit",
+	}, f.commands)
+}
+
+func TestUCIEngineTimeout(t *testing.T) {
+	u := startFakeUCIEngine(t, &fakeUCIEngine{silent: true})
+	u.Timeout = 50 * time.Millisecond
+
+	b := chess.NewBoard()
+	_, err := u.Go(&b, chess.SearchLimits{MoveTime: 10 * time.Millisecond})
+	assert.ErrorIs(t, err, chess.ErrUCITimeout)
+}